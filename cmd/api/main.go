@@ -2,15 +2,36 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal"
+	archives "github.com/David/Boxed/internal/archives/services"
+	files "github.com/David/Boxed/internal/files/services"
+	jobs "github.com/David/Boxed/internal/jobs/services"
+	uploads "github.com/David/Boxed/internal/uploads/services"
 )
 
 func main() {
 	singleton := boxed.GetInstance()
 	defer singleton.DbConn.Close()
 
+	// Remove abandoned resumable uploads
+	uploads.ReapExpiredUploads(singleton.DbConn)
+	uploads.StartUploadReaper(singleton.DbConn, time.Hour)
+	// Empty the trash of files older than the retention
+	files.PurgeExpiredTrash(singleton.DbConn, singleton.TrashRetention)
+	files.StartTrashPurger(singleton.DbConn, singleton.TrashRetention, time.Hour)
+	// Run the background jobs
+	jobs.Register(files.ThumbnailJobKind, files.ThumbnailJobHandler)
+	jobs.Register(files.MediaJobKind, files.MediaJobHandler)
+	jobs.Register(files.TextJobKind, files.TextJobHandler)
+	jobs.Register(files.PreviewJobKind, files.PreviewJobHandler)
+	jobs.Register(files.StreamJobKind, files.StreamJobHandler)
+	jobs.StartWorkers(singleton.DbConn, 2, 5*time.Second)
+	// Extractions don't survive a restart
+	archives.FailInterruptedExtractions(singleton.DbConn)
+
 	// It setups the controllers and then start the server
 	server := internal.SetupControllers()
 	server.Start(fmt.Sprintf(":%v", singleton.BackendPort))
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/albums/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// AddAlbumFilesController appends `files` at the end of the album given in the `:uuid` path parameter, in the
+// given order. Files already in the album keep their place.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated album as JSON.
+//   - Responds with HTTP 400 (Bad Request) if a uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the album or a file belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the album or a file doesn't exist.
+func AddAlbumFilesController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	album, err := ownedAlbumFromRequest(c)
+	if album == nil {
+		return err
+	}
+	fileIDs, err := albumFilesFromBody(c)
+	if fileIDs == nil {
+		return err
+	}
+	db := boxed.GetInstance().DbConn
+	if err := services.AddAlbumFiles(db, album, fileIDs); err != nil {
+		return albumError(c, err, "One of the `files` doesn't exist or is in the trash.")
+	}
+	updated, err := repositories.NewAlbumsRepo(db).GetByID(album.ID)
+	if err != nil {
+		return albumError(c, err, fmt.Sprintf("There is no album with id %v.", album.ID))
+	}
+	return c.JSON(http.StatusOK, updated)
+}
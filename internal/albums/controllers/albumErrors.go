@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/albums/services"
+	albumTypes "github.com/David/Boxed/internal/albums/types"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// albumError translates the errors returned while resolving or changing an album into a response.
+// notFound is the message answered for pgx.ErrNoRows.
+func albumError(c *echo.Context, err error, notFound string) error {
+	if ok, err := fileServices.FileSelectionError(c, err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: notFound,
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	case errors.Is(err, services.ErrAlbumNotOwned):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user don't own this album.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	case errors.Is(err, services.ErrCoverNotInAlbum):
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`cover` must be one of the files of the album.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while accessing the album. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
+
+// ownedAlbumFromRequest resolves the album given in the `:uuid` path parameter and checks that the authenticated
+// user owns it.
+// When the returned album is nil an error response has already been written and the caller must return the error as is.
+func ownedAlbumFromRequest(c *echo.Context) (*repositories.Album, error) {
+	albumID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`uuid` provided is not a valid uuid.",
+		}
+		return nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	album, err := services.GetOwnedAlbum(boxed.GetInstance().DbConn, userID, albumID)
+	if err != nil {
+		return nil, albumError(c, err, fmt.Sprintf("There is no album with id %v.", albumID))
+	}
+	return album, nil
+}
+
+// albumFilesFromBody reads the `files` of an AlbumFilesRequest body, which must hold at least one file.
+// When the returned ids are nil an error response has already been written and the caller must return the error as is.
+func albumFilesFromBody(c *echo.Context) ([]uuid.UUID, error) {
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return nil, c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body albumTypes.AlbumFilesRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the `files` of the album.",
+		}
+		return nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	if len(body.Files) == 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`files` must hold at least one file uuid.",
+		}
+		return nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	ids, err := fileServices.ParseFileIDs(body.Files)
+	if err != nil {
+		return nil, albumError(c, err, "")
+	}
+	return ids, nil
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/albums/services"
+	albumTypes "github.com/David/Boxed/internal/albums/types"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/labstack/echo/v5"
+)
+
+// CreateAlbumController creates an album for the authenticated user, optionally holding some of their files.
+//
+// Returns:
+//   - Responds with HTTP 201 (Created) and the new album as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the name or a file uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if a file belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if a file doesn't exist or is in the trash.
+func CreateAlbumController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body albumTypes.CreateAlbumRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to create an album.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if err := services.ValidateAlbumName(body.Name); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`name` must not be blank nor longer than 255 characters.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	fileIDs, err := fileServices.ParseFileIDs(body.Files)
+	if err != nil {
+		return albumError(c, err, "")
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	album, err := services.CreateAlbum(boxed.GetInstance().DbConn, userID, body.Name, fileIDs)
+	if err != nil {
+		return albumError(c, err, "One of the `files` doesn't exist or is in the trash.")
+	}
+	return c.JSON(http.StatusCreated, album)
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// DeleteAlbumController deletes the album given in the `:uuid` path parameter. Its files are left untouched.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) for successful deletion.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the album belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the album doesn't exist.
+func DeleteAlbumController(c *echo.Context) error {
+	album, err := ownedAlbumFromRequest(c)
+	if album == nil {
+		return err
+	}
+	if err := repositories.NewAlbumsRepo(boxed.GetInstance().DbConn).Delete(album.ID); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while deleting the album. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// GetAlbumController returns the album given in the `:uuid` path parameter with its files, in their order.
+// Files in the trash are left out until they are restored.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the album as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the album belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the album doesn't exist.
+func GetAlbumController(c *echo.Context) error {
+	album, err := ownedAlbumFromRequest(c)
+	if album == nil {
+		return err
+	}
+	files, err := repositories.NewAlbumsRepo(boxed.GetInstance().DbConn).GetFiles(album.ID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal server error while getting files, Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	content := struct {
+		Album *repositories.Album `json:"album"`
+		Files []repositories.File `json:"files"`
+	}{
+		Album: album,
+		Files: files,
+	}
+	return c.JSON(http.StatusOK, content)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// GetAlbumsController lists the albums of the authenticated user, newest first, with their cover and number of files.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the albums as JSON.
+func GetAlbumsController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	albums, err := repositories.NewAlbumsRepo(boxed.GetInstance().DbConn).GetByOwnerID(userID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while getting the albums. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, albums)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// RemoveAlbumFilesController takes `files` out of the album given in the `:uuid` path parameter. The files
+// themselves are left untouched, and files that aren't in the album are ignored.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated album as JSON.
+//   - Responds with HTTP 400 (Bad Request) if a uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the album belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the album doesn't exist.
+func RemoveAlbumFilesController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	album, err := ownedAlbumFromRequest(c)
+	if album == nil {
+		return err
+	}
+	fileIDs, err := albumFilesFromBody(c)
+	if fileIDs == nil {
+		return err
+	}
+	repo := repositories.NewAlbumsRepo(boxed.GetInstance().DbConn)
+	notFound := fmt.Sprintf("There is no album with id %v.", album.ID)
+	if err := repo.RemoveFiles(album.ID, fileIDs); err != nil {
+		return albumError(c, err, notFound)
+	}
+	updated, err := repo.GetByID(album.ID)
+	if err != nil {
+		return albumError(c, err, notFound)
+	}
+	return c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// ReorderAlbumController puts `files` first in the album given in the `:uuid` path parameter, in the given order.
+// The files left out follow them in their current order, so sending every file sets the whole order.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the files of the album in their new order.
+//   - Responds with HTTP 400 (Bad Request) if a uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the album belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the album doesn't exist.
+func ReorderAlbumController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	album, err := ownedAlbumFromRequest(c)
+	if album == nil {
+		return err
+	}
+	fileIDs, err := albumFilesFromBody(c)
+	if fileIDs == nil {
+		return err
+	}
+	repo := repositories.NewAlbumsRepo(boxed.GetInstance().DbConn)
+	notFound := fmt.Sprintf("There is no album with id %v.", album.ID)
+	if err := repo.Reorder(album.ID, fileIDs); err != nil {
+		return albumError(c, err, notFound)
+	}
+	files, err := repo.GetFiles(album.ID)
+	if err != nil {
+		return albumError(c, err, notFound)
+	}
+	return c.JSON(http.StatusOK, files)
+}
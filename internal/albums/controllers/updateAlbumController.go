@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/albums/services"
+	albumTypes "github.com/David/Boxed/internal/albums/types"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// UpdateAlbumController renames the album given in the `:uuid` path parameter and/or changes its cover.
+// Fields left out of the body are kept.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated album as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid, the name or the cover are invalid.
+//   - Responds with HTTP 403 (Forbidden) if the album belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the album doesn't exist.
+func UpdateAlbumController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	album, err := ownedAlbumFromRequest(c)
+	if album == nil {
+		return err
+	}
+	var body albumTypes.UpdateAlbumRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to update an album.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	db := boxed.GetInstance().DbConn
+	if body.Name != nil {
+		if err := services.ValidateAlbumName(*body.Name); err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`name` must not be blank nor longer than 255 characters.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+	}
+	var cover *uuid.UUID
+	if body.Cover != nil && *body.Cover != "" {
+		id, err := uuid.Parse(*body.Cover)
+		if err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`cover` provided is not a valid uuid.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		cover = &id
+	}
+	notFound := fmt.Sprintf("There is no album with id %v.", album.ID)
+	if body.Cover != nil {
+		if err := services.SetAlbumCover(db, album, cover); err != nil {
+			return albumError(c, err, notFound)
+		}
+	}
+	if body.Name != nil {
+		if err := repositories.NewAlbumsRepo(db).Rename(album.ID, *body.Name); err != nil {
+			return albumError(c, err, notFound)
+		}
+	}
+	updated, err := repositories.NewAlbumsRepo(db).GetByID(album.ID)
+	if err != nil {
+		return albumError(c, err, notFound)
+	}
+	return c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxAlbumName is the maximum length of an album name, in characters.
+const maxAlbumName = 255
+
+var (
+	ErrInvalidAlbumName = errors.New("album name is not valid")
+	ErrAlbumNotOwned    = errors.New("album is not owned by this user")
+	ErrCoverNotInAlbum  = errors.New("the cover must be a file of the album")
+)
+
+// ValidateAlbumName checks that name can be used as an album name: not blank and at most 255 characters.
+func ValidateAlbumName(name string) error {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxAlbumName {
+		return ErrInvalidAlbumName
+	}
+	return nil
+}
+
+// GetOwnedAlbum retrieves an album and verifies that it belongs to ownerID, albums aren't shared.
+//
+// Returns:
+//   - pgx.ErrNoRows if the album doesn't exist.
+//   - ErrAlbumNotOwned if the album belongs to somebody else.
+func GetOwnedAlbum(db *pgxpool.Pool, ownerID, albumID uuid.UUID) (*repositories.Album, error) {
+	album, err := repositories.NewAlbumsRepo(db).GetByID(albumID)
+	if err != nil {
+		return nil, err
+	}
+	if album.OwnerID != ownerID {
+		return nil, ErrAlbumNotOwned
+	}
+	return album, nil
+}
+
+// CreateAlbum creates an album for ownerID holding the given files, in order.
+//
+// Returns:
+//   - The errors of fileServices.CheckOwnedFiles if a file can't be added.
+func CreateAlbum(db *pgxpool.Pool, ownerID uuid.UUID, name string, fileIDs []uuid.UUID) (*repositories.Album, error) {
+	if err := fileServices.CheckOwnedFiles(db, ownerID, fileIDs); err != nil {
+		return nil, err
+	}
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	repo := repositories.NewAlbumsRepo(db).WithTx(t)
+	album := &repositories.Album{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := repo.Create(album); err != nil {
+		return nil, err
+	}
+	if len(fileIDs) > 0 {
+		if err := repo.AddFiles(album.ID, fileIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.Commit(context.Background()); err != nil {
+		return nil, err
+	}
+	return repositories.NewAlbumsRepo(db).GetByID(album.ID)
+}
+
+// AddAlbumFiles appends files of the album's owner at the end of an album.
+//
+// Returns:
+//   - The errors of fileServices.CheckOwnedFiles if a file can't be added.
+func AddAlbumFiles(db *pgxpool.Pool, album *repositories.Album, fileIDs []uuid.UUID) error {
+	if err := fileServices.CheckOwnedFiles(db, album.OwnerID, fileIDs); err != nil {
+		return err
+	}
+	return repositories.NewAlbumsRepo(db).AddFiles(album.ID, fileIDs)
+}
+
+// SetAlbumCover chooses the file shown for an album, a nil fileID going back to its first file.
+//
+// Returns:
+//   - ErrCoverNotInAlbum if the file isn't in the album.
+func SetAlbumCover(db *pgxpool.Pool, album *repositories.Album, fileID *uuid.UUID) error {
+	repo := repositories.NewAlbumsRepo(db)
+	if fileID != nil {
+		found, err := repo.Contains(album.ID, *fileID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrCoverNotInAlbum
+		}
+	}
+	return repo.SetCover(album.ID, fileID)
+}
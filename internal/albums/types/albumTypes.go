@@ -0,0 +1,15 @@
+package types
+
+type CreateAlbumRequest struct {
+	Name  string   `json:"name"`
+	Files []string `json:"files"` // Uuids of the files to start the album with, optional.
+}
+
+type UpdateAlbumRequest struct {
+	Name  *string `json:"name"`  // Omitted to keep the name.
+	Cover *string `json:"cover"` // Uuid of a file of the album, empty to use the first file. Omitted to keep the cover.
+}
+
+type AlbumFilesRequest struct {
+	Files []string `json:"files"` // Uuids of the files to add, remove or move first, in order.
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/David/Boxed/internal/common/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// resourceError translates the errors returned while resolving an archive or a job into a response.
+// notFound is the message answered for pgx.ErrNoRows.
+func resourceError(c *echo.Context, err error, notFound string) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: notFound,
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	case errors.Is(err, shareServices.ErrForbidden):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user doesn't have write access to this file.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while looking up the resource. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/archives/services"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// ExtractArchiveController starts extracting the zip, tar or tar.gz archive given in the `uuid` header.
+// Its content is unpacked in the background into a new folder placed next to the archive, see services.StartExtraction.
+//
+// Returns:
+//   - Responds with HTTP 202 (Accepted) and the job to poll as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid, the file doesn't exist or isn't an archive.
+//   - Responds with HTTP 403 (Forbidden) if the user doesn't have write access to the archive.
+func ExtractArchiveController(c *echo.Context) error {
+	fileID, err := uuid.Parse(c.Request().Header.Get("uuid"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`uuid` must be provided as a valid uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	db := boxed.GetInstance().DbConn
+	// The content lands next to the archive, so extracting needs write access.
+	archive, err := shareServices.AuthorizeFile(db, userID, fileID, shareServices.AccessWrite)
+	if err != nil {
+		return resourceError(c, err, "There is no file with this uuid.")
+	}
+	job, err := services.StartExtraction(db, userID, archive)
+	if err != nil {
+		if errors.Is(err, services.ErrNotAnArchive) {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "The file is not a zip, tar or tar.gz archive.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError,
+			Message: "Error while starting the extraction. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
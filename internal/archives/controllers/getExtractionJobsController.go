@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// GetExtractionJobsController reports the progress of the extractions started by the authenticated user.
+// With the `:uuid` path parameter only that job is returned, otherwise every job, newest first.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the job(s) as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid or the job doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the job was started by another user.
+func GetExtractionJobsController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	repo := repositories.NewExtractionJobsRepo(boxed.GetInstance().DbConn)
+	if c.Param("uuid") == "" {
+		jobs, err := repo.GetByOwnerID(userID)
+		if err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.DatabaseError,
+				Message: "Error while getting the extraction jobs. Please try later.",
+			}
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+		return c.JSON(http.StatusOK, jobs)
+	}
+	id, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`uuid` provided is not valid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	job, err := repo.GetByID(id)
+	if err != nil {
+		return resourceError(c, err, "There is no extraction job with this uuid.")
+	}
+	if job.OwnerID != userID {
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This extraction job was started by another user.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	}
+	return c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	folderServices "github.com/David/Boxed/internal/folders/services"
+	"github.com/David/Boxed/internal/storage"
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Limits protecting the server from archives crafted to expand into far more data than they hold (zip bombs).
+const (
+	MaxArchiveEntries   = 10000
+	MaxExtractedBytes   = 10 << 30 // Whatever the owner's quota.
+	MaxCompressionRatio = 100      // Checked on zip entries larger than ratioThreshold.
+	ratioThreshold      = 1 << 20
+)
+
+var (
+	ErrNotAnArchive    = errors.New("file is not a zip, tar or tar.gz archive")
+	ErrTooManyEntries  = fmt.Errorf("archive has more than %d entries", MaxArchiveEntries)
+	ErrArchiveTooLarge = errors.New("archive expands to more data than allowed")
+	ErrSuspiciousRatio = fmt.Errorf("an entry is compressed more than %d times, the archive looks like a zip bomb", MaxCompressionRatio)
+)
+
+// Supported archive formats.
+const (
+	formatZip   = "zip"
+	formatTar   = "tar"
+	formatTarGz = "tar.gz"
+)
+
+// archiveEntry is an entry read from an archive, whatever its format.
+type archiveEntry struct {
+	name       string
+	dir        bool
+	regular    bool  // false for links, devices and other special entries.
+	size       int64 // Declared uncompressed size.
+	compressed int64 // Declared compressed size, 0 when unknown.
+	open       func() (io.ReadCloser, error)
+}
+
+// detectFormat reads the first bytes of a stored file to recognize its archive format.
+// Returns ErrNotAnArchive when it isn't a supported archive.
+func detectFormat(ctx context.Context, key string) (string, error) {
+	r, err := boxed.GetInstance().Storage.Get(ctx, key, 0, 512)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	head, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return formatZip, nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return formatTarGz, nil
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return formatTar, nil
+	}
+	return "", ErrNotAnArchive
+}
+
+// walkArchive calls fn for every entry of the archive stored in the local file localPath.
+// The reader returned by an entry's open is only valid until fn returns.
+func walkArchive(format, localPath string, fn func(e archiveEntry) error) error {
+	if format == formatZip {
+		zr, err := zip.OpenReader(localPath)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			err := fn(archiveEntry{
+				name:       f.Name,
+				dir:        f.FileInfo().IsDir(),
+				regular:    f.FileInfo().Mode().IsRegular() || f.FileInfo().IsDir(),
+				size:       int64(f.UncompressedSize64),
+				compressed: int64(f.CompressedSize64),
+				open:       f.Open,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var src io.Reader = file
+	if format == formatTarGz {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		src = gz
+	}
+	tr := tar.NewReader(src)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(archiveEntry{
+			name:    header.Name,
+			dir:     header.Typeflag == tar.TypeDir,
+			regular: header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeDir,
+			size:    header.Size,
+			open:    func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// safeEntryPath cleans the path of an archive entry.
+// It returns false for the paths that would escape the destination folder (zip-slip), absolute paths, and
+// the paths using names that can't be folder names.
+func safeEntryPath(name string) (string, bool) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || strings.Contains(name, ":") {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	name = strings.TrimPrefix(path.Clean(name), "./")
+	if name == "." || name == "" || strings.HasPrefix(name, "__MACOSX") {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if folderServices.ValidateFolderName(part) != nil {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// StartExtraction checks that a file is a supported archive and starts extracting it in the background,
+// into a new folder placed next to it and named after it. The content belongs to the archive's owner and
+// counts towards their quota.
+// Callers must have checked that userID has write access to the archive.
+//
+// Returns:
+//   - The job whose progress can be polled.
+//   - ErrNotAnArchive if the file isn't a zip, tar or tar.gz archive.
+func StartExtraction(db *pgxpool.Pool, userID uuid.UUID, archive *repositories.File) (*repositories.ExtractionJob, error) {
+	format, err := detectFormat(context.Background(), archive.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &repositories.ExtractionJob{
+		OwnerID:   userID,
+		FileID:    &archive.ID,
+		Status:    repositories.ExtractionPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := repositories.NewExtractionJobsRepo(db).Create(job); err != nil {
+		return nil, err
+	}
+	started := *job
+	go runExtraction(db, &started, archive, format)
+	return job, nil
+}
+
+// runExtraction extracts an archive for job and records how it ended.
+// Entries that were extracted before a failure are kept.
+func runExtraction(db *pgxpool.Pool, job *repositories.ExtractionJob, archive *repositories.File, format string) {
+	repo := repositories.NewExtractionJobsRepo(db)
+	job.Status = repositories.ExtractionRunning
+	if err := repo.Update(job); err != nil {
+		log.Printf("Couldn't start extraction job %v: %v", job.ID, err)
+	}
+	err := extract(db, job, archive, format)
+	job.Status = repositories.ExtractionDone
+	if err != nil {
+		log.Printf("Extraction job %v failed: %v", job.ID, err)
+		message := err.Error()
+		job.Status, job.Error = repositories.ExtractionFailed, &message
+	}
+	if err := repo.Update(job); err != nil {
+		log.Printf("Couldn't save the end of extraction job %v: %v", job.ID, err)
+	}
+}
+
+// extract unpacks the archive into a new folder, updating the progress of job as entries are processed.
+func extract(db *pgxpool.Pool, job *repositories.ExtractionJob, archive *repositories.File, format string) error {
+	// The budget is the owner's remaining quota, capped by MaxExtractedBytes.
+	budget := int64(MaxExtractedBytes)
+	usage, err := userServices.GetUsage(db, archive.OwnerID)
+	if err != nil {
+		return err
+	}
+	if usage.Quota > 0 && usage.Quota-usage.Used < budget {
+		budget = max(usage.Quota-usage.Used, 0)
+	}
+
+	localPath, cleanup, err := storage.Fetch(context.Background(), boxed.GetInstance().Storage, archive.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	total, err := scanArchive(format, localPath, budget)
+	if errors.Is(err, ErrArchiveTooLarge) {
+		return tooLarge(usage.Quota, budget)
+	}
+	if err != nil {
+		return err
+	}
+	job.TotalEntries = &total
+
+	root, err := createDestination(db, archive)
+	if err != nil {
+		return err
+	}
+	job.FolderID = &root.ID
+	repo := repositories.NewExtractionJobsRepo(db)
+	if err := repo.Update(job); err != nil {
+		return err
+	}
+
+	folders := map[string]uuid.UUID{"": root.ID}
+	return walkArchive(format, localPath, func(e archiveEntry) error {
+		defer func() {
+			job.ProcessedEntries++
+			if err := repo.Update(job); err != nil {
+				log.Printf("Couldn't save the progress of extraction job %v: %v", job.ID, err)
+			}
+		}()
+		name, ok := safeEntryPath(e.name)
+		if !ok || !e.regular {
+			job.SkippedEntries++
+			return nil
+		}
+		if e.dir {
+			_, err := ensureFolder(db, archive.OwnerID, folders, name)
+			return err
+		}
+		if err := checkEntry(e, budget-job.ExtractedBytes); err != nil {
+			if errors.Is(err, ErrArchiveTooLarge) {
+				return tooLarge(usage.Quota, budget)
+			}
+			return err
+		}
+		parent, err := ensureFolder(db, archive.OwnerID, folders, path.Dir(name))
+		if err != nil {
+			return err
+		}
+		size, err := extractFile(db, archive.OwnerID, parent, path.Base(name), e, budget-job.ExtractedBytes)
+		if err != nil {
+			if errors.Is(err, ErrArchiveTooLarge) {
+				return tooLarge(usage.Quota, budget)
+			}
+			return err
+		}
+		job.ExtractedBytes += size
+		return nil
+	})
+}
+
+// scanArchive is the first pass over an archive, done before anything is created.
+// It counts the entries and refuses archives that declare too much data.
+//
+// Returns:
+//   - The number of entries.
+//   - ErrTooManyEntries if there are more than MaxArchiveEntries entries.
+//   - ErrArchiveTooLarge if the entries declare more than budget bytes.
+func scanArchive(format, localPath string, budget int64) (int, error) {
+	total, declared := 0, int64(0)
+	err := walkArchive(format, localPath, func(e archiveEntry) error {
+		total++
+		declared += e.size
+		if total > MaxArchiveEntries {
+			return ErrTooManyEntries
+		}
+		if declared > budget {
+			return ErrArchiveTooLarge
+		}
+		return nil
+	})
+	return total, err
+}
+
+// checkEntry refuses an entry that looks like a zip bomb, or declares more than the left bytes.
+//
+// Returns:
+//   - ErrSuspiciousRatio if the entry is compressed more than MaxCompressionRatio times.
+//   - ErrArchiveTooLarge if the entry declares more than left bytes.
+func checkEntry(e archiveEntry, left int64) error {
+	if e.compressed > 0 && e.size > ratioThreshold && e.size/e.compressed > MaxCompressionRatio {
+		return ErrSuspiciousRatio
+	}
+	if e.size > left {
+		return ErrArchiveTooLarge
+	}
+	return nil
+}
+
+// tooLarge returns the error explaining why the extraction budget was exceeded.
+func tooLarge(quota, budget int64) error {
+	if budget < MaxExtractedBytes && quota > 0 {
+		return userServices.ErrQuotaExceeded
+	}
+	return ErrArchiveTooLarge
+}
+
+// createDestination creates the folder receiving the content of an archive, next to it and named after it without
+// its extension. ` (1)`, ` (2)`... is appended to the name while a sibling folder already uses it.
+func createDestination(db *pgxpool.Pool, archive *repositories.File) (*repositories.Folder, error) {
+	base := strings.TrimSuffix(archive.OriginalName, fileServices.FullExtension(archive.OriginalName))
+	if folderServices.ValidateFolderName(base) != nil {
+		base = "archive"
+	}
+	for i := 0; ; i++ {
+		folder := &repositories.Folder{
+			ID:        uuid.New(),
+			OwnerID:   archive.OwnerID,
+			ParentID:  archive.FolderID,
+			Name:      base,
+			CreatedAt: time.Now(),
+		}
+		if i > 0 {
+			folder.Name = fmt.Sprintf("%v (%d)", base, i)
+		}
+		err := repositories.NewFoldersRepo(db).Create(folder)
+		if err == nil {
+			return folder, nil
+		}
+		if !folderServices.IsNameConflict(err) || i >= 100 {
+			return nil, err
+		}
+	}
+}
+
+// ensureFolder returns the folder at dir, relative to the destination, creating it and its parents if needed.
+// folders caches the folders already created, by path.
+func ensureFolder(db *pgxpool.Pool, ownerID uuid.UUID, folders map[string]uuid.UUID, dir string) (uuid.UUID, error) {
+	if dir == "." {
+		dir = ""
+	}
+	if id, ok := folders[dir]; ok {
+		return id, nil
+	}
+	parent, err := ensureFolder(db, ownerID, folders, path.Dir(dir))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	folder := &repositories.Folder{
+		ID:        uuid.New(),
+		OwnerID:   ownerID,
+		ParentID:  &parent,
+		Name:      path.Base(dir),
+		CreatedAt: time.Now(),
+	}
+	if err := repositories.NewFoldersRepo(db).Create(folder); err != nil {
+		return uuid.Nil, err
+	}
+	folders[dir] = folder.ID
+	return folder.ID, nil
+}
+
+// extractFile stores the content of an archive entry as a new file of ownerID in folderID, with its thumbnail.
+// At most limit bytes are read, whatever the entry declares.
+//
+// Returns:
+//   - The size of the content.
+//   - ErrArchiveTooLarge if the content is larger than limit.
+func extractFile(db *pgxpool.Pool, ownerID, folderID uuid.UUID, name string, e archiveEntry, limit int64) (int64, error) {
+	src, err := e.open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	dir, err := fileServices.TempDir()
+	if err != nil {
+		return 0, err
+	}
+	dst, err := os.CreateTemp(dir, "extract-*")
+	if err != nil {
+		return 0, err
+	}
+	hash := sha256.New()
+	head := &sniffBuffer{}
+	size, err := io.Copy(io.MultiWriter(dst, hash, head), io.LimitReader(src, limit+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > limit {
+		err = ErrArchiveTooLarge
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return 0, err
+	}
+	blob, err := fileServices.StoreBlob(db, dst.Name(), hex.EncodeToString(hash.Sum(nil)), size)
+	if err != nil {
+		os.Remove(dst.Name())
+		return 0, err
+	}
+	info := fileServices.FileInfo{
+		Filename: name,
+		MimeType: mimeTypeOf(name, head.Bytes()),
+		Size:     size,
+	}
+	return size, fileServices.RegisterStoredFile(db, ownerID, info, uuid.New(), &folderID, blob)
+}
+
+// mimeTypeOf guesses the mime type of an extracted file from its extension, or from its first bytes.
+func mimeTypeOf(name string, head []byte) string {
+	mimeType := mime.TypeByExtension(path.Ext(name))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(head)
+	}
+	if parsed, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return parsed
+	}
+	return "application/octet-stream"
+}
+
+// sniffBuffer keeps the first 512 bytes written to it, what http.DetectContentType looks at.
+type sniffBuffer struct {
+	bytes.Buffer
+}
+
+func (b *sniffBuffer) Write(p []byte) (int, error) {
+	if room := 512 - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// FailInterruptedExtractions marks the jobs left unfinished by a previous run of the server as failed.
+// Their archive can be extracted again.
+func FailInterruptedExtractions(db *pgxpool.Pool) {
+	n, err := repositories.NewExtractionJobsRepo(db).FailUnfinished("interrupted by a server restart")
+	if err != nil {
+		log.Println("Error while failing interrupted extraction jobs:", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("%d interrupted extraction jobs marked as failed", n)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeEntryPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"notes.txt", "notes.txt", true},
+		{"docs/notes.txt", "docs/notes.txt", true},
+		{"./docs//notes.txt", "docs/notes.txt", true},
+		{`docs\notes.txt`, "docs/notes.txt", true},
+		{"docs/", "docs", true},
+		{"../notes.txt", "", false},
+		{"docs/../../notes.txt", "", false},
+		{"docs/../notes.txt", "", false},
+		{`..\notes.txt`, "", false},
+		{`docs\..\..\notes.txt`, "", false},
+		{"/etc/passwd", "", false},
+		{`\etc\passwd`, "", false},
+		{"C:/Windows/notes.txt", "", false},
+		{`C:\Windows\notes.txt`, "", false},
+		{"C:notes.txt", "", false},
+		{"__MACOSX/._notes.txt", "", false},
+		{"__MACOSX", "", false},
+		{".", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := safeEntryPath(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("safeEntryPath(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// writeArchive writes an archive of the given format holding the files, and returns its path.
+func writeArchive(t *testing.T, format string, files map[string][]byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if format == formatZip {
+		zw := zip.NewWriter(&buf)
+		for name, content := range files {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		tw := tar.NewWriter(&buf)
+		for name, content := range files {
+			header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+			if err := tw.WriteHeader(header); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write(content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	localPath := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(localPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return localPath
+}
+
+func TestScanArchiveBudget(t *testing.T) {
+	files := map[string][]byte{"a.txt": bytes.Repeat([]byte("a"), 600), "b.txt": bytes.Repeat([]byte("b"), 600)}
+	for _, format := range []string{formatZip, formatTar} {
+		localPath := writeArchive(t, format, files)
+		if _, err := scanArchive(format, localPath, 1000); !errors.Is(err, ErrArchiveTooLarge) {
+			t.Errorf("%v archive over budget: got %v, want %v", format, err, ErrArchiveTooLarge)
+		}
+		total, err := scanArchive(format, localPath, 1200)
+		if err != nil || total != 2 {
+			t.Errorf("%v archive within budget: got %d entries and %v, want 2 entries", format, total, err)
+		}
+	}
+}
+
+func TestCheckEntryRatio(t *testing.T) {
+	bomb := bytes.Repeat([]byte{0}, 2*ratioThreshold)
+	plain := make([]byte, 2*ratioThreshold)
+	rand.NewChaCha8([32]byte{}).Read(plain)
+	tests := []struct {
+		name    string
+		content []byte
+		left    int64
+		want    error
+	}{
+		{"bomb.bin", bomb, MaxExtractedBytes, ErrSuspiciousRatio},
+		{"plain.bin", plain, MaxExtractedBytes, nil},
+		{"plain.bin", plain, ratioThreshold, ErrArchiveTooLarge},
+		{"small.bin", bomb[:ratioThreshold], MaxExtractedBytes, nil},
+	}
+	for _, tt := range tests {
+		localPath := writeArchive(t, formatZip, map[string][]byte{tt.name: tt.content})
+		err := walkArchive(formatZip, localPath, func(e archiveEntry) error {
+			return checkEntry(e, tt.left)
+		})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("checkEntry(%v, %d) = %v, want %v", tt.name, tt.left, err, tt.want)
+		}
+	}
+}
@@ -19,6 +19,32 @@ const (
 	ResourceDeleteFailed = "RESOURCE_DELETE_FAILED"
 	FileFetchFailed      = "FILE_FETCH_FAILED"
 	ResourceNotFound     = "RESOURCE_NOT_FOUND"
+	ThumbnailPending     = "THUMBNAIL_PENDING"
+	StreamPending        = "STREAM_PENDING"
+	PreviewPending       = "PREVIEW_PENDING"
+	WaveformPending      = "WAVEFORM_PENDING"
+	RevisionMismatch     = "REVISION_MISMATCH"
+
+	// Folder related errors.
+	FolderAlreadyExists = "FOLDER_ALREADY_EXISTS"
+	InvalidFolderMove   = "INVALID_FOLDER_MOVE"
+
+	// Tag related errors.
+	TagAlreadyExists = "TAG_ALREADY_EXISTS"
+
+	// Resumable upload related errors.
+	TusVersionUnsupported = "TUS_VERSION_UNSUPPORTED"
+	UploadOffsetMismatch  = "UPLOAD_OFFSET_MISMATCH"
+	UploadLocked          = "UPLOAD_LOCKED"
+	UploadChunkTooLarge   = "UPLOAD_CHUNK_TOO_LARGE"
+
+	// Share link related errors.
+	ShareLinkUnavailable  = "SHARE_LINK_UNAVAILABLE"
+	SharePasswordRequired = "SHARE_PASSWORD_REQUIRED"
+	SharePasswordInvalid  = "SHARE_PASSWORD_INVALID"
+
+	// Quota related errors.
+	QuotaExceeded = "QUOTA_EXCEEDED"
 
 	// Server generic errors.
 	InternalServerError = "INTERNAL_SERVER_ERROR"
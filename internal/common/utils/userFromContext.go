@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// GetUserID returns the uuid of the authenticated user, read from the claims the jwt middleware stored under "user".
+func GetUserID(c *echo.Context) (uuid.UUID, error) {
+	claims, err := echo.ContextGet[*types.ResponseClaims](c, "user")
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(claims.Subject)
+}
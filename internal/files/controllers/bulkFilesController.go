@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/files/services"
+	fileTypes "github.com/David/Boxed/internal/files/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	tagServices "github.com/David/Boxed/internal/tags/services"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// bulkFileResult is the outcome of a bulk operation on one of its files.
+type bulkFileResult struct {
+	File   uuid.UUID `json:"file"`
+	Status int       `json:"status"` // The HTTP status a request on this file alone would have got.
+	// Error is nil when the operation succeeded on the file.
+	Error *types.ErrorResponse `json:"error,omitempty"`
+}
+
+// BulkFilesController applies an `operation` to many `files` at once: `delete` moves them to the trash, `restore`
+// takes them out of it, `move` moves them to `folder` (the root when empty) and `tag` puts `tags` on them.
+// Every file is checked as it would be on its own request; the ones that can't be changed are skipped and the
+// others are changed in a single transaction.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the result of every file, in order, as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the operation, a file uuid, the folder or a tag name is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the user can't write to the folder.
+//   - Responds with HTTP 404 (Not Found) if the folder doesn't exist.
+func BulkFilesController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body fileTypes.BulkFilesRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the `operation` and its `files`.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	req := services.BulkRequest{Operation: services.BulkOperation(body.Operation)}
+	if !services.ValidBulkOperation(req.Operation) {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`operation` must be `delete`, `restore`, `move` or `tag`.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if len(body.Files) == 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`files` must hold at least one file uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	var err error
+	if req.Files, err = services.ParseFileIDs(body.Files); err != nil {
+		_, err = services.FileSelectionError(c, err)
+		return err
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	db := boxed.GetInstance().DbConn
+	switch req.Operation {
+	case services.BulkMove:
+		if req.Folder, err = services.ResolveDestination(db, userID, body.Folder); err != nil {
+			return moveDestinationError(c, err)
+		}
+	case services.BulkTag:
+		if len(body.Tags) == 0 {
+			e := &types.ErrorResponse{
+				Code:    types.MissingFields,
+				Message: "`tags` must hold at least one tag name.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		if req.Tags, err = tagServices.ParseTagNames(body.Tags); err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "Tag names must not be blank, span several lines nor be longer than 100 characters.",
+			}
+			if errors.Is(err, tagServices.ErrTooManyTags) {
+				e.Message = fmt.Sprintf("At most %d `tags` can be given at once.", tagServices.MaxRequestTags)
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+	}
+
+	failed, err := services.RunBulk(db, userID, req)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while changing the files, none was changed. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	results := make([]bulkFileResult, 0, len(req.Files))
+	succeeded := 0
+	for _, id := range req.Files {
+		res := bulkFileResult{File: id, Status: http.StatusOK}
+		if ferr, ok := failed[id]; ok {
+			res.Status, res.Error = bulkFileError(req.Operation, id, ferr)
+		} else {
+			succeeded++
+		}
+		results = append(results, res)
+	}
+	content := struct {
+		Succeeded int              `json:"succeeded"`
+		Failed    int              `json:"failed"`
+		Results   []bulkFileResult `json:"results"`
+	}{
+		Succeeded: succeeded,
+		Failed:    len(results) - succeeded,
+		Results:   results,
+	}
+	return c.JSON(http.StatusOK, content)
+}
+
+// bulkFileError translates the error of a file skipped by services.RunBulk into the status and error the request
+// on this file alone would have answered.
+func bulkFileError(op services.BulkOperation, id uuid.UUID, err error) (int, *types.ErrorResponse) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		message := fmt.Sprintf("There is no file with id %v.", id)
+		if op == services.BulkRestore {
+			message = fmt.Sprintf("There is no file with id %v in the trash.", id)
+		}
+		return http.StatusNotFound, &types.ErrorResponse{Code: types.ResourceNotFound, Message: message}
+	case errors.Is(err, shareServices.ErrForbidden):
+		return http.StatusForbidden, &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user can't access this file, or doesn't have write access to it.",
+		}
+	case errors.Is(err, services.ErrFileNotOwned):
+		return services.FileSelectionResponse(err)
+	case errors.Is(err, services.ErrForeignDestination):
+		return http.StatusForbidden, &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "Files can only be moved between folders of their owner.",
+		}
+	default:
+		return http.StatusInternalServerError, &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while accessing the file. Please try later.",
+		}
+	}
+}
@@ -1,123 +1,34 @@
 package controllers
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal/common/types"
-	"github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
 	"github.com/David/Boxed/repositories"
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/labstack/echo/v5"
 )
 
-// DeleteFile removes a file identified by its UUID, along with its metadata, from both the database and storage.
+// DeleteFile moves a file identified by its UUID to its owner's trash. It can be restored until the trash is purged,
+// see RestoreFileController and PurgeFileController.
 //
 // Returns:
 //   - Responds with HTTP 200 (OK) for successful deletion.
 //   - Responds with HTTP 400 (Bad Request) if the UUID is invalid or the file does not exist.
+//   - Responds with HTTP 403 (Forbidden) if the user has no write access to the file.
 func DeleteFileController(c *echo.Context) error {
-	id := c.Request().Header.Get("uuid")
-	if id == "" {
-		e := &types.ErrorResponse{
-			Code:    types.MissingFields,
-			Message: "`uuid` must be provided.",
-		}
-		return c.JSON(http.StatusBadRequest, &e)
-	}
-
-	conn := boxed.GetInstance().DbConn
-	fileRepo := repositories.NewFilesRepo(conn)
-
-	ui, e := uuid.Parse(id)
-	if e != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InvalidFields,
-			Message: "`uuid` provided is not valid.",
-		}
-		return c.JSON(http.StatusBadRequest, &e)
-	}
-	// Verify user authorization
-	userClaims, err := echo.ContextGet[*types.ResponseClaims](c, "user")
-	if err != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
-			Message: "Error while getting user from jwt, please try again.",
-		}
-		return c.JSON(http.StatusInternalServerError, &e)
+	f, err := authorizedFile(c, "uuid", c.Request().Header.Get("uuid"), shareServices.AccessWrite)
+	if f == nil {
+		return err
 	}
-	userID, err := uuid.Parse(userClaims.Subject)
-	if err != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InternalServerError,
-			Message: "Internal error while parsing user uuid, please try again.",
-		}
-		return c.JSON(http.StatusInternalServerError, &e)
-	}
-	// get by id
-	f, e := fileRepo.GetByID(ui)
-	if e != nil {
-		var pge *pgconn.PgError
-		if errors.As(e, &pge) || errors.As(e, &pgx.ErrNoRows) {
-			em := &types.ErrorResponse{
-				Code:    types.ResourceNotFound,
-				Message: fmt.Sprintf("Couldn't get any file with %v to delete.", id),
-			}
-			return c.JSON(http.StatusBadRequest, &em)
-		} else {
-			em := &types.ErrorResponse{
-				Code:    types.ResourceNotFound,
-				Message: fmt.Sprintf("Internal error while getting file with id: %v", id),
-			}
-			return c.JSON(http.StatusInternalServerError, &em)
-		}
-	}
-	if e := fileRepo.Delete(ui); e != nil {
-
+	if err := repositories.NewFilesRepo(boxed.GetInstance().DbConn).Trash(f.ID); err != nil {
 		em := &types.ErrorResponse{
 			Code:    types.ResourceDeleteFailed,
-			Message: fmt.Sprintf("Internal error while deleting file `%v`. Please try later.", id),
+			Message: fmt.Sprintf("Internal error while deleting file `%v`. Please try later.", f.ID),
 		}
 		return c.JSON(http.StatusInternalServerError, &em)
 	}
-	// Get Thumbnail path
-	tr := repositories.NewThumbnailRepository(boxed.GetInstance().DbConn)
-	t, err := tr.GetByID(f.ThumbnailId)
-	if err != nil {
-		log.Println("No thumnbail by this id:", f.ThumbnailId)
-	} else {
-		// Check if user owns this resource.
-		if f.OwnerID != userID {
-			em := &types.ErrorResponse{
-				Code:    types.WrongOwner,
-				Message: "This user don't own this file.",
-			}
-			return c.JSON(http.StatusForbidden, &em)
-		}
-		err := tr.DeleteByID(t.ID)
-		if err != nil {
-			var pge *pgconn.PgError
-			if errors.As(e, &pge) || errors.As(e, &pgx.ErrNoRows) {
-				em := &types.ErrorResponse{
-					Code:    types.ResourceNotFound,
-					Message: fmt.Sprintf("Couldn't get any thumnbail with %v to delete.", id),
-				}
-				return c.JSON(http.StatusBadRequest, &em)
-			} else {
-				em := &types.ErrorResponse{
-					Code:    types.ResourceDeleteFailed,
-					Message: fmt.Sprintf("Internal error while deleting thumnbail with id: %v", id),
-				}
-				return c.JSON(http.StatusInternalServerError, &em)
-			}
-		}
-		go services.DeleteFile(t.StoragePath)
-	}
-	go services.DeleteFile(f.StoragePath)
 	return c.NoContent(http.StatusOK)
 }
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	fileTypes "github.com/David/Boxed/internal/files/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// maxZipFiles is the maximum number of files that can be listed in a single archive request.
+const maxZipFiles = 1000
+
+// DownloadZipController streams a ZIP archive of several files, or of a folder with everything below it.
+// The archive is built on the fly while it is sent, nothing is written to disk.
+// Entries use the original file names, duplicates get a ` (1)`, ` (2)`... suffix. Read access is checked on
+// every file, as for serve-file, before anything is sent.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the archive as an attachment.
+//   - Responds with HTTP 400 (Bad Request) if the body is invalid or a file or the folder doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to a file or the folder.
+func DownloadZipController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body fileTypes.DownloadZipRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the `files` or the `folder` to download.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if (len(body.Files) == 0) == (body.Folder == "") {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "Exactly one of `files` and `folder` must be provided.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if len(body.Files) > maxZipFiles {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("At most %d `files` can be downloaded at once.", maxZipFiles),
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+
+	var entries []services.ZipEntry
+	archiveName := "files.zip"
+	if body.Folder != "" {
+		folder, err := readableFolder(c, body.Folder)
+		if folder == nil {
+			return err
+		}
+		entries, err = services.ZipEntriesForFolder(boxed.GetInstance().DbConn, folder)
+		if err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.DatabaseError,
+				Message: "Error while listing the folder. Please try later.",
+			}
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+		archiveName = folder.Name + ".zip"
+	} else {
+		files := make([]repositories.File, 0, len(body.Files))
+		seen := map[string]bool{}
+		for _, id := range body.Files {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			file, err := authorizedFile(c, "files", id, shareServices.AccessRead)
+			if file == nil {
+				return err
+			}
+			files = append(files, *file)
+		}
+		entries = services.ZipEntriesForFiles(files)
+	}
+
+	h := c.Response().Header()
+	h.Set("Content-Type", "application/zip")
+	h.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archiveName))
+	h.Set("Cache-Control", "private, no-store")
+	c.Response().WriteHeader(http.StatusOK)
+	// Headers are already sent, a failure can only cut the archive short.
+	if err := services.WriteZip(c.Request().Context(), c.Response(), entries); err != nil {
+		log.Printf("ZIP download interrupted: %v", err)
+	}
+	return nil
+}
+
+// readableFolder resolves the folder whose uuid is id and checks that the authenticated user can read it.
+// When the returned folder is nil an error response has already been written and the caller must return the error as is.
+func readableFolder(c *echo.Context, id string) (*repositories.Folder, error) {
+	folderID, userID, err := parseFileRequest(c, "folder", id)
+	if folderID == uuid.Nil {
+		return nil, err
+	}
+	folder, err := shareServices.AuthorizeFolder(boxed.GetInstance().DbConn, userID, folderID, shareServices.AccessRead)
+	if err != nil {
+		return nil, fileAccessError(c, err, fmt.Sprintf("There is no folder with id %v.", id))
+	}
+	return folder, nil
+}
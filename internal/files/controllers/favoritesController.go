@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/files/services"
+	fileTypes "github.com/David/Boxed/internal/files/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// AddFavoritesController stars the `files` of the authenticated user, so they can be listed and searched apart
+// with the `favorite` parameter. Nothing is starred if one of the files can't be.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) when the files are starred.
+//   - Responds with HTTP 400 (Bad Request) if a file uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if a file belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if a file doesn't exist or is in the trash.
+func AddFavoritesController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	fileIDs, userID, err := favoriteFilesFromBody(c)
+	if fileIDs == nil {
+		return err
+	}
+	if err := services.AddFavorites(boxed.GetInstance().DbConn, userID, fileIDs); err != nil {
+		return favoritesError(c, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// RemoveFavoritesController unstars the `files` of the authenticated user.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) when the files are unstarred.
+//   - Responds with HTTP 400 (Bad Request) if a file uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if a file belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if a file doesn't exist or is in the trash.
+func RemoveFavoritesController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	fileIDs, userID, err := favoriteFilesFromBody(c)
+	if fileIDs == nil {
+		return err
+	}
+	if err := services.RemoveFavorites(boxed.GetInstance().DbConn, userID, fileIDs); err != nil {
+		return favoritesError(c, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// favoriteFilesFromBody reads the `files` of a FavoritesRequest body, which must hold at least one file, and the
+// authenticated user's id.
+// When the returned ids are nil an error response has already been written and the caller must return the error as is.
+func favoriteFilesFromBody(c *echo.Context) ([]uuid.UUID, uuid.UUID, error) {
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return nil, uuid.Nil, c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body fileTypes.FavoritesRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the `files` to star or unstar.",
+		}
+		return nil, uuid.Nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	if len(body.Files) == 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`files` must hold at least one file uuid.",
+		}
+		return nil, uuid.Nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	fileIDs, err := services.ParseFileIDs(body.Files)
+	if err != nil {
+		return nil, uuid.Nil, favoritesError(c, err)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return nil, uuid.Nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	return fileIDs, userID, nil
+}
+
+// favoritesError translates the errors returned while starring or unstarring files into a response.
+func favoritesError(c *echo.Context, err error) error {
+	if ok, err := services.FileSelectionError(c, err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: "One of the `files` doesn't exist or is in the trash.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while updating the favorites. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
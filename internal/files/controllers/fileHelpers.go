@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// authorizedFileFromRequest resolves the file given in the `:uuid` path parameter or the `uuid` header and checks
+// that the authenticated user has at least the needed access on it. Files in the trash are not found.
+// When the returned file is nil an error response has already been written and the caller must return the error as is.
+func authorizedFileFromRequest(c *echo.Context, need shareServices.Access) (*repositories.File, error) {
+	return authorizedFile(c, "uuid", idFromRequest(c), need)
+}
+
+// authorizedFile does the same as authorizedFileFromRequest for the file id read from the request input named field.
+func authorizedFile(c *echo.Context, field, id string, need shareServices.Access) (*repositories.File, error) {
+	fileID, userID, err := parseFileRequest(c, field, id)
+	if fileID == uuid.Nil {
+		return nil, err
+	}
+	file, err := shareServices.AuthorizeFile(boxed.GetInstance().DbConn, userID, fileID, need)
+	if err != nil {
+		return nil, fileAccessError(c, err, fmt.Sprintf("There is no file with id %v.", id))
+	}
+	return file, nil
+}
+
+// ownedTrashedFileFromHeader resolves the file in the trash given in the `uuid` header and checks that the
+// authenticated user owns it, only owners manage their trash.
+// When the returned file is nil an error response has already been written and the caller must return the error as is.
+func ownedTrashedFileFromHeader(c *echo.Context) (*repositories.File, error) {
+	id := c.Request().Header.Get("uuid")
+	fileID, userID, err := parseFileRequest(c, "uuid", id)
+	if fileID == uuid.Nil {
+		return nil, err
+	}
+	file, err := repositories.NewFilesRepo(boxed.GetInstance().DbConn).GetTrashedByID(fileID)
+	if err == nil && file.OwnerID != userID {
+		err = shareServices.ErrForbidden
+	}
+	if err != nil {
+		return nil, fileAccessError(c, err, fmt.Sprintf("There is no file with id %v in the trash.", id))
+	}
+	return file, nil
+}
+
+// parseFileRequest parses the file id read from the request input named field, and the authenticated user's id.
+// When the returned file id is uuid.Nil an error response has already been written and the caller must return the error as is.
+func parseFileRequest(c *echo.Context, field, id string) (uuid.UUID, uuid.UUID, error) {
+	if id == "" {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: fmt.Sprintf("`%v` must be provided.", field),
+		}
+		return uuid.Nil, uuid.Nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	fileID, err := uuid.Parse(id)
+	if err != nil || fileID == uuid.Nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("`%v` provided is not valid.", field),
+		}
+		return uuid.Nil, uuid.Nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return uuid.Nil, uuid.Nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	return fileID, userID, nil
+}
+
+// fileAccessError translates the errors returned while resolving or changing a file into a response.
+// notFound is the message answered for pgx.ErrNoRows.
+func fileAccessError(c *echo.Context, err error, notFound string) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: notFound,
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	case errors.Is(err, shareServices.ErrForbidden):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user can't access this file, or doesn't have write access to it.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while accessing the file. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
+
+// withinQuota checks that ownerID, who will own the uploaded content, can store size more bytes.
+// When it returns false an error response has already been written and the caller must return the error as is.
+func withinQuota(c *echo.Context, ownerID uuid.UUID, size int64) (bool, error) {
+	if err := userServices.CheckQuota(boxed.GetInstance().DbConn, ownerID, size); err != nil {
+		return false, userServices.QuotaError(c, err)
+	}
+	return true, nil
+}
+
+// int64Param parses the optional, non negative number given in the query parameter name into dst, left
+// untouched when the parameter is omitted.
+// When it returns false an error response has already been written and the caller must return the error as is.
+func int64Param(c *echo.Context, name string, dst *int64) (bool, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return true, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("`%v` must be a positive number.", name),
+		}
+		return false, c.JSON(http.StatusBadRequest, &e)
+	}
+	*dst = n
+	return true, nil
+}
+
+// dateParam parses the optional date given in the query parameter name into dst, as an RFC 3339 date or a
+// `YYYY-MM-DD` day. With endOfDay a day is read as the end of that day, so it is included in the range it closes.
+// When it returns false an error response has already been written and the caller must return the error as is.
+func dateParam(c *echo.Context, name string, endOfDay bool, dst **time.Time) (bool, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		*dst = &t
+		return true, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("`%v` must be an RFC 3339 date or a `YYYY-MM-DD` day.", name),
+		}
+		return false, c.JSON(http.StatusBadRequest, &e)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	*dst = &t
+	return true, nil
+}
+
+// boolParam parses the optional boolean (`true`, `false`, `1`, `0`) given in the query parameter name into dst,
+// left untouched when the parameter is omitted.
+// When it returns false an error response has already been written and the caller must return the error as is.
+func boolParam(c *echo.Context, name string, dst *bool) (bool, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("`%v` must be `true` or `false`.", name),
+		}
+		return false, c.JSON(http.StatusBadRequest, &e)
+	}
+	*dst = b
+	return true, nil
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// GetFileVersionsController lists the previous versions of the file given in the `:uuid` path parameter
+// or the `uuid` header, newest first. The current version is the one described by get-file.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the versions on success.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid or the file doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to the file.
+func GetFileVersionsController(c *echo.Context) error {
+	file, err := authorizedFileFromRequest(c, shareServices.AccessRead)
+	if file == nil {
+		return err
+	}
+	versions, err := repositories.NewFileVersionsRepo(boxed.GetInstance().DbConn).GetByFileID(file.ID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: "Error while getting the versions of the file. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, versions)
+}
@@ -2,20 +2,35 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	folderServices "github.com/David/Boxed/internal/folders/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
 	"github.com/David/Boxed/repositories"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/labstack/echo/v5"
 )
 
-// GetFiles retrieves all files (Metadata) owned by the authenticated user and returns their metadata.
+// GetFiles retrieves a page of the files (Metadata) owned by the authenticated user and returns their metadata.
+// When a `folder` header is provided only the files stored directly inside that folder are returned,
+// the folder can be one shared with the user.
+// Query parameters:
+//   - `sort`: `name`, `size`, `created_at` (default) or `mime`, and `order`: `asc` or `desc`. The order defaults
+//     to `desc` for sizes and dates, `asc` otherwise.
+//   - `mime`: a mime type or its prefix, `name`: a part of the name, `from`, `to`: upload date range, as RFC 3339
+//     dates or `YYYY-MM-DD` days (`to` included).
+//   - `tag`: the name of one of the user's tags, and `favorite`: `true` for the starred files only.
+//   - `limit`: the number of files in the page, and `cursor`: the `next_cursor` of the previous page.
 //
 // Returns:
-//   - Responds with HTTP 200 (OK) along with a JSON payload containing file metadata.
+//   - Responds with HTTP 200 (OK) along with a JSON payload containing file metadata and the cursor of the next page.
+//   - Responds with HTTP 400 (Bad Request) if a parameter is invalid.
 //   - Responds with HTTP 404 (Not Found) if no files exist for the user.
 func GetFilesController(c *echo.Context) error {
 	user, err := echo.ContextGet[*types.ResponseClaims](c, "user")
@@ -36,8 +51,40 @@ func GetFilesController(c *echo.Context) error {
 		}
 		return c.JSON(http.StatusBadRequest, &e)
 	}
-	frepo := repositories.NewFilesRepo(boxed.GetInstance().DbConn)
-	files, err := frepo.GetByOwnerID(uid)
+	opts, ok, err := listOptionsFromQuery(c)
+	if !ok {
+		return err
+	}
+	opts.UserID = uid
+	db := boxed.GetInstance().DbConn
+	ownerID := uid
+	if folder := c.Request().Header.Get("folder"); folder != "" {
+		// The folder may be shared with the user, its files are then listed as its owner's.
+		f, ferr := folderServices.ResolveFolder(db, uid, folder, shareServices.AccessRead)
+		if ferr != nil {
+			if errors.Is(ferr, folderServices.ErrFolderNotOwned) {
+				e := &types.ErrorResponse{
+					Code:    types.WrongOwner,
+					Message: "This user can't access the folder given in `folder`.",
+				}
+				return c.JSON(http.StatusForbidden, &e)
+			}
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`folder` provided is not a valid folder uuid.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		ownerID, opts.Folder = f.OwnerID, &f.ID
+	}
+	page, err := services.ListFiles(db, ownerID, opts, c.QueryParam("cursor"))
+	if errors.Is(err, services.ErrInvalidCursor) {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`cursor` must be the `next_cursor` of a previous page with the same `sort` and `order`.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
 	if err != nil {
 		var pge *pgconn.PgError
 		if errors.As(err, &pge) {
@@ -56,11 +103,72 @@ func GetFilesController(c *echo.Context) error {
 		}
 	}
 	content := struct {
-		Length int `json:"length"`
-		Files  any `json:"files"`
+		Length     int    `json:"length"`
+		Files      any    `json:"files"`
+		NextCursor string `json:"next_cursor"` // Empty on the last page.
 	}{
-		Length: len(files),
-		Files:  files,
+		Length:     len(page.Files),
+		Files:      page.Files,
+		NextCursor: page.NextCursor,
 	}
 	return c.JSON(200, content)
 }
+
+// listOptionsFromQuery reads the sort, order, filters and page size of a file listing from the query parameters.
+// When it returns false an error response has already been written and the caller must return the error as is.
+func listOptionsFromQuery(c *echo.Context) (repositories.ListOptions, bool, error) {
+	opts := repositories.ListOptions{
+		Sort:         repositories.SortByCreatedAt,
+		MimePrefix:   strings.ToLower(strings.TrimSpace(c.QueryParam("mime"))),
+		NameContains: c.QueryParam("name"),
+		Tag:          strings.TrimSpace(c.QueryParam("tag")),
+		Limit:        services.DefaultListLimit,
+	}
+	if raw := c.QueryParam("sort"); raw != "" {
+		opts.Sort = repositories.FileSort(raw)
+		if !repositories.ValidFileSort(opts.Sort) {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`sort` must be `name`, `size`, `created_at` or `mime`.",
+			}
+			return opts, false, c.JSON(http.StatusBadRequest, &e)
+		}
+	}
+	switch c.QueryParam("order") {
+	case "":
+		opts.Descending = opts.Sort == repositories.SortBySize || opts.Sort == repositories.SortByCreatedAt
+	case "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`order` must be `asc` or `desc`.",
+		}
+		return opts, false, c.JSON(http.StatusBadRequest, &e)
+	}
+	if ok, err := dateParam(c, "from", false, &opts.CreatedAfter); !ok {
+		return opts, false, err
+	}
+	if ok, err := dateParam(c, "to", true, &opts.CreatedBefore); !ok {
+		return opts, false, err
+	}
+	if ok, err := boolParam(c, "favorite", &opts.Favorites); !ok {
+		return opts, false, err
+	}
+	if c.QueryParam("limit") != "" {
+		var limit int64
+		if ok, err := int64Param(c, "limit", &limit); !ok {
+			return opts, false, err
+		}
+		if limit == 0 || limit > services.MaxListLimit {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: fmt.Sprintf("`limit` must be a number between 1 and %d.", services.MaxListLimit),
+			}
+			return opts, false, c.JSON(http.StatusBadRequest, &e)
+		}
+		opts.Limit = int(limit)
+	}
+	return opts, true, nil
+}
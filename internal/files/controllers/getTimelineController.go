@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/files/services"
+	"github.com/labstack/echo/v5"
+)
+
+// GetTimelineController returns a page of the authenticated user's pictures and videos, most recently taken first,
+// grouped by day. Files are placed at the date in their EXIF or video tags, or at their upload date.
+// The `cursor` query parameter reads the page following the one that returned it, `limit` sets the number of
+// files in the page and `tz` the IANA time zone the days are cut in, UTC by default.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the page as JSON, its `next_cursor` is empty on the last page.
+//   - Responds with HTTP 400 (Bad Request) if the cursor, the limit or the time zone are invalid.
+func GetTimelineController(c *echo.Context) error {
+	limit := services.DefaultTimelineLimit
+	if raw := c.QueryParam("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > services.MaxTimelineLimit {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: fmt.Sprintf("`limit` must be a number between 1 and %d.", services.MaxTimelineLimit),
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		limit = n
+	}
+	loc := time.UTC
+	if raw := c.QueryParam("tz"); raw != "" {
+		var err error
+		if loc, err = time.LoadLocation(raw); err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`tz` must be an IANA time zone, such as `Europe/Paris`.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	timeline, err := services.GetTimeline(boxed.GetInstance().DbConn, userID, c.QueryParam("cursor"), limit, loc)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`cursor` must be the `next_cursor` of a previous page.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal server error while getting the timeline, Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, timeline)
+}
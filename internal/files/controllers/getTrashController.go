@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// GetTrashController lists the files the authenticated user has in the trash, most recently deleted first.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the files on success.
+//   - Responds with HTTP 500 (Internal Server Error) if the files couldn't be fetched.
+func GetTrashController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	files, err := repositories.NewFilesRepo(boxed.GetInstance().DbConn).GetTrashByOwnerID(userID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: "Error while getting the trash. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, files)
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// GetWaveformController returns the waveform of the audio file given in the `:uuid` path parameter: its duration
+// and the peaks to draw, from 0 to 100. The user must be able to read the file.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the waveform as JSON.
+//   - Responds with HTTP 202 (Accepted) and a `Retry-After` header while the audio hasn't been probed yet.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to the file.
+//   - Responds with HTTP 404 (Not Found) if the file isn't an audio file or its waveform couldn't be generated.
+func GetWaveformController(c *echo.Context) error {
+	file, err := authorizedFileFromRequest(c, shareServices.AccessRead)
+	if file == nil {
+		return err
+	}
+	waveform, err := repositories.NewWaveformsRepo(boxed.GetInstance().DbConn).GetByThumbnailID(file.ThumbnailId)
+	if err == nil {
+		return c.JSON(http.StatusOK, waveform)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: fmt.Sprintf("Internal error while getting the waveform of file %v.", file.ID),
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if strings.HasPrefix(file.MimeType, "audio/") && file.Metadata == nil {
+		c.Response().Header().Set("Retry-After", "5")
+		e := &types.ErrorResponse{
+			Code:    types.WaveformPending,
+			Message: fmt.Sprintf("The waveform of file %v is still being generated.", file.ID),
+		}
+		return c.JSON(http.StatusAccepted, &e)
+	}
+	e := &types.ErrorResponse{
+		Code:    types.ResourceNotFound,
+		Message: fmt.Sprintf("The file %v has no waveform.", file.ID),
+	}
+	return c.JSON(http.StatusNotFound, &e)
+}
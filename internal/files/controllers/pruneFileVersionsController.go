@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	fileTypes "github.com/David/Boxed/internal/files/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/labstack/echo/v5"
+)
+
+// PruneFileVersionsController deletes previous versions of the file given in the `uuid` header: the ones beyond
+// the `keep` newest, and the ones uploaded more than `older-than` ago. At least one of them must be given.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the number of deleted versions as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the body is invalid or the file doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the user has no write access to the file.
+func PruneFileVersionsController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	file, err := authorizedFileFromRequest(c, shareServices.AccessWrite)
+	if file == nil {
+		return err
+	}
+	var body fileTypes.PruneVersionsRequest
+	if err := echo.BindBody(c, &body); err != nil || body.Keep < 0 || (body.Keep == 0 && body.OlderThan == "") {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`keep` as a positive number and/or `older-than` as a duration must be provided.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	var before *time.Time
+	if body.OlderThan != "" {
+		age, err := time.ParseDuration(body.OlderThan)
+		if err != nil || age < 0 {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`older-than` must be a duration such as `720h`.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		limit := time.Now().Add(-age)
+		before = &limit
+	}
+	pruned, err := services.PruneFileVersions(boxed.GetInstance().DbConn, file.ID, body.Keep, before)
+	if err != nil {
+		return fileAccessError(c, err, "The file doesn't exist anymore.")
+	}
+	return c.JSON(http.StatusOK, map[string]int{"pruned": pruned})
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	"github.com/labstack/echo/v5"
+)
+
+// PurgeFileController permanently deletes the file given in the `uuid` header from the trash,
+// along with its thumbnail and stored content.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) on success.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid or the file isn't in the trash.
+//   - Responds with HTTP 403 (Forbidden) if the file belongs to another user.
+func PurgeFileController(c *echo.Context) error {
+	file, err := ownedTrashedFileFromHeader(c)
+	if file == nil {
+		return err
+	}
+	if err := services.PurgeFile(boxed.GetInstance().DbConn, file); err != nil {
+		log.Println("Error while purging file:", err)
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while deleting the file. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// RestoreFileController takes the file given in the `uuid` header out of the trash, back into its folder.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) on success.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid or the file isn't in the trash.
+//   - Responds with HTTP 403 (Forbidden) if the file belongs to another user.
+func RestoreFileController(c *echo.Context) error {
+	file, err := ownedTrashedFileFromHeader(c)
+	if file == nil {
+		return err
+	}
+	if err := repositories.NewFilesRepo(boxed.GetInstance().DbConn).Restore(file.ID); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal error while restoring the file. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
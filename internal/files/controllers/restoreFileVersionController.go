@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	fileTypes "github.com/David/Boxed/internal/files/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/labstack/echo/v5"
+)
+
+// RestoreFileVersionController makes a previous version the current content of the file given in the `uuid` header.
+// The restored content gets a new version number and the replaced one is kept as a version.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated file as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the body is invalid or the file or version doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the user has no write access to the file.
+func RestoreFileVersionController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	file, err := authorizedFileFromRequest(c, shareServices.AccessWrite)
+	if file == nil {
+		return err
+	}
+	var body fileTypes.RestoreVersionRequest
+	if err := echo.BindBody(c, &body); err != nil || body.Version <= 0 {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`version` must be provided as a version number.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	restored, err := services.RestoreFileVersion(boxed.GetInstance().DbConn, file.ID, body.Version)
+	if err != nil {
+		return fileAccessError(c, err, fmt.Sprintf("File %v has no version %v.", file.ID, body.Version))
+	}
+	return c.JSON(http.StatusOK, restored)
+}
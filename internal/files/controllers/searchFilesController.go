@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// SearchFilesController searches the authenticated user's files by name, by tag and by the text extracted from their
+// content (PDFs, text and office documents), best matches first.
+// Query parameters:
+//   - `q`: the words to look for, in web search syntax (`"exact phrase"`, `or`, `-excluded`). Omitted to only filter.
+//   - `mime`: a mime type or its prefix, such as `image/` or `application/pdf`.
+//   - `min_size`, `max_size`: size range in bytes.
+//   - `from`, `to`: upload date range, as RFC 3339 dates or `YYYY-MM-DD` days (`to` included).
+//   - `tag`: the name of one of the user's tags, and `favorite`: `true` for the starred files only.
+//   - `limit`, `offset`: the page of results.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the results as JSON.
+//   - Responds with HTTP 400 (Bad Request) if a parameter is invalid.
+func SearchFilesController(c *echo.Context) error {
+	opts := repositories.SearchOptions{
+		Query:      strings.TrimSpace(c.QueryParam("q")),
+		MimePrefix: strings.ToLower(strings.TrimSpace(c.QueryParam("mime"))),
+		Tag:        strings.TrimSpace(c.QueryParam("tag")),
+		Limit:      services.DefaultSearchLimit,
+	}
+	var limit, offset int64
+	if ok, err := int64Param(c, "min_size", &opts.MinSize); !ok {
+		return err
+	}
+	if ok, err := int64Param(c, "max_size", &opts.MaxSize); !ok {
+		return err
+	}
+	if ok, err := dateParam(c, "from", false, &opts.CreatedAfter); !ok {
+		return err
+	}
+	if ok, err := dateParam(c, "to", true, &opts.CreatedBefore); !ok {
+		return err
+	}
+	if ok, err := boolParam(c, "favorite", &opts.Favorites); !ok {
+		return err
+	}
+	if ok, err := int64Param(c, "limit", &limit); !ok {
+		return err
+	}
+	if ok, err := int64Param(c, "offset", &offset); !ok {
+		return err
+	}
+	if c.QueryParam("limit") != "" {
+		if limit == 0 || limit > services.MaxSearchLimit {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: fmt.Sprintf("`limit` must be a number between 1 and %d.", services.MaxSearchLimit),
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		opts.Limit = int(limit)
+	}
+	opts.Offset = int(offset)
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	hits, err := services.SearchFiles(boxed.GetInstance().DbConn, userID, opts)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal server error while searching files, Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	content := struct {
+		Length  int                  `json:"length"`
+		Results []services.SearchHit `json:"results"`
+	}{
+		Length:  len(hits),
+		Results: hits,
+	}
+	return c.JSON(http.StatusOK, content)
+}
@@ -3,14 +3,14 @@ package controllers
 import (
 	"errors"
 	"fmt"
-	"mime"
 	"net/http"
-	"path"
-	"strings"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal/common/types"
 	"github.com/David/Boxed/internal/files/services"
+	folderServices "github.com/David/Boxed/internal/folders/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	userServices "github.com/David/Boxed/internal/users/services"
 	"github.com/David/Boxed/repositories"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,10 +19,14 @@ import (
 )
 
 // SendFile uploads a single file for the authenticated user and saves both the file and its metadata to disk and the database.
+// When the `version-of` form value names one of the user's files, the upload becomes its new version instead of a new file.
 //
 // Returns:
 //   - Responds with HTTP 201 (Created) on success.
 //   - Responds with HTTP 400 (Bad Request) if the file or user info is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the user can't write to the folder or to the file of `version-of`.
+//   - Responds with HTTP 404 (Not Found) if the file of `version-of` was trashed or deleted while uploading.
+//   - Responds with HTTP 413 (Request Entity Too Large) if the file would exceed the owner's storage quota.
 //   - Returns an error if saving the file or metadata fails.
 func SendFileController(c *echo.Context) error {
 	db := boxed.GetInstance().DbConn
@@ -71,15 +75,41 @@ func SendFileController(c *echo.Context) error {
 			return c.JSON(http.StatusInternalServerError, &em)
 		}
 	}
+	// Destination folder, the root when not provided. It can be a folder shared with write access.
+	ownerID, folderID, err := folderServices.UploadDestination(db, user.ID, c.FormValue("folder"))
+	if err != nil {
+		if errors.Is(err, folderServices.ErrFolderNotOwned) {
+			e := &types.ErrorResponse{
+				Code:    types.WrongOwner,
+				Message: "This user can't upload to the folder given in `folder`.",
+			}
+			return c.JSON(http.StatusForbidden, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`folder` provided is not a valid folder uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	// File receiving a new version, checked before storing anything.
+	var versionOf *repositories.File
+	if raw := c.FormValue("version-of"); raw != "" {
+		versionOf, err = authorizedFile(c, "version-of", raw, shareServices.AccessWrite)
+		if versionOf == nil {
+			return err
+		}
+	}
+	// The content is counted in the usage of whoever owns it: the folder's owner, or the versioned file's.
+	if versionOf != nil {
+		ownerID = versionOf.OwnerID
+	}
+	if ok, err := withinQuota(c, ownerID, file.Size); !ok {
+		return err
+	}
 	// metadata info
-	m := file.Header.Get("Content-Type")
-	typet, _ := mime.ExtensionsByType(m)
-	fileId := uuid.New()
-	filename := fmt.Sprintf("%v%v", fileId.String(), typet[0])
-	originalName := strings.Split(file.Filename, ".")[0]
-	filePath := path.Join(user.FolderPath, filename)
-	// Create the file to the os
-	err = services.SaveFile(filePath, file)
+	info := services.InfoFromHeader(file)
+	// Store the content
+	blob, err := services.SaveFile(db, file)
 	if err != nil {
 		e := &types.ErrorResponse{
 			Code:    types.InternalServerError,
@@ -87,24 +117,42 @@ func SendFileController(c *echo.Context) error {
 		}
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
-	// Setup thumbnail
-	thumbnailRepository := repositories.NewThumbnailRepository(db)
-	thumbnailUUID := uuid.New()
-	thumbnailRepository.Create(&repositories.Thumbnail{
-		ID:      thumbnailUUID,
-		OwnerId: user.ID,
-	})
-	thumbnailPath := path.Join(user.FolderPath, fmt.Sprintf("/thumbnail/%v.jpg", thumbnailUUID))
-	// Generate Thumbnail
-	go services.CreateAndSaveThumbnail(filePath, thumbnailPath, m, originalName, thumbnailUUID, thumbnailRepository)
-	err = services.SaveFileToDatabase(db, file, fileId, id, filePath, thumbnailUUID)
+	if versionOf != nil {
+		_, err = services.RegisterFileVersion(db, info, versionOf.ID, blob)
+	} else {
+		err = services.RegisterStoredFile(db, ownerID, info, uuid.New(), folderID, blob)
+	}
 	if err != nil {
+		return registerError(c, err, versionOf)
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+// registerError translates the error returned while registering an uploaded content into a response.
+// versionOf is the file receiving the content as a new version, nil for a new file.
+func registerError(c *echo.Context, err error, versionOf *repositories.File) error {
+	switch {
+	case errors.Is(err, userServices.ErrQuotaExceeded):
+		return userServices.QuotaError(c, err)
+	case versionOf != nil && errors.Is(err, pgx.ErrNoRows):
+		// Trashed or deleted since its access was checked.
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("There is no file with id %v to add a version to.", versionOf.ID),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	case errors.Is(err, shareServices.ErrForbidden):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user can't access this file, or doesn't have write access to it.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	default:
 		e := &types.ErrorResponse{
 			Code:    types.InternalServerError,
 			Message: "Error while trying to save a file to the database. Please try later.",
 		}
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
-
-	return c.NoContent(http.StatusCreated)
 }
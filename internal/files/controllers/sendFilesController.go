@@ -3,14 +3,12 @@ package controllers
 import (
 	"errors"
 	"fmt"
-	"mime"
 	"net/http"
-	"path"
-	"strings"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal/common/types"
 	"github.com/David/Boxed/internal/files/services"
+	folderServices "github.com/David/Boxed/internal/folders/services"
 	"github.com/David/Boxed/repositories"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -22,6 +20,7 @@ import (
 // Returns:
 //   - Responds with HTTP 201 (Created) after successfully processing all files.
 //   - Responds with HTTP 400 (Bad Request) if form data or file inputs are invalid.
+//   - Responds with HTTP 413 (Request Entity Too Large) if the files would exceed the owner's storage quota.
 func SendFilesController(c *echo.Context) error {
 	db := boxed.GetInstance().DbConn
 	ur := repositories.NewUserRepo(db)
@@ -80,34 +79,43 @@ func SendFilesController(c *echo.Context) error {
 		}
 		return c.JSON(http.StatusBadRequest, &e)
 	}
+	// Destination folder, the root when not provided. It can be a folder shared with write access.
+	ownerID, folderID, err := folderServices.UploadDestination(db, user.ID, c.FormValue("folder"))
+	if err != nil {
+		if errors.Is(err, folderServices.ErrFolderNotOwned) {
+			e := &types.ErrorResponse{
+				Code:    types.WrongOwner,
+				Message: "This user can't upload to the folder given in `folder`.",
+			}
+			return c.JSON(http.StatusForbidden, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`folder` provided is not a valid folder uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	// The whole batch is refused when it doesn't fit in the quota.
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	if ok, err := withinQuota(c, ownerID, total); !ok {
+		return err
+	}
 	// Track of files that failed to update.
 	var failed []string
 	// Iterate over files
 	for _, file := range files {
-		m := file.Header.Get("Content-Type")
-		typet, _ := mime.ExtensionsByType(m)
-		fileId := uuid.New()
-		filename := fmt.Sprintf("%v%v", fileId.String(), typet[0])
-		filePath := path.Join(user.FolderPath, filename)
-		originalName := strings.Split(file.Filename, ".")[0]
-		thumbnailPath := path.Join(user.FolderPath, fmt.Sprintf("/thumbnail/%v", filename))
+		info := services.InfoFromHeader(file)
 
-		saveErr := services.SaveFile(filePath, file)
+		blob, saveErr := services.SaveFile(db, file)
 		if saveErr != nil {
 			c.Logger().Error(saveErr.Error())
+			failed = append(failed, file.Filename)
 			continue
 		}
-
-		// Setup thumbnail
-		thumbnailRepository := repositories.NewThumbnailRepository(db)
-		thumbnailUUID := uuid.New()
-		thumbnailRepository.Create(&repositories.Thumbnail{
-			ID:      thumbnailUUID,
-			OwnerId: user.ID,
-		})
-		// Generate Thumbnail
-		go services.CreateAndSaveThumbnail(filePath, thumbnailPath, m, originalName, thumbnailUUID, thumbnailRepository)
-		err = services.SaveFileToDatabase(db, file, fileId, id, filePath, thumbnailUUID)
+		err = services.RegisterStoredFile(db, ownerID, info, uuid.New(), folderID, blob)
 		if err != nil {
 			failed = append(failed, file.Filename)
 			continue
@@ -2,74 +2,53 @@ package controllers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
 	"github.com/David/Boxed/repositories"
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
 )
 
 // ServeFile streams a requested file to the user based on its UUID.
-// Authorization is validated to ensure the requesting user owns the file.
+// Authorization is validated to ensure the requesting user owns the file or has it shared with them.
+// The UUID is taken from the `:uuid` path parameter, or from the `uuid` header for older clients.
+//
+// Range and conditional requests are supported: the ETag is the file's SHA-256 and Last-Modified the upload date of its current version.
 //
 // Returns:
-//   - Responds with the file content if successful.
-//   - Responds with HTTP 403 (Forbidden) if the user is not authorized.
+//   - Responds with the file content (HTTP 200, or HTTP 206 for ranges) if successful.
+//   - Responds with HTTP 304 (Not Modified) if the client's cached copy is still valid.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to the file.
 //   - Responds with HTTP 400 (Bad Request) or HTTP 401 (Unauthorized) based on validation errors.
 func ServeFileController(c *echo.Context) error {
-	// Extract file UUID from path parameter
-	id := c.Request().Header.Get("uuid")
-	if id == "" {
-		e := &types.ErrorResponse{
-			Code:    types.MissingFields,
-			Message: "You must provide a `uuid` entry to serve file.",
-		}
-		return c.JSON(http.StatusBadRequest, &e)
-	}
-	uid, err := uuid.Parse(id)
-	if err != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InvalidFields,
-			Message: "`uuid` provided is not valid.",
-		}
-		return c.JSON(http.StatusBadRequest, &e)
-	}
-	// Verify user authorization
-	userClaims, err := echo.ContextGet[*types.ResponseClaims](c, "user")
-	if err != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
-			Message: "Error while getting user from jwt, please try again.",
-		}
-		return c.JSON(http.StatusInternalServerError, &e)
+	file, err := authorizedFileFromRequest(c, shareServices.AccessRead)
+	if file == nil {
+		return err
 	}
-	userID, err := uuid.Parse(userClaims.Subject)
-	if err != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InternalServerError,
-			Message: "Internal error while parsing user uuid, please try again.",
-		}
-		return c.JSON(http.StatusInternalServerError, &e)
-	}
-	// Query file details
 	fileRepo := repositories.NewFilesRepo(boxed.GetInstance().DbConn)
-	file, err := fileRepo.GetByID(uid)
+	if err := services.EnsureFileHash(fileRepo, file); err != nil {
+		log.Printf("Couldn't hash file %v: %v", file.ID, err)
+	}
+	// Serve the file
+	err = services.ServeStoredContent(c.Response(), c.Request(), file.StoragePath, file.MimeType, file.ContentHash, file.UpdatedAt, "private, no-cache")
 	if err != nil {
 		e := &types.ErrorResponse{
-			Code:    types.ResourceNotFound,
-			Message: fmt.Sprintf("Couldn't get any file with uuid: %v", uid.String()),
+			Code:    types.FileFetchFailed,
+			Message: fmt.Sprintf("The content of file %v couldn't be read.", file.ID),
 		}
-		return c.JSON(http.StatusBadRequest, &e)
+		return c.JSON(http.StatusNotFound, &e)
 	}
-	if file.OwnerID != userID {
-		e := &types.ErrorResponse{
-			Code:    types.WrongOwner,
-			Message: "This user don't own this resource.",
-		}
-		return c.JSON(http.StatusForbidden, &e)
+	return nil
+}
+
+// idFromRequest returns the resource uuid given in the `:uuid` path parameter, falling back to the `uuid` header.
+func idFromRequest(c *echo.Context) string {
+	if id := c.Param("uuid"); id != "" {
+		return id
 	}
-	// Serve the file
-	return c.File(file.StoragePath)
+	return c.Request().Header.Get("uuid")
 }
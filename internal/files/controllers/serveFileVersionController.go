@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// ServeFileVersionController streams a previous version of a file, given by the `:uuid` and `:version` path parameters.
+// Range and conditional requests are supported the same way as for serve-file.
+//
+// Returns:
+//   - Responds with the version content (HTTP 200, or HTTP 206 for ranges) if successful.
+//   - Responds with HTTP 400 (Bad Request) if the file or the version doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to the file.
+func ServeFileVersionController(c *echo.Context) error {
+	file, err := authorizedFileFromRequest(c, shareServices.AccessRead)
+	if file == nil {
+		return err
+	}
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`version` must be a version number.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	version, err := repositories.NewFileVersionsRepo(boxed.GetInstance().DbConn).GetByVersion(file.ID, number)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: fmt.Sprintf("File %v has no version %v.", file.ID, number),
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while looking up the version. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	err = services.ServeStoredContent(c.Response(), c.Request(), version.StoragePath, version.MimeType, version.ContentHash, version.CreatedAt, "private, no-cache")
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: fmt.Sprintf("The content of version %v of file %v couldn't be read.", number, file.ID),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// ServeStreamController streams the HLS playlists and segments of the video given in the `:uuid` path parameter.
+// The rest of the path names the object: `master.m3u8`, then the playlists and segments it refers to relatively.
+// The user must be able to read the file. The stream follows the current content of the file, so nothing is cached
+// without revalidation.
+//
+// Returns:
+//   - Responds with the playlist or segment (HTTP 200, or HTTP 206 for ranges) if successful.
+//   - Responds with HTTP 202 (Accepted) and a `Retry-After` header while the video is being transcoded.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to the file.
+//   - Responds with HTTP 404 (Not Found) if the file has no stream, its transcoding failed or the object doesn't exist.
+func ServeStreamController(c *echo.Context) error {
+	file, err := authorizedFileFromRequest(c, shareServices.AccessRead)
+	if file == nil {
+		return err
+	}
+	stream, err := repositories.NewVideoStreamsRepo(boxed.GetInstance().DbConn).GetByThumbnailID(file.ThumbnailId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: fmt.Sprintf("The file %v has no stream, serve it whole instead.", file.ID),
+			}
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: fmt.Sprintf("Internal error while getting the stream of file %v.", file.ID),
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	switch stream.Status {
+	case repositories.StreamPending:
+		c.Response().Header().Set("Retry-After", "30")
+		e := &types.ErrorResponse{
+			Code:    types.StreamPending,
+			Message: fmt.Sprintf("The file %v is still being transcoded.", file.ID),
+		}
+		return c.JSON(http.StatusAccepted, &e)
+	case repositories.StreamFailed:
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("The file %v couldn't be transcoded, serve it whole instead.", file.ID),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	key, mimeType, ok := services.StreamObjectKey(stream, c.Param("*"))
+	if !ok {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("The stream of file %v has no %q.", file.ID, c.Param("*")),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	err = services.ServeStoredContent(c.Response(), c.Request(), key, mimeType, "", time.Time{}, "private, no-cache")
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: fmt.Sprintf("The stream of file %v couldn't be read.", file.ID),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	return nil
+}
@@ -3,20 +3,30 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	boxed "github.com/David/Boxed"
 	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
 	"github.com/David/Boxed/repositories"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/labstack/echo/v5"
 )
 
 // ServeThumbnailController streams a requested thumbnail bassed on its UUID.
+// The UUID is taken from the `:uuid` path parameter, or from the `uuid` header for older clients.
+// The user must own the thumbnail or be able to read the file using it.
+// Thumbnails never change once generated, so they are sent with a strong ETag and may be cached by the client.
+// While its generation job hasn't run the thumbnail is answered with HTTP 202 (Accepted) and a `Retry-After` header.
+// The optional `size` (small, medium, large) and `format` (jpeg, webp) query parameters select a variant,
+// which is rendered on its first request; the default is the medium jpeg thumbnail.
 func ServeThumbnailController(c *echo.Context) error {
-	uid := c.Request().Header.Get("uuid")
+	uid := idFromRequest(c)
 
 	if uid == "" {
 		e := &types.ErrorResponse{
@@ -33,9 +43,17 @@ func ServeThumbnailController(c *echo.Context) error {
 		}
 		return c.JSON(http.StatusBadRequest, &e)
 	}
+	size, format := c.QueryParam("size"), c.QueryParam("format")
+	if err := services.CheckThumbnailVariant(size, format); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`size` must be small, medium or large and `format` jpeg or webp.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
 
 	// Verify user authorization
-	userClaims, err := echo.ContextGet[*types.ResponseClaims](c, "user")
+	userID, err := utils.GetUserID(c)
 	if err != nil {
 		e := &types.ErrorResponse{
 			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
@@ -43,49 +61,60 @@ func ServeThumbnailController(c *echo.Context) error {
 		}
 		return c.JSON(http.StatusInternalServerError, &e)
 	}
-	userID, err := uuid.Parse(userClaims.Subject)
-	if err != nil {
-		e := &types.ErrorResponse{
-			Code:    types.InternalServerError,
-			Message: "Internal error while parsing user uuid, please try again.",
-		}
-		return c.JSON(http.StatusInternalServerError, &e)
-	}
 
 	repository := repositories.NewThumbnailRepository(boxed.GetInstance().DbConn)
 	thumbnail, err := repository.GetByID(thumbnailUUID)
 	if err != nil {
-
-		var pge *pgconn.PgError
-		if errors.As(err, &pge) || errors.As(err, &pgx.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			e := &types.ErrorResponse{
 				Code:    types.ResourceNotFound,
 				Message: fmt.Sprintf("No thumbnail with uuid: %v", uid),
 			}
 			return c.JSON(http.StatusBadRequest, &e)
 		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: fmt.Sprintf("Internal error while getting thumbnail with uuid: %v", uid),
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if err := shareServices.AuthorizeThumbnail(boxed.GetInstance().DbConn, userID, thumbnail); err != nil {
+		return fileAccessError(c, err, fmt.Sprintf("No thumbnail with uuid: %v", uid))
+	}
+	if thumbnail.Status == repositories.ThumbnailPending {
+		c.Response().Header().Set("Retry-After", "5")
+		e := &types.ErrorResponse{
+			Code:    types.ThumbnailPending,
+			Message: fmt.Sprintf("The thumbnail %v is still being generated.", uid),
+		}
+		return c.JSON(http.StatusAccepted, &e)
 	}
 	if thumbnail.StoragePath == "" {
 		e := &types.ErrorResponse{
 			Code:    types.ResourceNotFound,
-			Message: fmt.Sprintf("No thumbnail to serve with uuid: %v", uid),
+			Message: fmt.Sprintf("No thumbnail to serve with uuid: %v (%v)", uid, thumbnail.Status),
 		}
 		return c.JSON(http.StatusBadRequest, &e)
 	}
-
+	if err := services.EnsureThumbnailHash(repository, thumbnail); err != nil {
+		log.Printf("Couldn't hash thumbnail %v: %v", thumbnail.ID, err)
+	}
+	variant, err := services.GetThumbnailVariant(c.Request().Context(), boxed.GetInstance().DbConn, thumbnail, size, format)
 	if err != nil {
+		log.Printf("Couldn't get the %v %v variant of thumbnail %v: %v", size, format, thumbnail.ID, err)
 		e := &types.ErrorResponse{
-			Code:    types.ResourceNotFound,
-			Message: fmt.Sprintf("Couldn't get any thumbnail with uuid: %v", thumbnailUUID.String()),
+			Code:    types.FileFetchFailed,
+			Message: fmt.Sprintf("The thumbnail %v couldn't be rendered in this size.", uid),
 		}
-		return c.JSON(http.StatusBadRequest, &e)
+		return c.JSON(http.StatusInternalServerError, &e)
 	}
-	if thumbnail.OwnerId != userID {
+	err = services.ServeStoredContent(c.Response(), c.Request(), variant.StoragePath, "image/"+variant.Format, variant.ContentHash, time.Time{}, "private, max-age=86400")
+	if err != nil {
 		e := &types.ErrorResponse{
-			Code:    types.WrongOwner,
-			Message: "This user don't own this resource.",
+			Code:    types.FileFetchFailed,
+			Message: fmt.Sprintf("The thumbnail %v couldn't be read.", uid),
 		}
-		return c.JSON(http.StatusForbidden, &e)
+		return c.JSON(http.StatusNotFound, &e)
 	}
-	return c.File(thumbnail.StoragePath)
+	return nil
 }
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// ServeVideoPreviewController serves the scrubbing preview of the video given in the `:uuid` path parameter.
+// `:name` is either `thumbnails.vtt`, the WebVTT track to give to the player, or `sprite.jpg`, the sprite sheet its
+// cues point at. The user must be able to read the file. The preview follows the current content of the file,
+// so nothing is cached without revalidation.
+//
+// Returns:
+//   - Responds with the track or the sprite sheet if successful.
+//   - Responds with HTTP 202 (Accepted) and a `Retry-After` header while the preview is being generated.
+//   - Responds with HTTP 403 (Forbidden) if the user has no read access to the file.
+//   - Responds with HTTP 404 (Not Found) if the file isn't a video, its preview couldn't be generated or `:name` is unknown.
+func ServeVideoPreviewController(c *echo.Context) error {
+	file, err := authorizedFileFromRequest(c, shareServices.AccessRead)
+	if file == nil {
+		return err
+	}
+	preview, err := repositories.NewVideoPreviewsRepo(boxed.GetInstance().DbConn).GetByThumbnailID(file.ThumbnailId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: fmt.Sprintf("The file %v has no preview.", file.ID),
+			}
+			return c.JSON(http.StatusNotFound, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: fmt.Sprintf("Internal error while getting the preview of file %v.", file.ID),
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	switch preview.Status {
+	case repositories.PreviewPending:
+		c.Response().Header().Set("Retry-After", "10")
+		e := &types.ErrorResponse{
+			Code:    types.PreviewPending,
+			Message: fmt.Sprintf("The preview of file %v is still being generated.", file.ID),
+		}
+		return c.JSON(http.StatusAccepted, &e)
+	case repositories.PreviewFailed:
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("The preview of file %v couldn't be generated.", file.ID),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	key, mimeType, ok := services.PreviewObjectKey(preview, c.Param("name"))
+	if !ok {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("`name` must be `%v` or `%v`.", services.PreviewTrack, services.PreviewSprite),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	err = services.ServeStoredContent(c.Response(), c.Request(), key, mimeType, "", time.Time{}, "private, no-cache")
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: fmt.Sprintf("The preview of file %v couldn't be read.", file.ID),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	return nil
+}
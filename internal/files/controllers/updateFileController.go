@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/files/services"
+	fileTypes "github.com/David/Boxed/internal/files/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// UpdateFileController renames, moves and/or changes the description of the file given in the `:uuid` path
+// parameter or the `uuid` header. Fields left out of the body are kept. A new name keeps the full extension of
+// the current one (`.tar.gz`), so only the content decides the file type.
+// The body carries the `revision` of the file the changes were made on; if the file changed since, nothing is
+// changed and the client must read it again, so two clients don't overwrite each other.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated file as JSON, holding its new `Revision`.
+//   - Responds with HTTP 400 (Bad Request) if the uuid, the revision, the name, the folder or the description is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the user has no write access to the file or the folder, or the folder
+//     belongs to somebody else than the file's owner.
+//   - Responds with HTTP 404 (Not Found) if the folder doesn't exist.
+//   - Responds with HTTP 409 (Conflict) if the file changed since `revision`.
+func UpdateFileController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	file, err := authorizedFileFromRequest(c, shareServices.AccessWrite)
+	if file == nil {
+		return err
+	}
+	var body fileTypes.UpdateFileRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to update a file.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if body.Revision <= 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`revision` must be the `Revision` of the file the changes were made on.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	db := boxed.GetInstance().DbConn
+	updated := *file
+	if body.Name != nil {
+		updated.OriginalName = services.KeepExtension(strings.TrimSpace(*body.Name), file.OriginalName)
+		if err := services.ValidateFileName(updated.OriginalName); err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`name` must not be blank, `.` or `..`, contain slashes or line breaks, nor be longer than 255 characters.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+	}
+	if body.Description != nil {
+		if err := services.ValidateDescription(*body.Description); err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: fmt.Sprintf("`description` must not be longer than %d characters.", services.MaxDescription),
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		updated.Description = *body.Description
+	}
+	if body.Folder != nil {
+		updated.FolderID, err = services.MoveDestination(db, userID, file, *body.Folder)
+		if err != nil {
+			return moveDestinationError(c, err)
+		}
+	}
+	if err := services.UpdateFileDetails(db, &updated, body.Revision); err != nil {
+		if errors.Is(err, services.ErrRevisionMismatch) {
+			e := &types.ErrorResponse{
+				Code:    types.RevisionMismatch,
+				Message: "The file changed since this `revision`. Please get it again before changing it.",
+			}
+			return c.JSON(http.StatusConflict, &e)
+		}
+		return fileAccessError(c, err, fmt.Sprintf("There is no file with id %v.", file.ID))
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// moveDestinationError translates the errors of services.MoveDestination into a response.
+func moveDestinationError(c *echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidDestination):
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`folder` provided is not a valid folder uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: "The folder given in `folder` doesn't exist.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	case errors.Is(err, shareServices.ErrForbidden):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user can't write to the folder given in `folder`.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	case errors.Is(err, services.ErrForeignDestination):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "Files can only be moved between folders of their owner.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while looking up the folder. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
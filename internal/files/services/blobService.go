@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BlobKey returns the storage key of the blob with the given hash: `blobs/<ab>/<cd>/<hash>`.
+func BlobKey(hash string) string {
+	return path.Join("blobs", hash[0:2], hash[2:4], hash)
+}
+
+// TempDir returns the local directory holding files that are being received, creating it if needed.
+// It lives below FOLDER_PATH so that the local backend can take them over with a rename.
+func TempDir() (string, error) {
+	dir := path.Join(boxed.GetInstance().FolderPath, ".tmp")
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+// StoreBlob stores the content of a local file as a blob, or adds a reference to the blob that already holds the same content.
+// On success the local file is consumed; on failure it is left in place.
+//
+// Parameters:
+//   - localPath: The file holding the content.
+//   - hash: Hex encoded SHA-256 of the content.
+//   - size: Size of the content in bytes.
+//
+// Returns:
+//   - The blob now referencing the content.
+func StoreBlob(db *pgxpool.Pool, localPath, hash string, size int64) (*repositories.Blob, error) {
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	blob := &repositories.Blob{
+		Hash:        hash,
+		StoragePath: BlobKey(hash),
+		Size:        size,
+		CreatedAt:   time.Now(),
+	}
+	created, err := repositories.NewBlobsRepo(db).WithTx(t).Acquire(blob)
+	if err != nil {
+		return nil, err
+	}
+	// The row lock taken by Acquire is held until commit, so nobody can use the blob before the object exists.
+	if created {
+		if err := storage.PutFile(context.Background(), boxed.GetInstance().Storage, blob.StoragePath, localPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.Commit(context.Background()); err != nil {
+		return nil, err
+	}
+	if !created {
+		os.Remove(localPath) // Same content already stored.
+	}
+	return blob, nil
+}
+
+// ReleaseBlob drops a reference to a blob. The stored object is deleted together with the last reference.
+//
+// The release is committed first, so the dropped reference can't come back with a failed rollback
+// once the object is gone.
+func ReleaseBlob(db *pgxpool.Pool, hash string) error {
+	blob, err := repositories.NewBlobsRepo(db).Release(hash)
+	if err != nil || blob.RefCount > 0 {
+		return err
+	}
+	return deleteUnreferencedBlob(db, hash)
+}
+
+// deleteUnreferencedBlob deletes the object then the row of a blob left without references.
+// The row stays locked meanwhile, so a concurrent upload of the same content waits and then stores the object again.
+// Nothing is deleted if the blob has been referenced again since its release.
+func deleteUnreferencedBlob(db *pgxpool.Pool, hash string) error {
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	repo := repositories.NewBlobsRepo(db).WithTx(t)
+	blob, err := repo.LockUnreferenced(hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := boxed.GetInstance().Storage.Delete(context.Background(), blob.StoragePath); err != nil {
+		return err
+	}
+	if err := repo.Delete(hash); err != nil {
+		return err
+	}
+	return t.Commit(context.Background())
+}
+
+// DeleteFileContent removes the stored content of a file whose row has been deleted: its blob reference,
+// or its own object for files stored before deduplication.
+//
+// Logs:
+//   - Logs an error message if the content cannot be deleted successfully.
+func DeleteFileContent(db *pgxpool.Pool, file *repositories.File) {
+	releaseContent(db, file.BlobHash, file.StoragePath)
+}
+
+// DeleteVersionContent does the same as DeleteFileContent for a deleted file version.
+func DeleteVersionContent(db *pgxpool.Pool, version *repositories.FileVersion) {
+	releaseContent(db, version.BlobHash, version.StoragePath)
+}
+
+// releaseContent releases a blob reference, or deletes the object at storagePath when blobHash is nil.
+func releaseContent(db *pgxpool.Pool, blobHash *string, storagePath string) {
+	if blobHash == nil {
+		DeleteFile(storagePath)
+		return
+	}
+	if err := ReleaseBlob(db, *blobHash); err != nil {
+		log.Printf("Fatal error: couldn't release blob %v of %v: %v", *blobHash, storagePath, err)
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BulkOperation is an operation RunBulk applies to many files at once.
+type BulkOperation string
+
+const (
+	BulkDelete  BulkOperation = "delete"  // Moves the files to the trash, needs write access.
+	BulkRestore BulkOperation = "restore" // Takes the files out of the trash, owners only.
+	BulkMove    BulkOperation = "move"    // Moves the files to BulkRequest.Folder, needs write access.
+	BulkTag     BulkOperation = "tag"     // Puts BulkRequest.Tags on the files, owners only.
+)
+
+// ValidBulkOperation reports whether op can be run by RunBulk.
+func ValidBulkOperation(op BulkOperation) bool {
+	switch op {
+	case BulkDelete, BulkRestore, BulkMove, BulkTag:
+		return true
+	}
+	return false
+}
+
+// BulkRequest is an operation on many files.
+type BulkRequest struct {
+	Operation BulkOperation
+	Files     []uuid.UUID
+	Folder    *repositories.Folder // Destination of BulkMove, see ResolveDestination. nil for the user's root.
+	Tags      []string             // Names of the tags of BulkTag, missing tags are created.
+}
+
+// RunBulk applies an operation to many files of userID. Every file is authorized on its own, as if it was the
+// only one, and the ones that can't be changed are skipped. The others are changed in a single transaction.
+//
+// Returns:
+//   - The error of each file that was skipped, by file id:
+//     pgx.ErrNoRows if it doesn't exist, or is in the trash (out of the trash for BulkRestore),
+//     shareServices.ErrForbidden if userID doesn't have the needed access,
+//     ErrFileNotOwned if userID doesn't own it for BulkTag and BulkRestore,
+//     ErrForeignDestination if BulkMove's destination belongs to somebody else than its owner.
+//   - An error if the transaction failed, in which case no file was changed.
+func RunBulk(db *pgxpool.Pool, userID uuid.UUID, req BulkRequest) (map[uuid.UUID]error, error) {
+	failed := map[uuid.UUID]error{}
+	ids, err := authorizeBulk(db, userID, req, failed)
+	if err != nil || len(ids) == 0 {
+		return failed, err
+	}
+
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	fr := repositories.NewFilesRepo(db).WithTx(t)
+	var changed []uuid.UUID
+	switch req.Operation {
+	case BulkDelete:
+		changed, err = fr.TrashByIDs(ids)
+	case BulkRestore:
+		changed, err = fr.RestoreByIDs(ids)
+	case BulkMove:
+		var folderID *uuid.UUID
+		if req.Folder != nil {
+			folderID = &req.Folder.ID
+		}
+		changed, err = fr.MoveByIDs(ids, folderID)
+	case BulkTag:
+		tr := repositories.NewTagsRepo(db).WithTx(t)
+		var tagIDs []uuid.UUID
+		tagIDs, err = tr.EnsureByNames(userID, req.Tags)
+		if err == nil {
+			changed, err = tr.TagFiles(ids, tagIDs)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Commit(context.Background()); err != nil {
+		return nil, err
+	}
+	// The files left out by the statement were trashed, restored or deleted by another request since they were
+	// authorized.
+	done := make(map[uuid.UUID]bool, len(changed))
+	for _, id := range changed {
+		done[id] = true
+	}
+	for _, id := range ids {
+		if !done[id] {
+			failed[id] = pgx.ErrNoRows
+		}
+	}
+	return failed, nil
+}
+
+// authorizeBulk checks every file of req, recording the error of the ones that can't be changed in failed.
+//
+// Returns:
+//   - The ids of the files that can be changed, in the order of req.Files.
+func authorizeBulk(db *pgxpool.Pool, userID uuid.UUID, req BulkRequest, failed map[uuid.UUID]error) ([]uuid.UUID, error) {
+	repo := repositories.NewFilesRepo(db)
+	var files []repositories.File
+	var err error
+	if req.Operation == BulkRestore {
+		files, err = repo.GetTrashedByIDs(req.Files)
+	} else {
+		files, err = repo.GetByIDs(req.Files)
+	}
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*repositories.File, len(files))
+	for i := range files {
+		byID[files[i].ID] = &files[i]
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.Files))
+	for _, id := range req.Files {
+		file, ok := byID[id]
+		if !ok {
+			failed[id] = pgx.ErrNoRows
+			continue
+		}
+		if err := checkBulkFile(db, userID, req, file); err != nil {
+			failed[id] = err
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// checkBulkFile checks that userID can apply the operation of req to file, see RunBulk.
+func checkBulkFile(db *pgxpool.Pool, userID uuid.UUID, req BulkRequest, file *repositories.File) error {
+	switch req.Operation {
+	case BulkRestore, BulkTag:
+		// Only owners manage their trash and their tags.
+		if file.OwnerID != userID {
+			return ErrFileNotOwned
+		}
+		return nil
+	case BulkMove:
+		if err := shareServices.CheckFile(db, userID, file, shareServices.AccessWrite); err != nil {
+			return err
+		}
+		return CheckDestination(userID, file, req.Folder)
+	default:
+		return shareServices.CheckFile(db, userID, file, shareServices.AccessWrite)
+	}
+}
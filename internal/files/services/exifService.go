@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoExif is returned by ReadExif for pictures without EXIF data.
+var ErrNoExif = errors.New("no EXIF data")
+
+// ExifData is the part of the EXIF tags of a picture kept in its metadata.
+type ExifData struct {
+	Make        string
+	Model       string
+	Orientation int        // 1 to 8, 0 when unknown.
+	TakenAt     *time.Time // In UTC when the offset wasn't recorded.
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// EXIF tags read by ReadExif.
+const (
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// ReadExif reads the camera, orientation, capture date and location of a JPEG, TIFF (which most raw formats are),
+// PNG or WebP picture.
+//
+// Returns:
+//   - ErrNoExif if the picture has no EXIF data, or is in another format.
+func ReadExif(path string) (*ExifData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	base, err := findExif(f)
+	if err != nil {
+		return nil, err
+	}
+	return parseTiff(f, base)
+}
+
+// findExif locates the TIFF structure holding the EXIF data of a picture.
+//
+// Returns:
+//   - Its offset in the file.
+func findExif(f io.ReadSeeker) (int64, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return 0, ErrNoExif
+	}
+	switch {
+	case header[0] == 0xFF && header[1] == 0xD8:
+		return findJpegExif(f)
+	case bytes.HasPrefix(header, []byte("II*\x00")) || bytes.HasPrefix(header, []byte("MM\x00*")):
+		return 0, nil
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return findChunk(f, 8, binary.BigEndian, true, "eXIf", "IEND")
+	case bytes.HasPrefix(header, []byte("RIFF")) && string(header[8:12]) == "WEBP":
+		return findChunk(f, 12, binary.LittleEndian, false, "EXIF", "")
+	}
+	return 0, ErrNoExif
+}
+
+// findJpegExif walks the segments of a JPEG picture up to its image data, looking for the APP1 segment holding EXIF data.
+func findJpegExif(f io.ReadSeeker) (int64, error) {
+	offset := int64(2)
+	marker := make([]byte, 4)
+	for {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(f, marker); err != nil || marker[0] != 0xFF {
+			return 0, ErrNoExif
+		}
+		length := int64(binary.BigEndian.Uint16(marker[2:]))
+		switch marker[1] {
+		case 0xDA, 0xD9: // Start of scan, end of image.
+			return 0, ErrNoExif
+		case 0xE1:
+			id := make([]byte, 6)
+			if _, err := io.ReadFull(f, id); err == nil && string(id) == "Exif\x00\x00" {
+				return offset + 10, nil
+			}
+		}
+		offset += 2 + length
+	}
+}
+
+// findChunk walks the chunks of a PNG (length then type, followed by a CRC) or RIFF (type then length, padded to
+// an even size) file from offset, looking for the chunk of type name. The walk stops at the chunk of type last.
+func findChunk(f io.ReadSeeker, offset int64, order binary.ByteOrder, png bool, name, last string) (int64, error) {
+	header := make([]byte, 8)
+	for {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(f, header); err != nil {
+			return 0, ErrNoExif
+		}
+		kind, length := string(header[4:]), int64(order.Uint32(header[:4]))
+		if !png {
+			kind, length = string(header[:4]), int64(order.Uint32(header[4:]))
+		}
+		data := offset + 8
+		switch kind {
+		case name:
+			// Some writers keep the JPEG identifier in front of the TIFF structure.
+			id := make([]byte, 6)
+			if _, err := io.ReadFull(f, id); err == nil && string(id) == "Exif\x00\x00" {
+				return data + 6, nil
+			}
+			return data, nil
+		case last:
+			return 0, ErrNoExif
+		}
+		if png {
+			offset = data + length + 4
+		} else {
+			offset = data + length + length%2
+		}
+	}
+}
+
+// tiffReader reads the IFDs of a TIFF structure starting at base in r.
+type tiffReader struct {
+	r     io.ReaderAt
+	base  int64
+	order binary.ByteOrder
+}
+
+// ifdEntry is an entry of an IFD, whose value is inline when it fits in 4 bytes or at the offset they hold.
+type ifdEntry struct {
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// tiffTypeSizes maps the TIFF field types read by tiffReader to the size of one of their values.
+var tiffTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// read reads n bytes at offset from base, refusing sizes no tag read here comes near.
+func (t *tiffReader) read(offset int64, n int) ([]byte, error) {
+	if n < 0 || n > 1<<16 {
+		return nil, fmt.Errorf("EXIF value of %d bytes", n)
+	}
+	buf := make([]byte, n)
+	if _, err := t.r.ReadAt(buf, t.base+offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ifd reads the entries of the IFD at offset.
+func (t *tiffReader) ifd(offset uint32) (map[uint16]ifdEntry, error) {
+	raw, err := t.read(int64(offset), 2)
+	if err != nil {
+		return nil, err
+	}
+	count := int(t.order.Uint16(raw))
+	raw, err = t.read(int64(offset)+2, count*12)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[uint16]ifdEntry, count)
+	for i := range count {
+		e := raw[i*12 : (i+1)*12]
+		entries[t.order.Uint16(e)] = ifdEntry{kind: t.order.Uint16(e[2:]), count: t.order.Uint32(e[4:]), value: e[8:]}
+	}
+	return entries, nil
+}
+
+// data returns the raw values of an entry.
+func (t *tiffReader) data(e ifdEntry) ([]byte, error) {
+	size, ok := tiffTypeSizes[e.kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown EXIF type %d", e.kind)
+	}
+	n := size * int(min(e.count, 1<<16))
+	if n <= 4 {
+		return e.value[:n], nil
+	}
+	return t.read(int64(t.order.Uint32(e.value)), n)
+}
+
+// string returns the value of an ASCII entry, without its padding.
+func (t *tiffReader) string(e ifdEntry) string {
+	raw, err := t.data(e)
+	if err != nil || e.kind != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+}
+
+// uint returns the first value of a SHORT or LONG entry.
+func (t *tiffReader) uint(e ifdEntry) (uint32, bool) {
+	switch e.kind {
+	case 3:
+		return uint32(t.order.Uint16(e.value)), true
+	case 4:
+		return t.order.Uint32(e.value), true
+	}
+	return 0, false
+}
+
+// rationals returns the values of a RATIONAL entry.
+func (t *tiffReader) rationals(e ifdEntry) ([]float64, error) {
+	if e.kind != 5 {
+		return nil, fmt.Errorf("EXIF type %d is not a rational", e.kind)
+	}
+	raw, err := t.data(e)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, 0, len(raw)/8)
+	for i := 0; i+8 <= len(raw); i += 8 {
+		num, den := t.order.Uint32(raw[i:]), t.order.Uint32(raw[i+4:])
+		if den == 0 {
+			return nil, fmt.Errorf("EXIF rational with a zero denominator")
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values, nil
+}
+
+// parseTiff reads the tags kept in ExifData from the TIFF structure at base in r.
+func parseTiff(r io.ReaderAt, base int64) (*ExifData, error) {
+	t := &tiffReader{r: r, base: base}
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, base); err != nil {
+		return nil, ErrNoExif
+	}
+	switch string(header[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+	ifd0, err := t.ifd(t.order.Uint32(header[4:]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoExif, err)
+	}
+
+	data := &ExifData{
+		Make:  t.string(ifd0[tagMake]),
+		Model: t.string(ifd0[tagModel]),
+	}
+	if o, ok := t.uint(ifd0[tagOrientation]); ok && o >= 1 && o <= 8 {
+		data.Orientation = int(o)
+	}
+	taken, offset := t.string(ifd0[tagDateTime]), ""
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if off, ok := t.uint(e); ok {
+			if exif, err := t.ifd(off); err == nil {
+				if original := t.string(exif[tagDateTimeOriginal]); original != "" {
+					taken, offset = original, t.string(exif[tagOffsetTimeOriginal])
+				}
+			}
+		}
+	}
+	data.TakenAt = parseExifTime(taken, offset)
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if off, ok := t.uint(e); ok {
+			if gps, err := t.ifd(off); err == nil {
+				data.Latitude = t.coordinate(gps[tagGPSLatitude], t.string(gps[tagGPSLatitudeRef]), "S")
+				data.Longitude = t.coordinate(gps[tagGPSLongitude], t.string(gps[tagGPSLongitudeRef]), "W")
+			}
+		}
+	}
+	return data, nil
+}
+
+// coordinate converts a GPS position given in degrees, minutes and seconds to decimal degrees, negative when
+// ref is negativeRef. Returns nil when the entry isn't a valid position.
+func (t *tiffReader) coordinate(e ifdEntry, ref, negativeRef string) *float64 {
+	dms, err := t.rationals(e)
+	if err != nil || len(dms) != 3 {
+		return nil
+	}
+	degrees := dms[0] + dms[1]/60 + dms[2]/3600
+	if ref == negativeRef {
+		degrees = -degrees
+	}
+	if math.IsNaN(degrees) || math.Abs(degrees) > 180 {
+		return nil
+	}
+	return &degrees
+}
+
+// parseExifTime parses an EXIF date (`2006:01:02 15:04:05`), with its offset (`+02:00`) when it was recorded.
+func parseExifTime(value, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	layout := "2006:01:02 15:04:05"
+	if offset != "" {
+		value, layout = value+offset, layout+"-07:00"
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil || t.Year() < 1900 {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// orientationFilter returns the ffmpeg filters turning a picture stored with an EXIF orientation upright,
+// or an empty string when it already is.
+func orientationFilter(orientation int) string {
+	switch orientation {
+	case 2:
+		return "hflip"
+	case 3:
+		return "hflip,vflip"
+	case 4:
+		return "vflip"
+	case 5:
+		return "transpose=0"
+	case 6:
+		return "transpose=1"
+	case 7:
+		return "transpose=3"
+	case 8:
+		return "transpose=2"
+	}
+	return ""
+}
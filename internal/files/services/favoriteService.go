@@ -0,0 +1,29 @@
+package services
+
+import (
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AddFavorites stars files of userID. Files already starred stay so.
+//
+// Returns:
+//   - The errors of CheckOwnedFiles if a file can't be starred.
+func AddFavorites(db *pgxpool.Pool, userID uuid.UUID, fileIDs []uuid.UUID) error {
+	if err := CheckOwnedFiles(db, userID, fileIDs); err != nil {
+		return err
+	}
+	return repositories.NewFavoritesRepo(db).Add(userID, fileIDs)
+}
+
+// RemoveFavorites unstars files of userID.
+//
+// Returns:
+//   - The errors of CheckOwnedFiles if a file can't be unstarred.
+func RemoveFavorites(db *pgxpool.Pool, userID uuid.UUID, fileIDs []uuid.UUID) error {
+	if err := CheckOwnedFiles(db, userID, fileIDs); err != nil {
+		return err
+	}
+	return repositories.NewFavoritesRepo(db).Remove(userID, fileIDs)
+}
@@ -2,27 +2,44 @@ package services
 
 import (
 	"mime/multipart"
-	"strings"
 	"time"
 
 	"github.com/David/Boxed/repositories"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// FileInfo describes an uploaded file independently of how it reached the server (multipart form or resumable upload).
+type FileInfo struct {
+	Filename string // Name sent by the client, extension included.
+	MimeType string
+	Size     int64
+	Hash     string // Hex encoded SHA-256 of the content, once it has been stored.
+}
+
+// InfoFromHeader builds the FileInfo of a multipart file.
+func InfoFromHeader(file *multipart.FileHeader) FileInfo {
+	return FileInfo{
+		Filename: file.Filename,
+		MimeType: file.Header.Get("Content-Type"),
+		Size:     file.Size,
+	}
+}
+
 // saveFileToDb will save the metadata into the database and return the corresponding id.
-func SaveFileToDatabase(c *pgxpool.Pool, file *multipart.FileHeader, fid, uid uuid.UUID, fpath string, thumbnail uuid.UUID) error {
-	mime := file.Header.Get("Content-Type")
-	originalName := strings.Split(file.Filename, ".")[0]
-	fr := repositories.NewFilesRepo(c)
+// The file is named after file.Filename, extension included. A nil folder stores the file in the owner's root. The file's content is expected to be the blob with file.Hash.
+// fr can be bound to the transaction creating the file's thumbnail.
+func SaveFileToDatabase(fr *repositories.FilesRepo, file FileInfo, fid, uid uuid.UUID, folder *uuid.UUID, fpath string, thumbnail uuid.UUID) error {
 	return fr.Create(&repositories.File{
 		ID:           fid,
 		OwnerID:      uid,
-		OriginalName: originalName,
+		OriginalName: file.Filename,
 		StoragePath:  fpath,
 		Size:         file.Size,
-		MimeType:     mime,
+		MimeType:     file.MimeType,
 		ThumbnailId:  thumbnail,
+		FolderID:     folder,
+		ContentHash:  file.Hash,
+		BlobHash:     &file.Hash,
 		CreatedAt:    time.Now(),
 	})
 }
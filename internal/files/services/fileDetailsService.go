@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxFileName is the maximum length of a file name, extension included, in characters.
+	maxFileName = 255
+	// MaxDescription is the maximum length of a file description, in characters.
+	MaxDescription = 4000
+)
+
+// compoundExtensions are the extensions made of several suffixes, kept whole when renaming a file.
+var compoundExtensions = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.lz", ".tar.lzma", ".tar.z"}
+
+var (
+	ErrInvalidFileName    = errors.New("file name is not valid")
+	ErrInvalidDescription = errors.New("file description is too long")
+	ErrRevisionMismatch   = errors.New("the file changed since this revision")
+	ErrInvalidDestination = errors.New("destination folder uuid is not valid")
+	ErrForeignDestination = errors.New("files can only be moved between folders of their owner")
+)
+
+// ValidateFileName checks that name can be used as a file name: not blank, `.` or `..`, without slashes nor
+// line breaks, and at most 255 characters.
+func ValidateFileName(name string) error {
+	if strings.TrimSpace(name) == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\r\n") ||
+		utf8.RuneCountInString(name) > maxFileName {
+		return ErrInvalidFileName
+	}
+	return nil
+}
+
+// ValidateDescription checks that description is at most MaxDescription characters.
+func ValidateDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxDescription {
+		return ErrInvalidDescription
+	}
+	return nil
+}
+
+// FullExtension returns the extension of a file name with its leading dot, made of several suffixes for
+// compressed archives (`.tar.gz`). Names without extension, or only made of one such as `.bashrc`, have none.
+func FullExtension(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range compoundExtensions {
+		if strings.HasSuffix(lower, ext) && len(name) > len(ext) {
+			return name[len(name)-len(ext):]
+		}
+	}
+	ext := path.Ext(name)
+	if ext == "." || len(ext) == len(name) {
+		return ""
+	}
+	return ext
+}
+
+// KeepExtension gives name the full extension of current, unless it already ends with it, ignoring case.
+// Renaming `report.tar.gz` to `backup` gives `backup.tar.gz`, as does renaming it to `backup.tar.gz`.
+func KeepExtension(name, current string) string {
+	ext := FullExtension(current)
+	if ext == "" || strings.HasSuffix(strings.ToLower(name), strings.ToLower(ext)) {
+		return name
+	}
+	return name + ext
+}
+
+// MoveDestination resolves the folder userID moves file to, given as an optional folder uuid, an empty string
+// meaning the root. Files stay with their owner: the folder must belong to the file's owner, and userID must be
+// able to write to it.
+//
+// Returns:
+//   - The folder, nil for the owner's root.
+//   - The errors of ResolveDestination and CheckDestination.
+func MoveDestination(db *pgxpool.Pool, userID uuid.UUID, file *repositories.File, raw string) (*uuid.UUID, error) {
+	folder, err := ResolveDestination(db, userID, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckDestination(userID, file, folder); err != nil {
+		return nil, err
+	}
+	if folder == nil {
+		return nil, nil
+	}
+	return &folder.ID, nil
+}
+
+// ResolveDestination resolves the folder userID moves files to, given as an optional folder uuid. An empty string
+// means the root and resolves to a nil folder.
+//
+// Returns:
+//   - ErrInvalidDestination if raw is not a uuid.
+//   - pgx.ErrNoRows if the folder doesn't exist.
+//   - shareServices.ErrForbidden if userID can't write to the folder.
+func ResolveDestination(db *pgxpool.Pool, userID uuid.UUID, raw string) (*repositories.Folder, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, ErrInvalidDestination
+	}
+	return shareServices.AuthorizeFolder(db, userID, id, shareServices.AccessWrite)
+}
+
+// CheckDestination checks that file can be moved by userID to folder, nil for userID's root. Files stay with their
+// owner, so the folder must belong to the file's owner.
+// Returns ErrForeignDestination otherwise.
+func CheckDestination(userID uuid.UUID, file *repositories.File, folder *repositories.Folder) error {
+	if (folder == nil && file.OwnerID != userID) || (folder != nil && folder.OwnerID != file.OwnerID) {
+		return ErrForeignDestination
+	}
+	return nil
+}
+
+// UpdateFileDetails stores the name, folder and description set on file, provided nobody changed the file since
+// revision. file.Revision is then set to the new revision.
+//
+// Returns:
+//   - ErrRevisionMismatch if the file changed since revision.
+//   - pgx.ErrNoRows if the file doesn't exist anymore or is in the trash.
+func UpdateFileDetails(db *pgxpool.Pool, file *repositories.File, revision int) error {
+	if file.Revision != revision {
+		return ErrRevisionMismatch
+	}
+	err := repositories.NewFilesRepo(db).UpdateDetails(file)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The file was changed, trashed or deleted since it was read, tell which.
+		if _, getErr := repositories.NewFilesRepo(db).GetByID(file.ID); getErr == nil {
+			return ErrRevisionMismatch
+		}
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/David/Boxed/repositories"
+)
+
+func TestRenameThenZip(t *testing.T) {
+	tests := []struct {
+		current  string
+		name     string
+		mimeType string
+		stored   string
+		entry    string
+	}{
+		{"notes.txt", "ideas", "text/plain", "ideas.txt", "ideas.txt"},
+		{"notes.txt", "ideas.TXT", "text/plain", "ideas.TXT", "ideas.TXT"},
+		{"backup.tar.gz", "2026", "application/gzip", "2026.tar.gz", "2026.tar.gz"},
+		{"backup.tar.gz", "2026.tar.gz", "application/gzip", "2026.tar.gz", "2026.tar.gz"},
+		{"report.v2", "report", "application/pdf", "report.v2", "report.v2"},
+		{".bashrc", "profile", "text/plain", "profile", "profile.txt"},
+		{"notes", "ideas", "text/plain", "ideas", "ideas.txt"},
+	}
+	for _, tt := range tests {
+		stored := KeepExtension(tt.name, tt.current)
+		if stored != tt.stored {
+			t.Errorf("KeepExtension(%q, %q) = %q, want %q", tt.name, tt.current, stored, tt.stored)
+		}
+		entries := ZipEntriesForFiles([]repositories.File{{OriginalName: stored, MimeType: tt.mimeType}})
+		if entries[0].Name != tt.entry {
+			t.Errorf("renaming %q to %q zips as %q, want %q", tt.current, tt.name, entries[0].Name, tt.entry)
+		}
+	}
+}
+
+func TestZipDuplicateNames(t *testing.T) {
+	files := []repositories.File{
+		{OriginalName: "backup.tar.gz", MimeType: "application/gzip"},
+		{OriginalName: "BACKUP.tar.gz", MimeType: "application/gzip"},
+		{OriginalName: "notes", MimeType: "text/plain"},
+		{OriginalName: "notes.txt", MimeType: "text/plain"},
+	}
+	want := []string{"backup.tar.gz", "BACKUP (1).tar.gz", "notes.txt", "notes (1).txt"}
+	for i, e := range ZipEntriesForFiles(files) {
+		if e.Name != want[i] {
+			t.Errorf("entry %d is named %q, want %q", i, e.Name, want[i])
+		}
+	}
+}
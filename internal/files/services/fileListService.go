@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultListLimit is the number of files in a page of a listing when the client doesn't choose.
+	DefaultListLimit = 100
+	// MaxListLimit is the largest page of a listing a client can ask for.
+	MaxListLimit = 1000
+)
+
+// listCursor is the content of the cursor of a listing page. It remembers the order it was made for, as its
+// position means nothing in another one.
+type listCursor struct {
+	Sort       repositories.FileSort `json:"s"`
+	Descending bool                  `json:"d"`
+	repositories.ListKey
+}
+
+// FilePage is a page of a file listing.
+type FilePage struct {
+	Files []repositories.File
+	// NextCursor reads the next page, empty on the last one.
+	NextCursor string
+}
+
+// ListFiles reads a page of the files of a user, filtered and sorted by opts. opts.After is set from cursor,
+// empty for the first page.
+//
+// Returns:
+//   - ErrInvalidCursor if cursor wasn't returned for the same sort and order, or holds a value of the wrong type.
+func ListFiles(db *pgxpool.Pool, ownerID uuid.UUID, opts repositories.ListOptions, cursor string) (*FilePage, error) {
+	if cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		var c listCursor
+		if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil ||
+			c.Sort != opts.Sort || c.Descending != opts.Descending || !repositories.ValidListValue(c.Sort, c.Value) {
+			return nil, ErrInvalidCursor
+		}
+		opts.After = &c.ListKey
+	}
+	limit := opts.Limit
+	// One more file tells whether there is a next page.
+	opts.Limit++
+	listed, err := repositories.NewFilesRepo(db).GetByOwnerIDWithOptions(ownerID, opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &FilePage{Files: make([]repositories.File, 0, min(len(listed), limit))}
+	if len(listed) > limit {
+		listed = listed[:limit]
+		raw, err := json.Marshal(listCursor{Sort: opts.Sort, Descending: opts.Descending, ListKey: listed[limit-1].Key})
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	for _, f := range listed {
+		page.Files = append(page.Files, f.File)
+	}
+	return page, nil
+}
@@ -1,54 +1,107 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"log"
 	"mime/multipart"
 	"os"
-	"path/filepath"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// saveFile saves to the file system.
+// saveFile saves to the storage backend, hashing the content with SHA-256 while it's received.
+// The content is stored once per hash: uploading a file that is already stored only adds a reference to its blob.
 //
 // Parameters:
-//   - fpath: The path where the file's going to be saved.
+//   - db: The database connection pool.
 //   - file: a pointer to the FileHeader, you could find this in a http request.
 //
 // Returns:
-//   - An error if any os-related operation went wrong.
-func SaveFile(fpath string, file *multipart.FileHeader) error {
-	// Try to create the directory before the files is created.
-
-	err := os.MkdirAll(filepath.Dir(fpath), 0755)
-	if err != nil {
-		return err
-	}
+//   - The blob holding the content; its hash is the hex encoded SHA-256 of the content.
+//   - An error if any storage-related operation went wrong.
+func SaveFile(db *pgxpool.Pool, file *multipart.FileHeader) (*repositories.Blob, error) {
 	src, err := file.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer src.Close()
-	dst, err := os.Create(fpath)
+	dir, err := TempDir()
+	if err != nil {
+		return nil, err
+	}
+	dst, err := os.CreateTemp(dir, "upload-*")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if _, err := io.Copy(dst, src); err != nil {
-		return err
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(dst, hash), src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
 	}
-	return nil
+	if err != nil {
+		os.Remove(dst.Name())
+		return nil, err
+	}
+	blob, err := StoreBlob(db, dst.Name(), hex.EncodeToString(hash.Sum(nil)), size)
+	if err != nil {
+		os.Remove(dst.Name())
+		return nil, err
+	}
+	return blob, nil
 }
 
-// deleteFile removes a file or directory from the file system.
+// HashFile returns the hex encoded SHA-256 of a file on the local disk.
+func HashFile(fpath string) (string, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HashObject returns the hex encoded SHA-256 of an object in the storage backend.
+func HashObject(key string) (string, error) {
+	r, err := boxed.GetInstance().Storage.Get(context.Background(), key, 0, -1)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// deleteFile removes a file from the storage backend.
 //
 // Parameters:
-//   - fpath: The path to the file or directory to be deleted.
+//   - key: The storage key of the file to be deleted.
 //
 // Logs:
 //   - Logs an error message if the file cannot be deleted successfully.
-func DeleteFile(fpath string) {
-	err := os.RemoveAll(fpath)
+func DeleteFile(key string) {
+	err := boxed.GetInstance().Storage.Delete(context.Background(), key)
 	if err != nil {
-		log.Println("Fatal error: coudln't remove the following file:", fpath)
+		log.Println("Fatal error: coudln't remove the following file:", key, err)
 		// maybe re-add the entry that was deleted in the database.
 	}
 }
+
+// deletePrefix removes every stored object below prefix, such as the playlists and segments of a stream.
+func deletePrefix(prefix string) {
+	if err := storage.DeletePrefix(context.Background(), boxed.GetInstance().Storage, prefix+"/"); err != nil {
+		log.Printf("Couldn't remove the objects stored under %v: %v", prefix, err)
+	}
+}
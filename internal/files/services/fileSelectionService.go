@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v5"
+)
+
+// MaxRequestFiles is the maximum number of files that can be given in a single request acting on several files.
+const MaxRequestFiles = 1000
+
+var (
+	ErrInvalidFileIDs = errors.New("file uuids are not valid")
+	ErrTooManyFiles   = errors.New("too many files in a single request")
+	ErrFileNotOwned   = errors.New("file is not owned by this user")
+)
+
+// ParseFileIDs parses the file uuids of a request, dropping duplicates but keeping the order.
+//
+// Returns:
+//   - ErrInvalidFileIDs if one of them is not a uuid.
+//   - ErrTooManyFiles if there are more than MaxRequestFiles.
+func ParseFileIDs(raw []string) ([]uuid.UUID, error) {
+	if len(raw) > MaxRequestFiles {
+		return nil, ErrTooManyFiles
+	}
+	ids := make([]uuid.UUID, 0, len(raw))
+	seen := map[uuid.UUID]bool{}
+	for _, r := range raw {
+		id, err := uuid.Parse(r)
+		if err != nil {
+			return nil, ErrInvalidFileIDs
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// FileSelectionResponse translates the errors of ParseFileIDs and CheckOwnedFiles, other than pgx.ErrNoRows, into
+// the status and error answered for them.
+// Returns a nil error for any other error.
+func FileSelectionResponse(err error) (int, *types.ErrorResponse) {
+	switch {
+	case errors.Is(err, ErrInvalidFileIDs):
+		return http.StatusBadRequest, &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`files` must only hold valid file uuids.",
+		}
+	case errors.Is(err, ErrTooManyFiles):
+		return http.StatusBadRequest, &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("At most %d `files` can be given at once.", MaxRequestFiles),
+		}
+	case errors.Is(err, ErrFileNotOwned):
+		return http.StatusForbidden, &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "Only the user's own files can be given in `files`.",
+		}
+	}
+	return 0, nil
+}
+
+// FileSelectionError responds to the errors of ParseFileIDs and CheckOwnedFiles, see FileSelectionResponse.
+// When it returns false nothing has been written and the caller must respond to err itself.
+func FileSelectionError(c *echo.Context, err error) (bool, error) {
+	status, e := FileSelectionResponse(err)
+	if e == nil {
+		return false, nil
+	}
+	return true, c.JSON(status, &e)
+}
+
+// CheckOwnedFiles verifies that every file exists, is out of the trash and belongs to ownerID.
+//
+// Returns:
+//   - pgx.ErrNoRows if a file doesn't exist or is in the trash.
+//   - ErrFileNotOwned if a file belongs to somebody else.
+func CheckOwnedFiles(db *pgxpool.Pool, ownerID uuid.UUID, ids []uuid.UUID) error {
+	files, err := repositories.NewFilesRepo(db).GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	if len(files) != len(ids) {
+		return pgx.ErrNoRows
+	}
+	for _, f := range files {
+		if f.OwnerID != ownerID {
+			return ErrFileNotOwned
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	jobServices "github.com/David/Boxed/internal/jobs/services"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MediaJobKind is the kind of the jobs probing the metadata of contents.
+const MediaJobKind = "media"
+
+const (
+	// waveformPeaks is the number of peaks of a waveform, whatever the duration of the audio.
+	waveformPeaks = 1000
+	// waveformSampleRate is the rate the audio is decoded at to find the peaks, in Hz.
+	waveformSampleRate = 8000
+)
+
+// mediaJob is the payload of a media job.
+type mediaJob struct {
+	Thumbnail uuid.UUID `json:"thumbnail"`
+}
+
+// MediaJobHandler probes the contents queued with queueMedia.
+var MediaJobHandler = jobServices.Handler{
+	Run:  runMediaJob,
+	Dead: mediaJobDead,
+}
+
+// queueMedia queues the probing of an image, video or audio content. Other contents are left alone.
+func queueMedia(jr *repositories.JobsRepo, thumbnailID uuid.UUID, mimeType string) error {
+	top, _, _ := strings.Cut(mimeType, "/")
+	if top != "image" && top != "video" && top != "audio" {
+		return nil
+	}
+	return jobServices.Enqueue(jr, MediaJobKind, mediaJob{Thumbnail: thumbnailID})
+}
+
+// runMediaJob stores the metadata of a content: what ffprobe finds out, the EXIF tags of pictures, and the
+// waveform of audio.
+// Contents that have been deleted are skipped.
+func runMediaJob(ctx context.Context, db *pgxpool.Pool, job *repositories.Job) error {
+	var payload mediaJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobServices.Permanent(err)
+	}
+	fr := repositories.NewFilesRepo(db)
+	src, err := fr.GetThumbnailSource(payload.Thumbnail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	inPath, cleanup, err := storage.Fetch(ctx, boxed.GetInstance().Storage, src.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	metadata, err := ProbeMedia(ctx, inPath)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(src.MimeType, "image/") {
+		// ffprobe sees pictures as one frame videos.
+		metadata.Duration, metadata.Bitrate = 0, 0
+		exif, err := ReadExif(inPath)
+		if err != nil && !errors.Is(err, ErrNoExif) {
+			log.Printf("Couldn't read the EXIF data of thumbnail %v's content: %v", payload.Thumbnail, err)
+		}
+		if err == nil {
+			mergeExif(metadata, exif)
+		}
+	}
+	if strings.HasPrefix(src.MimeType, "audio/") {
+		peaks, err := GenerateWaveform(ctx, inPath, metadata.Duration)
+		if err != nil {
+			return err
+		}
+		err = repositories.NewWaveformsRepo(db).Save(&repositories.Waveform{
+			ThumbnailID: payload.Thumbnail,
+			Duration:    metadata.Duration,
+			Peaks:       peaks,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return fr.SetMetadataByThumbnailID(payload.Thumbnail, metadata)
+}
+
+// mediaJobDead records that nothing could be found out about a content, so it isn't waited for.
+func mediaJobDead(db *pgxpool.Pool, job *repositories.Job, _ error) {
+	var payload mediaJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return
+	}
+	err := repositories.NewFilesRepo(db).SetMetadataByThumbnailID(payload.Thumbnail, &repositories.MediaMetadata{})
+	if err != nil {
+		log.Printf("Couldn't mark the content of thumbnail %v as probed: %v", payload.Thumbnail, err)
+	}
+}
+
+// mergeExif adds the EXIF tags of a picture to its metadata. Pictures turned sideways are displayed with their
+// width and height swapped.
+func mergeExif(metadata *repositories.MediaMetadata, exif *ExifData) {
+	metadata.CameraMake = exif.Make
+	metadata.CameraModel = exif.Model
+	metadata.TakenAt = exif.TakenAt
+	metadata.Orientation = exif.Orientation
+	metadata.Latitude = exif.Latitude
+	metadata.Longitude = exif.Longitude
+	if exif.Orientation >= 5 {
+		metadata.Width, metadata.Height = metadata.Height, metadata.Width
+	}
+}
+
+// ffprobeOutput is the part of `ffprobe -show_format -show_streams -of json` read by ProbeMedia.
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		SampleRate  string `json:"sample_rate"`
+		Channels    int    `json:"channels"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+		SideDataList []struct {
+			Rotation int `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		Duration string            `json:"duration"`
+		BitRate  string            `json:"bit_rate"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// iso6709 matches the start of an ISO 6709 location (`+48.8583+002.2944/`), as recorded by cameras and phones.
+var iso6709 = regexp.MustCompile(`^([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)`)
+
+// ProbeMedia reads the duration, bitrate, codecs and dimensions of a media file, with the capture date,
+// location and camera that videos record in their tags. The codec is the one of its video, or of its audio
+// when it has no video other than a cover art.
+// Depends on ffprobe to work.
+func ProbeMedia(c context.Context, iPath string) (*repositories.MediaMetadata, error) {
+	cmd := exec.CommandContext(
+		c,
+		"ffprobe",
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-of", "json",
+		iPath,
+	)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, err
+	}
+	metadata := &repositories.MediaMetadata{}
+	metadata.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	metadata.Bitrate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	hasVideo := false
+	for _, s := range probe.Streams {
+		switch {
+		case s.CodecType == "video" && s.Disposition.AttachedPic == 0 && !hasVideo:
+			hasVideo = true
+			metadata.Codec = s.CodecName
+			metadata.Width, metadata.Height = s.Width, s.Height
+			for _, side := range s.SideDataList {
+				if side.Rotation%180 != 0 {
+					metadata.Width, metadata.Height = s.Height, s.Width
+				}
+			}
+		case s.CodecType == "audio" && metadata.SampleRate == 0:
+			metadata.SampleRate, _ = strconv.Atoi(s.SampleRate)
+			metadata.Channels = s.Channels
+			metadata.AudioCodec = s.CodecName
+		}
+	}
+	if !hasVideo {
+		// Audio files only have the one codec.
+		metadata.Codec, metadata.AudioCodec = metadata.AudioCodec, ""
+	}
+
+	tags := probe.Format.Tags
+	if t, err := time.Parse(time.RFC3339Nano, tags["creation_time"]); err == nil && t.Year() > 1970 {
+		t = t.UTC()
+		metadata.TakenAt = &t
+	}
+	location := tags["com.apple.quicktime.location.ISO6709"]
+	if location == "" {
+		location = tags["location"]
+	}
+	if m := iso6709.FindStringSubmatch(location); m != nil {
+		lat, latErr := strconv.ParseFloat(m[1], 64)
+		lon, lonErr := strconv.ParseFloat(m[2], 64)
+		if latErr == nil && lonErr == nil {
+			metadata.Latitude, metadata.Longitude = &lat, &lon
+		}
+	}
+	metadata.CameraMake = tags["com.apple.quicktime.make"]
+	metadata.CameraModel = tags["com.apple.quicktime.model"]
+	return metadata, nil
+}
+
+// GenerateWaveform decodes the audio of a file, mixed down to mono, and returns the highest level of each of
+// waveformPeaks even slices of its duration, from 0 to 100.
+// Depends on ffmpeg to work.
+func GenerateWaveform(c context.Context, input string, duration float64) ([]int, error) {
+	cmd := exec.CommandContext(
+		c,
+		"ffmpeg",
+		"-v", "error",
+		"-i", input,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"-",
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// Samples per peak, ten peaks a second when the duration is unknown.
+	perPeak := waveformSampleRate / 10
+	if duration > 0 {
+		perPeak = max(1, int(math.Ceil(duration*waveformSampleRate/waveformPeaks)))
+	}
+	peaks := make([]int, 0, waveformPeaks)
+	r := bufio.NewReader(stdout)
+	highest, count := 0, 0
+	for {
+		// Samples are signed 16 bits little endian.
+		lo, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		hi, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		level := int(int16(uint16(lo) | uint16(hi)<<8))
+		if level < 0 {
+			level = -level
+		}
+		highest = max(highest, level)
+		if count++; count == perPeak {
+			peaks = append(peaks, highest*100/32768)
+			highest, count = 0, 0
+		}
+	}
+	if count > 0 {
+		peaks = append(peaks, highest*100/32768)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %w\n%s", err, stderr.String())
+	}
+	return peaks, nil
+}
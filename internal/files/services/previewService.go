@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	jobServices "github.com/David/Boxed/internal/jobs/services"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PreviewJobKind is the kind of the jobs generating video previews.
+const PreviewJobKind = "preview"
+
+// Names of the objects of a preview, relative to its prefix.
+const (
+	PreviewSprite = "sprite.jpg"
+	PreviewTrack  = "thumbnails.vtt"
+)
+
+const (
+	// previewInterval is the time between two frames of the sprite sheet, in seconds.
+	previewInterval = 10
+	// previewMaxFrames bounds the size of the sprite sheet: the interval of longer videos is stretched to fit.
+	previewMaxFrames = 100
+	// previewColumns is the number of frames per row of the sprite sheet.
+	previewColumns = 10
+	// previewWidth is the width of a frame in pixels.
+	previewWidth = 160
+)
+
+// previewJob is the payload of a preview job.
+type previewJob struct {
+	Thumbnail uuid.UUID `json:"thumbnail"`
+}
+
+// PreviewJobHandler generates the previews queued with queuePreview. Long videos take more than a thumbnail to sample.
+var PreviewJobHandler = jobServices.Handler{
+	Run:     runPreviewJob,
+	Dead:    previewJobDead,
+	Timeout: 30 * time.Minute,
+}
+
+// PreviewPrefixFor returns the key prefix of the preview of a content, next to its thumbnail:
+// `<user>/thumbnail/<thumbnail>/preview`.
+func PreviewPrefixFor(ownerID, thumbnailID uuid.UUID) string {
+	return path.Join(ownerID.String(), "thumbnail", thumbnailID.String(), "preview")
+}
+
+// queuePreview registers a pending preview for a video content and queues its generation. Other contents are left alone.
+func queuePreview(pr *repositories.VideoPreviewsRepo, jr *repositories.JobsRepo, ownerID, thumbnailID uuid.UUID, mimeType string) error {
+	if !strings.HasPrefix(mimeType, "video/") {
+		return nil
+	}
+	if err := pr.Create(&repositories.VideoPreview{
+		ThumbnailID:   thumbnailID,
+		StoragePrefix: PreviewPrefixFor(ownerID, thumbnailID),
+	}); err != nil {
+		return err
+	}
+	return jobServices.Enqueue(jr, PreviewJobKind, previewJob{Thumbnail: thumbnailID})
+}
+
+// runPreviewJob generates the sprite sheet and the WebVTT track of a preview and stores them.
+// Previews that are already ready, or whose content has been deleted, are left as they are.
+func runPreviewJob(ctx context.Context, db *pgxpool.Pool, job *repositories.Job) error {
+	var payload previewJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobServices.Permanent(err)
+	}
+	pr := repositories.NewVideoPreviewsRepo(db)
+	preview, err := pr.GetByThumbnailID(payload.Thumbnail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if preview.Status == repositories.PreviewReady {
+		return nil
+	}
+	src, err := repositories.NewFilesRepo(db).GetThumbnailSource(preview.ThumbnailID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	backend := boxed.GetInstance().Storage
+	inPath, cleanup, err := storage.Fetch(ctx, backend, src.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	outDir, err := os.MkdirTemp("", "boxed-preview-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	interval, frames, err := GenerateVideoPreview(ctx, inPath, outDir)
+	if err != nil {
+		return err
+	}
+	size, err := putDir(ctx, backend, outDir, preview.StoragePrefix)
+	if err != nil {
+		return err
+	}
+	preview.Status = repositories.PreviewReady
+	preview.IntervalSeconds = interval
+	preview.Frames = frames
+	preview.Size = size
+	return pr.Update(preview)
+}
+
+// previewJobDead records that a preview couldn't be generated.
+func previewJobDead(db *pgxpool.Pool, job *repositories.Job, _ error) {
+	var payload previewJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return
+	}
+	pr := repositories.NewVideoPreviewsRepo(db)
+	preview, err := pr.GetByThumbnailID(payload.Thumbnail)
+	if err == nil {
+		preview.Status = repositories.PreviewFailed
+		err = pr.Update(preview)
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Couldn't mark preview %v as failed: %v", payload.Thumbnail, err)
+	}
+}
+
+// GenerateVideoPreview writes the scrubbing preview of a video in outDir: PreviewSprite, a grid of frames taken every
+// interval seconds, and PreviewTrack, the WebVTT track whose cues point at them with `#xywh=` fragments.
+// Only keyframes are decoded, so a frame is the closest keyframe to its time.
+// Depends on ffmpeg and ffprobe to work.
+//
+// Returns:
+//   - The interval in seconds and the number of frames.
+func GenerateVideoPreview(c context.Context, input, outDir string) (int, int, error) {
+	duration, err := GetVideoDuration(c, input)
+	if err != nil {
+		return 0, 0, err
+	}
+	width, height, err := GetVideoSize(c, input)
+	if err != nil {
+		// Retrying won't give the file a video stream.
+		return 0, 0, jobServices.Permanent(err)
+	}
+
+	interval := max(previewInterval, int(math.Ceil(duration/previewMaxFrames)))
+	frames := max(1, int(math.Ceil(duration/float64(interval))))
+	columns := min(frames, previewColumns)
+	rows := (frames + columns - 1) / columns
+	// Height of a frame, rounded to an even number like ffmpeg does.
+	frameHeight := (previewWidth*height/width + 1) &^ 1
+
+	cmd := exec.CommandContext(
+		c,
+		"ffmpeg",
+		"-y",
+		"-skip_frame", "nokey",
+		"-i", input,
+		"-vf", fmt.Sprintf("fps=1/%d,scale=%d:%d,tile=%dx%d", interval, previewWidth, frameHeight, columns, rows),
+		"-frames:v", "1",
+		"-q:v", "5",
+		filepath.Join(outDir, PreviewSprite),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, 0, fmt.Errorf("ffmpeg failed: %w\n%s", err, out)
+	}
+
+	track := previewTrack(duration, interval, frames, columns, previewWidth, frameHeight)
+	if err := os.WriteFile(filepath.Join(outDir, PreviewTrack), []byte(track), 0644); err != nil {
+		return 0, 0, err
+	}
+	return interval, frames, nil
+}
+
+// previewTrack builds the WebVTT track of a sprite sheet: one cue per frame, pointing at its tile of the sheet.
+func previewTrack(duration float64, interval, frames, columns, width, height int) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i := range frames {
+		start := float64(i * interval)
+		end := min(float64((i+1)*interval), duration)
+		if end <= start { // Unknown duration.
+			end = start + float64(interval)
+		}
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n", vttTimestamp(start), vttTimestamp(end), PreviewSprite,
+			(i%columns)*width, (i/columns)*height, width, height)
+	}
+	return b.String()
+}
+
+// vttTimestamp formats seconds as a WebVTT timestamp: `hh:mm:ss.mmm`.
+func vttTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// PreviewObjectKey resolves the name of an object of a ready preview, PreviewSprite or PreviewTrack.
+//
+// Returns:
+//   - The storage key of the object and its content type.
+//   - false if name isn't part of the preview.
+func PreviewObjectKey(preview *repositories.VideoPreview, name string) (string, string, bool) {
+	switch name {
+	case PreviewSprite:
+		return path.Join(preview.StoragePrefix, name), "image/jpeg", true
+	case PreviewTrack:
+		return path.Join(preview.StoragePrefix, name), "text/vtt; charset=utf-8", true
+	}
+	return "", "", false
+}
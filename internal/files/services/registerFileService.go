@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ThumbnailPathFor returns the storage key of a thumbnail: `<user>/thumbnail/<thumbnail>.jpg`.
+func ThumbnailPathFor(ownerID, thumbnailId uuid.UUID) string {
+	return path.Join(ownerID.String(), "thumbnail", fmt.Sprintf("%v.jpg", thumbnailId))
+}
+
+// RegisterStoredFile records a file whose content has already been stored in blob.
+// It creates the thumbnail entry, saves the file metadata and queues the generation of the thumbnail, as well as
+// the preview and, when HLS is enabled, the transcoding of videos, all in a single transaction: a file is never
+// saved without the jobs generating what it needs.
+// If any of it fails the reference to the blob is released.
+//
+// Returns:
+//   - userServices.ErrQuotaExceeded if the content doesn't fit in the owner's quota anymore.
+//
+// Every upload path (single, multiple and resumable uploads) goes through this function.
+func RegisterStoredFile(db *pgxpool.Pool, ownerID uuid.UUID, file FileInfo, fileId uuid.UUID, folder *uuid.UUID, blob *repositories.Blob) error {
+	file.Hash = blob.Hash
+	file.Size = blob.Size
+	if err := saveStoredFile(db, ownerID, file, fileId, folder, blob.StoragePath); err != nil {
+		releaseAfterFailure(db, blob)
+		return err
+	}
+	return nil
+}
+
+// saveStoredFile checks the quota of the owner, creates the thumbnail entry and the file row of a new file and
+// queues its generation in one transaction.
+func saveStoredFile(db *pgxpool.Pool, ownerID uuid.UUID, file FileInfo, fileId uuid.UUID, folder *uuid.UUID, filePath string) error {
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	if err := userServices.CheckQuotaLocked(db, t, ownerID, file.Size); err != nil {
+		return err
+	}
+	thumbnailUUID := uuid.New()
+	if err := repositories.NewThumbnailRepository(db).WithTx(t).Create(&repositories.Thumbnail{
+		ID:      thumbnailUUID,
+		OwnerId: ownerID,
+	}); err != nil {
+		return err
+	}
+	fr := repositories.NewFilesRepo(db).WithTx(t)
+	if err := SaveFileToDatabase(fr, file, fileId, ownerID, folder, filePath, thumbnailUUID); err != nil {
+		return err
+	}
+	if err := queueGeneration(db, t, ownerID, thumbnailUUID, file.MimeType); err != nil {
+		return err
+	}
+	return t.Commit(context.Background())
+}
+
+// queueGeneration queues what is generated in the background for a new content: its thumbnail, its metadata, the
+// waveform of audio, the text of documents, and the scrubbing preview and HLS stream of videos.
+// Everything is queued in t, along with the rows of the content, so the jobs only become runnable once it commits.
+func queueGeneration(db *pgxpool.Pool, t pgx.Tx, ownerID, thumbnailID uuid.UUID, mimeType string) error {
+	jr := repositories.NewJobsRepo(db).WithTx(t)
+	pr := repositories.NewVideoPreviewsRepo(db).WithTx(t)
+	sr := repositories.NewVideoStreamsRepo(db).WithTx(t)
+	if err := enqueueThumbnail(jr, thumbnailID); err != nil {
+		return err
+	}
+	if err := queueMedia(jr, thumbnailID, mimeType); err != nil {
+		return err
+	}
+	if err := queueText(jr, thumbnailID, mimeType); err != nil {
+		return err
+	}
+	if err := queuePreview(pr, jr, ownerID, thumbnailID, mimeType); err != nil {
+		return err
+	}
+	return queueStream(sr, jr, ownerID, thumbnailID, mimeType)
+}
+
+// releaseAfterFailure drops the reference taken on a blob for a file that couldn't be registered.
+func releaseAfterFailure(db *pgxpool.Pool, blob *repositories.Blob) {
+	if err := ReleaseBlob(db, blob.Hash); err != nil {
+		log.Printf("Couldn't release blob %v: %v", blob.Hash, err)
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultSearchLimit is the number of results of a search when the client doesn't choose.
+	DefaultSearchLimit = 20
+	// MaxSearchLimit is the largest page of results a client can ask for.
+	MaxSearchLimit = 100
+)
+
+// SearchHit is a file matching a search, with its highlights ready to be shown as HTML.
+type SearchHit struct {
+	File repositories.File `json:"file"`
+	Rank float32           `json:"rank"`
+	// Name is the file name with its matches in `<mark>` elements, the rest of it HTML escaped.
+	Name string `json:"name"`
+	// Snippet is the part of the text of the content matching the search, in the same format. Empty when only
+	// the name matched.
+	Snippet string `json:"snippet"`
+}
+
+// SearchFiles searches the files of a user by name and by the text of their content, see FilesRepo.Search.
+func SearchFiles(db *pgxpool.Pool, ownerID uuid.UUID, opts repositories.SearchOptions) ([]SearchHit, error) {
+	results, err := repositories.NewFilesRepo(db).Search(ownerID, opts)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]SearchHit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, SearchHit{
+			File:    r.File,
+			Rank:    r.Rank,
+			Name:    highlightHTML(restoreName(r.NameHighlight, r.OriginalName)),
+			Snippet: highlightHTML(r.Snippet),
+		})
+	}
+	return hits, nil
+}
+
+// restoreName puts back the dots, dashes and underscores of a file name in its highlight, which was made with
+// them turned into spaces. Falls back to the plain name if the highlight doesn't line up with it.
+func restoreName(highlight, name string) string {
+	var out strings.Builder
+	rest := name
+	for _, r := range highlight {
+		if string(r) == repositories.HighlightStart || string(r) == repositories.HighlightStop {
+			out.WriteRune(r)
+			continue
+		}
+		original, size := utf8.DecodeRuneInString(rest)
+		if size == 0 || (original != r && !(r == ' ' && strings.ContainsRune("._-", original))) {
+			return name
+		}
+		out.WriteRune(original)
+		rest = rest[size:]
+	}
+	if rest != "" {
+		return name
+	}
+	return out.String()
+}
+
+// highlightHTML escapes a highlight for HTML and turns its markers into `<mark>` elements.
+func highlightHTML(highlight string) string {
+	highlight = html.EscapeString(highlight)
+	highlight = strings.ReplaceAll(highlight, repositories.HighlightStart, "<mark>")
+	return strings.ReplaceAll(highlight, repositories.HighlightStop, "</mark>")
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+)
+
+// ServeStoredContent streams an object of the storage backend to the client.
+// Range, If-Range, If-None-Match and If-Modified-Since are handled by http.ServeContent,
+// using a strong ETag built from the content hash and modTime as Last-Modified.
+//
+// Parameters:
+//   - key: The storage key of the object.
+//   - mimeType: Content-Type of the response. When empty it is guessed from the content.
+//   - hash: Hex encoded SHA-256 of the content. When empty no ETag is sent.
+//   - modTime: Last modification time of the resource. When zero, the modification time of the stored object is used.
+//   - cacheControl: Value of the Cache-Control header.
+//
+// Returns:
+//   - An error if the stored object can't be opened.
+func ServeStoredContent(w http.ResponseWriter, r *http.Request, key, mimeType, hash string, modTime time.Time, cacheControl string) error {
+	f, info, err := storage.Open(r.Context(), boxed.GetInstance().Storage, key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if modTime.IsZero() {
+		modTime = info.ModTime
+	}
+	h := w.Header()
+	if hash != "" {
+		h.Set("ETag", fmt.Sprintf(`"%v"`, hash))
+	}
+	if mimeType != "" {
+		h.Set("Content-Type", mimeType)
+	}
+	h.Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, "", modTime, f)
+	return nil
+}
+
+// EnsureFileHash fills the content hash of files uploaded before hashes were recorded, storing it for later requests.
+func EnsureFileHash(repo *repositories.FilesRepo, file *repositories.File) error {
+	if file.ContentHash != "" {
+		return nil
+	}
+	hash, err := HashObject(file.StoragePath)
+	if err != nil {
+		return err
+	}
+	file.ContentHash = hash
+	return repo.SetContentHash(file.ID, hash)
+}
+
+// EnsureThumbnailHash does the same as EnsureFileHash for thumbnails.
+func EnsureThumbnailHash(repo *repositories.ThumbnailRepository, thumbnail *repositories.Thumbnail) error {
+	if thumbnail.ContentHash != "" {
+		return nil
+	}
+	hash, err := HashObject(thumbnail.StoragePath)
+	if err != nil {
+		return err
+	}
+	thumbnail.ContentHash = hash
+	return repo.UpdateByID(&repositories.Thumbnail{ID: thumbnail.ID, ContentHash: hash})
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	jobServices "github.com/David/Boxed/internal/jobs/services"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StreamJobKind is the kind of the jobs transcoding videos to HLS.
+const StreamJobKind = "hls"
+
+// MasterPlaylist is the name of the playlist listing the renditions of a stream, relative to its prefix.
+const MasterPlaylist = "master.m3u8"
+
+// hlsSegmentDuration is the target duration of the segments, in seconds.
+const hlsSegmentDuration = 6
+
+// rendition is a quality of the HLS ladder.
+type rendition struct {
+	Name         string
+	Height       int
+	VideoBitrate int // In kbit/s.
+	AudioBitrate int // In kbit/s.
+}
+
+// renditionLadder lists the renditions a video can be transcoded to, from the lowest quality.
+// Those taller than the video are skipped, except the first one, so every video gets at least one.
+var renditionLadder = []rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 160},
+}
+
+// streamJob is the payload of a transcoding job.
+type streamJob struct {
+	Thumbnail uuid.UUID `json:"thumbnail"`
+}
+
+// StreamJobHandler transcodes the videos queued with queueStream. Transcoding takes far longer than a thumbnail,
+// so it runs on a worker of its own and never holds back the other jobs.
+var StreamJobHandler = jobServices.Handler{
+	Run:     runStreamJob,
+	Dead:    streamJobDead,
+	Timeout: 2 * time.Hour,
+	Workers: 1,
+}
+
+// StreamPrefixFor returns the key prefix of the HLS renditions of a content, next to its thumbnail:
+// `<user>/thumbnail/<thumbnail>/hls`.
+func StreamPrefixFor(ownerID, thumbnailID uuid.UUID) string {
+	return path.Join(ownerID.String(), "thumbnail", thumbnailID.String(), "hls")
+}
+
+// queueStream registers a pending stream for a video content and queues its transcoding.
+// Other contents are left alone, as are all of them unless HLS_ENABLED is set.
+func queueStream(sr *repositories.VideoStreamsRepo, jr *repositories.JobsRepo, ownerID, thumbnailID uuid.UUID, mimeType string) error {
+	if !boxed.GetInstance().StreamVideos || !strings.HasPrefix(mimeType, "video/") {
+		return nil
+	}
+	if err := sr.Create(&repositories.VideoStream{
+		ThumbnailID:   thumbnailID,
+		StoragePrefix: StreamPrefixFor(ownerID, thumbnailID),
+	}); err != nil {
+		return err
+	}
+	return jobServices.Enqueue(jr, StreamJobKind, streamJob{Thumbnail: thumbnailID})
+}
+
+// runStreamJob transcodes the content of a stream and stores its playlists and segments.
+// Streams that are already ready, or whose content has been deleted, are left as they are.
+func runStreamJob(ctx context.Context, db *pgxpool.Pool, job *repositories.Job) error {
+	var payload streamJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobServices.Permanent(err)
+	}
+	sr := repositories.NewVideoStreamsRepo(db)
+	stream, err := sr.GetByThumbnailID(payload.Thumbnail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stream.Status == repositories.StreamReady {
+		return nil
+	}
+	src, err := repositories.NewFilesRepo(db).GetThumbnailSource(stream.ThumbnailID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	backend := boxed.GetInstance().Storage
+	inPath, cleanup, err := storage.Fetch(ctx, backend, src.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	outDir, err := os.MkdirTemp("", "boxed-hls-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	renditions, err := transcodeHLS(ctx, inPath, outDir)
+	if err != nil {
+		return err
+	}
+	// A previous attempt may have stored part of the stream.
+	if err := storage.DeletePrefix(ctx, backend, stream.StoragePrefix+"/"); err != nil {
+		return err
+	}
+	size, err := putDir(ctx, backend, outDir, stream.StoragePrefix)
+	if err != nil {
+		return err
+	}
+	stream.Status = repositories.StreamReady
+	stream.Renditions = renditions
+	stream.Size = size
+	return sr.Update(stream)
+}
+
+// streamJobDead records that a video couldn't be transcoded.
+func streamJobDead(db *pgxpool.Pool, job *repositories.Job, _ error) {
+	var payload streamJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return
+	}
+	sr := repositories.NewVideoStreamsRepo(db)
+	stream, err := sr.GetByThumbnailID(payload.Thumbnail)
+	if err == nil {
+		stream.Status = repositories.StreamFailed
+		err = sr.Update(stream)
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Couldn't mark stream %v as failed: %v", payload.Thumbnail, err)
+	}
+}
+
+// transcodeHLS transcodes a video to the renditions of the ladder that fit its height, in outDir:
+// `<rendition>/index.m3u8` with its `.ts` segments, and MasterPlaylist listing them.
+// Depends on ffmpeg and ffprobe to work.
+//
+// Returns:
+//   - The names of the renditions, from the lowest quality.
+func transcodeHLS(c context.Context, input, outDir string) ([]string, error) {
+	width, height, err := GetVideoSize(c, input)
+	if err != nil {
+		// Retrying won't give the file a video stream.
+		return nil, jobServices.Permanent(err)
+	}
+
+	master := []string{"#EXTM3U", "#EXT-X-VERSION:3"}
+	var names []string
+	for i, r := range renditionLadder {
+		if i > 0 && r.Height > height {
+			break
+		}
+		if err := os.Mkdir(filepath.Join(outDir, r.Name), 0755); err != nil {
+			return nil, err
+		}
+		if err := transcodeRendition(c, input, filepath.Join(outDir, r.Name), r); err != nil {
+			return nil, err
+		}
+		h := min(r.Height, height)
+		// Width of the scaled picture, rounded to an even number like ffmpeg does.
+		w := (width*h/height + 1) &^ 1
+		master = append(master,
+			fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d", (r.VideoBitrate*107/100+r.AudioBitrate)*1000, w, h),
+			r.Name+"/index.m3u8",
+		)
+		names = append(names, r.Name)
+	}
+	err = os.WriteFile(filepath.Join(outDir, MasterPlaylist), []byte(strings.Join(master, "\n")+"\n"), 0644)
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// transcodeRendition writes one rendition of a video as a VOD playlist, `index.m3u8`, and its segments in dir.
+func transcodeRendition(c context.Context, input, dir string, r rendition) error {
+	cmd := exec.CommandContext(
+		c,
+		"ffmpeg",
+		"-y",
+		"-i", input,
+		"-map", "0:v:0",
+		"-map", "0:a:0?", // Videos without sound are fine.
+		"-vf", fmt.Sprintf("scale=-2:'min(%d,ih)'", r.Height),
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-profile:v", "main",
+		"-b:v", fmt.Sprintf("%dk", r.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", r.VideoBitrate*107/100),
+		"-bufsize", fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+		// Keyframes on segment boundaries, so every segment can start playback.
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", hlsSegmentDuration),
+		"-c:a", "aac",
+		"-ac", "2",
+		"-b:a", fmt.Sprintf("%dk", r.AudioBitrate),
+		"-f", "hls",
+		"-hls_time", fmt.Sprint(hlsSegmentDuration),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "segment_%04d.ts"),
+		filepath.Join(dir, "index.m3u8"),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w\n%s", err, out)
+	}
+
+	return nil
+}
+
+// putDir stores every file below dir under prefix, keeping their relative path. The local files are consumed.
+//
+// Returns:
+//   - The total size of the stored files.
+func putDir(ctx context.Context, backend storage.Backend, dir, prefix string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return storage.PutFile(ctx, backend, path.Join(prefix, filepath.ToSlash(rel)), p)
+	})
+	return size, err
+}
+
+// StreamObjectKey resolves the name of a playlist or segment of a ready stream, relative to its prefix,
+// such as MasterPlaylist or `720p/segment_0001.ts`.
+//
+// Returns:
+//   - The storage key of the object and its content type.
+//   - false if name isn't part of the stream.
+func StreamObjectKey(stream *repositories.VideoStream, name string) (string, string, bool) {
+	if name == MasterPlaylist {
+		return path.Join(stream.StoragePrefix, name), "application/vnd.apple.mpegurl", true
+	}
+	dir, file, found := strings.Cut(name, "/")
+	if !found || !slices.Contains(stream.Renditions, dir) || strings.ContainsAny(file, `/\`) {
+		return "", "", false
+	}
+	switch {
+	case file == "index.m3u8":
+		return path.Join(stream.StoragePrefix, dir, file), "application/vnd.apple.mpegurl", true
+	case strings.HasPrefix(file, "segment_") && path.Ext(file) == ".ts":
+		return path.Join(stream.StoragePrefix, dir, file), "video/mp2t", true
+	}
+	return "", "", false
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	boxed "github.com/David/Boxed"
+	jobServices "github.com/David/Boxed/internal/jobs/services"
+	"github.com/David/Boxed/internal/storage"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TextJobKind is the kind of the jobs extracting the text of documents for search.
+const TextJobKind = "text"
+
+const (
+	// maxExtractedText is the most text kept for a content, in bytes. It keeps the search vector of large
+	// documents under the limits of Postgres.
+	maxExtractedText = 256 << 10
+	// maxOfficePart is the most data read from a part of an office document, protecting the server from
+	// documents crafted to expand into far more data than they hold.
+	maxOfficePart = 64 << 20
+	// pdfTextPages is the number of pages whose text is extracted from PDFs.
+	pdfTextPages = 100
+)
+
+// TextExtractor returns the text of a file (input), at most maxExtractedText bytes of it.
+type TextExtractor func(c context.Context, input string) (string, error)
+
+// Office document formats, by MIME type.
+const (
+	docxMimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	pptxMimeType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	xlsxMimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	odtMimeType  = "application/vnd.oasis.opendocument.text"
+	odpMimeType  = "application/vnd.oasis.opendocument.presentation"
+	odsMimeType  = "application/vnd.oasis.opendocument.spreadsheet"
+)
+
+var (
+	extractorsMu sync.RWMutex
+	// extractors maps MIME types, or whole top-level types such as `text/*`, to their text extractor.
+	extractors = map[string]TextExtractor{
+		"text/*":          ExtractPlainText,
+		"application/pdf": ExtractPdfText,
+		// Source code and data formats that aren't registered as text.
+		"application/json":       ExtractPlainText,
+		"application/javascript": ExtractPlainText,
+		"application/xml":        ExtractPlainText,
+		"application/x-sh":       ExtractPlainText,
+		"application/x-yaml":     ExtractPlainText,
+		"application/yaml":       ExtractPlainText,
+		"application/toml":       ExtractPlainText,
+		"application/sql":        ExtractPlainText,
+		docxMimeType:             ExtractOfficeText,
+		pptxMimeType:             ExtractOfficeText,
+		xlsxMimeType:             ExtractOfficeText,
+		odtMimeType:              ExtractOfficeText,
+		odpMimeType:              ExtractOfficeText,
+		odsMimeType:              ExtractOfficeText,
+	}
+)
+
+// RegisterTextExtractor sets the text extractor of a MIME type, such as `application/pdf`, or of a whole top-level
+// type, such as `text/*`. Exact types take precedence over top-level ones. It replaces any previous extractor.
+func RegisterTextExtractor(mimeType string, e TextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors[strings.ToLower(mimeType)] = e
+}
+
+// textExtractorFor returns the text extractor of a MIME type, ignoring its parameters (`; charset=utf-8`).
+func textExtractorFor(mimeType string) (TextExtractor, bool) {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	return lookupMimeType(extractors, mimeType)
+}
+
+// textJob is the payload of a text job.
+type textJob struct {
+	Thumbnail uuid.UUID `json:"thumbnail"`
+}
+
+// TextJobHandler extracts the text of the contents queued with queueText. Contents whose text can't be
+// extracted are simply found by their name.
+var TextJobHandler = jobServices.Handler{
+	Run: runTextJob,
+}
+
+// queueText queues the text extraction of a content that has an extractor. Other contents are left alone.
+func queueText(jr *repositories.JobsRepo, thumbnailID uuid.UUID, mimeType string) error {
+	if _, ok := textExtractorFor(mimeType); !ok {
+		return nil
+	}
+	return jobServices.Enqueue(jr, TextJobKind, textJob{Thumbnail: thumbnailID})
+}
+
+// runTextJob stores the text of a content, so search can find it.
+// Contents that have been deleted are skipped.
+func runTextJob(ctx context.Context, db *pgxpool.Pool, job *repositories.Job) error {
+	var payload textJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobServices.Permanent(err)
+	}
+	src, err := repositories.NewFilesRepo(db).GetThumbnailSource(payload.Thumbnail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	extract, ok := textExtractorFor(src.MimeType)
+	if !ok {
+		return nil
+	}
+
+	inPath, cleanup, err := storage.Fetch(ctx, boxed.GetInstance().Storage, src.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	text, err := extract(ctx, inPath)
+	if err != nil {
+		return err
+	}
+	return repositories.NewFileTextsRepo(db).Save(&repositories.FileText{
+		ThumbnailID: payload.Thumbnail,
+		Content:     cleanText(text),
+	})
+}
+
+// cleanText makes extracted text storable: valid UTF-8 without NUL bytes, at most maxExtractedText bytes.
+func cleanText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\x00", "")
+	if len(text) <= maxExtractedText {
+		return text
+	}
+	cut := maxExtractedText
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+// ExtractPlainText reads the beginning of a text file.
+func ExtractPlainText(_ context.Context, input string) (string, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, maxExtractedText))
+	return string(b), err
+}
+
+// ExtractPdfText reads the text of the first pages of a PDF.
+// Depends on pdftotext (poppler-utils) to work.
+func ExtractPdfText(c context.Context, input string) (string, error) {
+	c, cancel := context.WithTimeout(c, time.Minute)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(c, "pdftotext", "-q", "-enc", "UTF-8", "-l", strconv.Itoa(pdfTextPages), input, "-")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// ExtractOfficeText reads the text of an Office Open XML (docx, pptx, xlsx) or OpenDocument (odt, odp, ods)
+// document, from the XML parts holding its content. Paragraphs, slides and cells end up on their own lines.
+func ExtractOfficeText(_ context.Context, input string) (string, error) {
+	r, err := zip.OpenReader(input)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var parts []*zip.File
+	for _, f := range r.File {
+		if isOfficeTextPart(f.Name) {
+			parts = append(parts, f)
+		}
+	}
+	// Slides are numbered, keep them in order.
+	sort.Slice(parts, func(i, j int) bool { return naturalLess(parts[i].Name, parts[j].Name) })
+
+	var text strings.Builder
+	for _, part := range parts {
+		if text.Len() >= maxExtractedText {
+			break
+		}
+		rc, err := part.Open()
+		if err != nil {
+			return "", err
+		}
+		err = xmlText(io.LimitReader(rc, maxOfficePart), &text)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return text.String(), nil
+}
+
+// isOfficeTextPart reports whether a part of an office document holds its text.
+func isOfficeTextPart(name string) bool {
+	switch name {
+	case "word/document.xml", "xl/sharedStrings.xml", "content.xml":
+		return true
+	}
+	dir, file := path.Split(name)
+	return dir == "ppt/slides/" && strings.HasPrefix(file, "slide") && strings.HasSuffix(file, ".xml")
+}
+
+// naturalLess orders names comparing their digits as numbers, so `slide2.xml` comes before `slide10.xml`.
+func naturalLess(a, b string) bool {
+	trim := func(s string) (string, string) {
+		i := strings.IndexAny(s, "0123456789")
+		if i < 0 {
+			return s, ""
+		}
+		j := i
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		return s[:i], strings.TrimLeft(s[i:j], "0")
+	}
+	pa, na := trim(a)
+	pb, nb := trim(b)
+	if pa != pb || na == nb {
+		return a < b
+	}
+	if len(na) != len(nb) {
+		return len(na) < len(nb)
+	}
+	return na < nb
+}
+
+// xmlText appends the character data of an XML document to text, starting a new line after the elements
+// closing a paragraph, a line break, a shared string or a table row, and stopping at maxExtractedText bytes.
+func xmlText(r io.Reader, text *strings.Builder) error {
+	d := xml.NewDecoder(r)
+	for text.Len() < maxExtractedText {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tab":
+				text.WriteByte('\t')
+			case "br", "line-break":
+				text.WriteByte('\n')
+			case "s": // Runs of spaces in OpenDocument.
+				text.WriteByte(' ')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p", "h", "si", "tr", "table-row":
+				text.WriteByte('\n')
+			case "tc", "table-cell":
+				text.WriteByte('\t')
+			}
+		}
+	}
+	return nil
+}
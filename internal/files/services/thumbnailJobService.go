@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	jobServices "github.com/David/Boxed/internal/jobs/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ThumbnailJobKind is the kind of the jobs generating thumbnails.
+const ThumbnailJobKind = "thumbnail"
+
+// thumbnailJob is the payload of a thumbnail job.
+type thumbnailJob struct {
+	Thumbnail uuid.UUID `json:"thumbnail"`
+}
+
+// ThumbnailJobHandler generates the thumbnails queued with enqueueThumbnail.
+var ThumbnailJobHandler = jobServices.Handler{
+	Run:  runThumbnailJob,
+	Dead: thumbnailJobDead,
+}
+
+// enqueueThumbnail queues the generation of a thumbnail. The content using it is looked up when the job runs.
+func enqueueThumbnail(r *repositories.JobsRepo, thumbnailID uuid.UUID) error {
+	return jobServices.Enqueue(r, ThumbnailJobKind, thumbnailJob{Thumbnail: thumbnailID})
+}
+
+// runThumbnailJob generates the thumbnail of a job from the content using it.
+// Thumbnails that are already generated, or whose content has been deleted, are left as they are.
+func runThumbnailJob(ctx context.Context, db *pgxpool.Pool, job *repositories.Job) error {
+	var payload thumbnailJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobServices.Permanent(err)
+	}
+	repository := repositories.NewThumbnailRepository(db)
+	thumbnail, err := repository.GetByID(payload.Thumbnail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if thumbnail.Status == repositories.ThumbnailReady {
+		return nil
+	}
+	src, err := repositories.NewFilesRepo(db).GetThumbnailSource(thumbnail.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	outKey := ThumbnailPathFor(thumbnail.OwnerId, thumbnail.ID)
+	err = CreateAndSaveThumbnail(ctx, src.StoragePath, outKey, src.MimeType, src.OriginalName, thumbnail.ID, repository)
+	if errors.Is(err, ErrUnsupportedThumbnail) {
+		return repository.UpdateByID(&repositories.Thumbnail{ID: thumbnail.ID, Status: repositories.ThumbnailUnsupported})
+	}
+	return err
+}
+
+// thumbnailJobDead records that a thumbnail couldn't be generated.
+func thumbnailJobDead(db *pgxpool.Pool, job *repositories.Job, _ error) {
+	var payload thumbnailJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return
+	}
+	err := repositories.NewThumbnailRepository(db).UpdateByID(&repositories.Thumbnail{ID: payload.Thumbnail, Status: repositories.ThumbnailFailed})
+	if err != nil {
+		log.Printf("Couldn't mark thumbnail %v as failed: %v", payload.Thumbnail, err)
+	}
+}
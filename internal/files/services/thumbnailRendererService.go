@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ThumbnailRenderer generates a thumbnail at most width pixels wide at output, for a given file (input).
+// The image format follows the extension of output.
+type ThumbnailRenderer func(c context.Context, input, output string, width int) error
+
+var (
+	renderersMu sync.RWMutex
+	// renderers maps MIME types, or whole top-level types such as `image/*`, to their renderer.
+	renderers = map[string]ThumbnailRenderer{
+		"video/*":         GenerateVideoThumbnail,
+		"audio/*":         GenerateAudioThumbnail,
+		"image/*":         GenerateImageThumbnail,
+		"application/pdf": GeneratePdfThumbnail,
+		"text/*":          GenerateTextThumbnail,
+		// Source code and data formats that aren't registered as text.
+		"application/json":       GenerateTextThumbnail,
+		"application/javascript": GenerateTextThumbnail,
+		"application/xml":        GenerateTextThumbnail,
+		"application/x-sh":       GenerateTextThumbnail,
+		"application/x-yaml":     GenerateTextThumbnail,
+		"application/yaml":       GenerateTextThumbnail,
+		"application/toml":       GenerateTextThumbnail,
+		"application/sql":        GenerateTextThumbnail,
+	}
+)
+
+// RegisterThumbnailRenderer sets the renderer of a MIME type, such as `application/pdf`, or of a whole top-level
+// type, such as `image/*`. Exact types take precedence over top-level ones. It replaces any previous renderer.
+func RegisterThumbnailRenderer(mimeType string, r ThumbnailRenderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[strings.ToLower(mimeType)] = r
+}
+
+// thumbnailRendererFor returns the renderer of a MIME type, ignoring its parameters (`; charset=utf-8`).
+func thumbnailRendererFor(mimeType string) (ThumbnailRenderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return lookupMimeType(renderers, mimeType)
+}
+
+// lookupMimeType finds the entry of a MIME type in a map keyed by MIME types or whole top-level types such as
+// `image/*`, ignoring its parameters (`; charset=utf-8`). Exact types take precedence over top-level ones.
+func lookupMimeType[T any](m map[string]T, mimeType string) (T, bool) {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	mimeType = strings.TrimSpace(mimeType)
+	top, _, found := strings.Cut(mimeType, "/")
+	if !found {
+		var zero T
+		return zero, false
+	}
+	if v, ok := m[mimeType]; ok {
+		return v, true
+	}
+	v, ok := m[top+"/*"]
+	return v, ok
+}
+
+// GenerateAudioThumbnail uses the cover art embedded in an audio file (ID3, FLAC or MP4 tags) as its thumbnail.
+// Returns ErrUnsupportedThumbnail when the file has none.
+// Depends on ffmpeg and ffprobe to work.
+func GenerateAudioThumbnail(c context.Context, input, output string, width int) error {
+	c, cancel := context.WithTimeout(c, 10*time.Second)
+	defer cancel()
+
+	// Cover arts are demuxed as video streams.
+	probe := exec.CommandContext(
+		c,
+		"ffprobe",
+		"-v", "error",
+		"-select_streams", "v",
+		"-show_entries", "stream=index",
+		"-of", "csv=p=0",
+		input,
+	)
+	streams, err := probe.Output()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(streams)) == "" {
+		return fmt.Errorf("%w: no cover art", ErrUnsupportedThumbnail)
+	}
+
+	cmd := exec.CommandContext(
+		c,
+		"ffmpeg",
+		"-y",
+		"-i", input,
+		"-map", "0:v:0",
+		"-frames:v", "1",
+		"-vf", scaleFilter(width),
+		output,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w\n%s", err, out)
+	}
+
+	return nil
+}
+
+// GeneratePdfThumbnail renders the first page of a PDF document as a thumbnail.
+// Depends on pdftoppm (poppler-utils) and ffmpeg to work.
+func GeneratePdfThumbnail(c context.Context, input, output string, width int) error {
+	c, cancel := context.WithTimeout(c, 20*time.Second)
+	defer cancel()
+
+	tmpDir, err := os.MkdirTemp("", "boxed-pdf-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	page := filepath.Join(tmpDir, "page")
+
+	cmd := exec.CommandContext(
+		c,
+		"pdftoppm",
+		"-f", "1",
+		"-l", "1",
+		"-singlefile",
+		"-png",
+		"-scale-to", fmt.Sprint(width*2), // Longest side, the image renderer shrinks it to the final width.
+		input,
+		page,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pdftoppm failed: %w\n%s", err, out)
+	}
+
+	return GenerateImageThumbnail(c, page+".png", output, width)
+}
+
+const (
+	// textPreviewLines and textPreviewColumns bound the part of a text file shown in its thumbnail.
+	textPreviewLines   = 40
+	textPreviewColumns = 80
+	// textPreviewBytes bounds what is read from a text file to find those lines.
+	textPreviewBytes = 64 << 10
+)
+
+// GenerateTextThumbnail renders the beginning of a text file (plain text, Markdown, source code...) as a page
+// snapshot, in a monospace font on a white background.
+// Depends on ffmpeg, built with fontconfig, to work.
+func GenerateTextThumbnail(c context.Context, input, output string, width int) error {
+	c, cancel := context.WithTimeout(c, 10*time.Second)
+	defer cancel()
+
+	f, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	head, err := io.ReadAll(io.LimitReader(f, textPreviewBytes))
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	snippet, err := os.CreateTemp("", "boxed-text-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(snippet.Name())
+	_, err = snippet.WriteString(textSnippet(string(head)))
+	if closeErr := snippet.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(
+		c,
+		"ffmpeg",
+		"-y",
+		"-f", "lavfi",
+		"-i", "color=c=white:s=800x1000",
+		"-vf", fmt.Sprintf("drawtext=textfile='%s':expansion=none:font=monospace:fontsize=16:fontcolor=0x333333:x=24:y=24:line_spacing=6,%s",
+			escapeFilterValue(snippet.Name()), scaleFilter(width)),
+		"-frames:v", "1",
+		output,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w\n%s", err, out)
+	}
+
+	return nil
+}
+
+// textSnippet keeps the first lines of a text, cut to a page width, with tabs expanded and control characters dropped.
+func textSnippet(text string) string {
+	text = strings.ToValidUTF8(text, "�")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) > textPreviewLines {
+		lines = lines[:textPreviewLines]
+	}
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		line = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, line)
+		if runes := []rune(line); len(runes) > textPreviewColumns {
+			line = string(runes[:textPreviewColumns])
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeFilterValue escapes a value single-quoted inside an ffmpeg filter graph, where only quotes are special.
+func escapeFilterValue(value string) string {
+	return strings.ReplaceAll(value, `'`, `'\''`)
+}
@@ -2,82 +2,110 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/storage"
 	"github.com/David/Boxed/repositories"
 	"github.com/google/uuid"
 )
 
-// createAndSaveThumbnail generates a thumbnail for the given file and saves it to disk.
+// ErrUnsupportedThumbnail is returned when no thumbnail renderer is registered for a mime type.
+var ErrUnsupportedThumbnail = errors.New("no thumbnail can be generated for this MIME type")
+
+// DefaultThumbnailWidth is the width of the thumbnail generated for every file, served as the `medium` JPEG variant.
+const DefaultThumbnailWidth = 320
+
+// CreateAndSaveThumbnail generates a thumbnail for the given file and saves it to the storage backend.
+// The source is fetched to the local disk when the backend isn't local, as ffmpeg needs a file to read.
 //
 // Parameters:
-//   - fpath: The file path where the thumbnail is expected to be saved.
-func CreateAndSaveThumbnail(inPath, outPath, mime, originalName string, thumbnailEntry uuid.UUID, repository *repositories.ThumbnailRepository) error {
-	if inPath == "" {
-		return fmt.Errorf("Input path is empty")
+//   - inKey: The storage key of the file to generate the thumbnail from.
+//   - outKey: The storage key where the thumbnail is expected to be saved.
+//
+// Returns:
+//   - ErrUnsupportedThumbnail if no thumbnail can be made for mime.
+func CreateAndSaveThumbnail(ctx context.Context, inKey, outKey, mime, originalName string, thumbnailEntry uuid.UUID, repository *repositories.ThumbnailRepository) error {
+	hash, size, err := renderThumbnail(ctx, inKey, outKey, mime, DefaultThumbnailWidth)
+	if err != nil {
+		return err
+	}
+	// Fill thumbnail row
+	thumbnail, err := repository.GetByID(thumbnailEntry)
+	if err != nil {
+		return err
+	}
+	thumbnail.StoragePath = outKey
+	thumbnail.OriginalName = originalName
+	thumbnail.ContentHash = hash
+	thumbnail.Size = size
+	thumbnail.Status = repositories.ThumbnailReady
+	return repository.UpdateByID(thumbnail)
+}
+
+// renderThumbnail generates a thumbnail at most width pixels wide from a stored content and stores it under outKey,
+// with the renderer registered for mime. The image format follows the extension of outKey (`.jpg`, `.webp`).
+//
+// Returns:
+//   - The hex encoded SHA-256 and the size of the stored thumbnail.
+//   - ErrUnsupportedThumbnail if no thumbnail can be made for mime.
+func renderThumbnail(ctx context.Context, inKey, outKey, mime string, width int) (string, int64, error) {
+	if inKey == "" {
+		return "", 0, fmt.Errorf("Input path is empty")
+	}
+	if outKey == "" {
+		return "", 0, fmt.Errorf("Output path is empty")
 	}
 
-	// Check if input file exists
-	if _, err := os.Stat(inPath); os.IsNotExist(err) {
-		return fmt.Errorf("Input file does not exist: %s", inPath)
+	generate, ok := thumbnailRendererFor(mime)
+	if !ok {
+		return "", 0, fmt.Errorf("%w: %s", ErrUnsupportedThumbnail, mime)
 	}
 
-	if outPath == "" {
-		return fmt.Errorf("Output path is empty")
+	backend := boxed.GetInstance().Storage
+	inPath, cleanup, err := storage.Fetch(ctx, backend, inKey)
+	if err != nil {
+		return "", 0, fmt.Errorf("Input file does not exist: %s: %w", inKey, err)
 	}
+	defer cleanup()
 
-	// Ensure parent directories for the output file exist
-	outDir := filepath.Dir(outPath)
-	if err := os.MkdirAll(outDir, 0755); err != nil {
-		return fmt.Errorf("Failed to create parent directories for output file: %w", err)
+	outDir, err := os.MkdirTemp("", "boxed-thumbnail-*")
+	if err != nil {
+		return "", 0, err
 	}
+	defer os.RemoveAll(outDir)
+	outPath := filepath.Join(outDir, path.Base(outKey))
 
-	mimeParts := strings.Split(mime, "/")
-	if len(mimeParts) != 2 {
-		return fmt.Errorf("Invalid MIME type: %s", mime)
+	if err := generate(ctx, inPath, outPath, width); err != nil {
+		return "", 0, err
 	}
-	mimeType := mimeParts[0]
-	switch mimeType {
-	case "video":
-		log.Println("LLEGOOwnflka")
-		err := GenerateVideoThumbnail(context.Background(), inPath, outPath)
-		if err != nil {
-			return err
-		}
-		// Fill thumbnail row
-		thumbnail, err := repository.GetByID(thumbnailEntry)
-		if err != nil {
-			return err
-		}
-		thumbnail.StoragePath = outPath
-		thumbnail.OriginalName = originalName
-		return repository.UpdateByID(thumbnail)
-
-	case "image":
-		err := GenerateImageThumbnail(context.Background(), inPath, outPath)
-		if err != nil {
-			return err
-		}
-		// Fill thumbnail row
-		thumbnail, err := repository.GetByID(thumbnailEntry)
-		if err != nil {
-			return err
-		}
-		thumbnail.StoragePath = outPath
-		thumbnail.OriginalName = originalName
-		return repository.UpdateByID(thumbnail)
-	default:
-		log.Println("Unsupported MIME type")
-		return fmt.Errorf("Unsupported MIME type")
+	hash, err := HashFile(outPath)
+	if err != nil {
+		return "", 0, err
+	}
+	stat, err := os.Stat(outPath)
+	if err != nil {
+		return "", 0, err
 	}
+	if err := storage.PutFile(ctx, backend, outKey, outPath); err != nil {
+		return "", 0, err
+	}
+	return hash, stat.Size(), nil
+}
+
+// scaleFilter returns the ffmpeg filter shrinking a picture to width pixels, keeping its ratio. Smaller pictures are left as they are.
+func scaleFilter(width int) string {
+	return fmt.Sprintf("scale='min(%d,iw)':-2", width)
 }
+
 func GetVideoDuration(c context.Context, iPath string) (float64, error) {
 	cmd := exec.CommandContext(
 		c,
@@ -101,9 +129,34 @@ func GetVideoDuration(c context.Context, iPath string) (float64, error) {
 	return duration, nil
 }
 
-// GenerateVideoThumbnail will generate a thumbnail at output path, for a given file (input)
+// GetVideoSize returns the width and height in pixels of the first video stream of a file.
+func GetVideoSize(c context.Context, iPath string) (int, int, error) {
+	cmd := exec.CommandContext(
+		c,
+		"ffprobe",
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height",
+		"-of", "csv=s=x:p=0",
+		iPath,
+	)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var width, height int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(out)), "%dx%d", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("no video stream: %w", err)
+	}
+
+	return width, height, nil
+}
+
+// GenerateVideoThumbnail will generate a thumbnail at most width pixels wide at output path, for a given file (input)
 // Depends on ffmpeg to work.
-func GenerateVideoThumbnail(c context.Context, input, output string) error {
+func GenerateVideoThumbnail(c context.Context, input, output string, width int) error {
 	ctx, cancel := context.WithTimeout(c, 10*time.Second)
 	defer cancel()
 
@@ -121,7 +174,7 @@ func GenerateVideoThumbnail(c context.Context, input, output string) error {
 		"-ss", fmt.Sprintf("%.2f", half),
 		"-i", input,
 		"-vframes", "1",
-		"-vf", "scale=320:-1",
+		"-vf", scaleFilter(width),
 		output,
 	)
 
@@ -133,16 +186,26 @@ func GenerateVideoThumbnail(c context.Context, input, output string) error {
 	return nil
 }
 
-func GenerateImageThumbnail(c context.Context, input, output string) error {
+// GenerateImageThumbnail will generate a thumbnail at most width pixels wide at output path, for a given picture (input),
+// turned upright according to its EXIF orientation.
+// Depends on ffmpeg to work.
+func GenerateImageThumbnail(c context.Context, input, output string, width int) error {
 	c, cancel := context.WithTimeout(c, 5*time.Second)
 	defer cancel()
 
+	filter := scaleFilter(width) // same logic as video thumbnails
+	if exif, err := ReadExif(input); err == nil {
+		if upright := orientationFilter(exif.Orientation); upright != "" {
+			filter = upright + "," + filter
+		}
+	}
 	cmd := exec.CommandContext(
 		c,
 		"ffmpeg",
 		"-y",
+		"-noautorotate", // The orientation is applied above, whether this ffmpeg reads it or not.
 		"-i", input,
-		"-vf", "scale=320:-1", // same logic as video thumbnails
+		"-vf", filter,
 		output,
 	)
 
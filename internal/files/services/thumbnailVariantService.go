@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ThumbnailSizes maps the variant names to their maximum width in pixels.
+var ThumbnailSizes = map[string]int{
+	"small":  160,
+	"medium": DefaultThumbnailWidth,
+	"large":  1024,
+}
+
+// thumbnailFormats maps the variant formats to the extension of their storage key, which selects the encoder.
+var thumbnailFormats = map[string]string{
+	"jpeg": ".jpg",
+	"webp": ".webp",
+}
+
+// ErrUnknownVariant is returned for a size or format that isn't in ThumbnailSizes or thumbnailFormats.
+var ErrUnknownVariant = errors.New("unknown thumbnail size or format")
+
+// variantLocks serializes the generation of the same variant, so concurrent requests render it once.
+var variantLocks sync.Map
+
+// ThumbnailVariantPathFor returns the storage key of a thumbnail variant: `<user>/thumbnail/<thumbnail>/<size>.<ext>`.
+func ThumbnailVariantPathFor(ownerID, thumbnailID uuid.UUID, name, format string) string {
+	return path.Join(ownerID.String(), "thumbnail", thumbnailID.String(), name+thumbnailFormats[format])
+}
+
+// CheckThumbnailVariant returns ErrUnknownVariant if name or format isn't supported. Empty values are accepted.
+func CheckThumbnailVariant(name, format string) error {
+	if _, ok := ThumbnailSizes[name]; name != "" && !ok {
+		return ErrUnknownVariant
+	}
+	if _, ok := thumbnailFormats[format]; format != "" && !ok {
+		return ErrUnknownVariant
+	}
+	return nil
+}
+
+// GetThumbnailVariant returns a variant of a generated thumbnail, rendering it from the original content the first
+// time it is requested. An empty name or format stands for `medium` and `jpeg`, which is the thumbnail itself.
+//
+// Returns:
+//   - ErrUnknownVariant if name or format isn't supported.
+//   - pgx.ErrNoRows if the content the thumbnail was made from no longer exists.
+func GetThumbnailVariant(ctx context.Context, db *pgxpool.Pool, thumbnail *repositories.Thumbnail, name, format string) (*repositories.ThumbnailVariant, error) {
+	if err := CheckThumbnailVariant(name, format); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = "medium"
+	}
+	if format == "" {
+		format = "jpeg"
+	}
+	if name == "medium" && format == "jpeg" {
+		return &repositories.ThumbnailVariant{
+			ThumbnailID: thumbnail.ID,
+			Name:        name,
+			Format:      format,
+			StoragePath: thumbnail.StoragePath,
+			ContentHash: thumbnail.ContentHash,
+			Size:        thumbnail.Size,
+		}, nil
+	}
+
+	repo := repositories.NewThumbnailVariantsRepo(db)
+	variant, err := repo.Get(thumbnail.ID, name, format)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return variant, err
+	}
+	lockKey := fmt.Sprintf("%v/%v.%v", thumbnail.ID, name, format)
+	m, _ := variantLocks.LoadOrStore(lockKey, &sync.Mutex{})
+	mutex := m.(*sync.Mutex)
+	mutex.Lock()
+	defer func() {
+		mutex.Unlock()
+		variantLocks.Delete(lockKey)
+	}()
+	// Another request may have rendered it while this one was waiting.
+	variant, err = repo.Get(thumbnail.ID, name, format)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return variant, err
+	}
+
+	src, err := repositories.NewFilesRepo(db).GetThumbnailSource(thumbnail.ID)
+	if err != nil {
+		return nil, err
+	}
+	key := ThumbnailVariantPathFor(thumbnail.OwnerId, thumbnail.ID, name, format)
+	hash, size, err := renderThumbnail(ctx, src.StoragePath, key, src.MimeType, ThumbnailSizes[name])
+	if err != nil {
+		return nil, err
+	}
+	variant = &repositories.ThumbnailVariant{
+		ThumbnailID: thumbnail.ID,
+		Name:        name,
+		Format:      format,
+		StoragePath: key,
+		ContentHash: hash,
+		Size:        size,
+		CreatedAt:   time.Now(),
+	}
+	if err := repo.Save(variant); err != nil {
+		DeleteFile(key)
+		return nil, err
+	}
+	return variant, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultTimelineLimit is the number of files in a page of the timeline when the client doesn't choose.
+	DefaultTimelineLimit = 100
+	// MaxTimelineLimit is the largest page of the timeline a client can ask for.
+	MaxTimelineLimit = 500
+)
+
+var ErrInvalidCursor = errors.New("timeline cursor is not valid")
+
+// TimelineDay is the files of a page of the timeline taken on the same day.
+type TimelineDay struct {
+	Date  string              `json:"date"` // YYYY-MM-DD
+	Files []repositories.File `json:"files"`
+}
+
+// Timeline is a page of the timeline.
+type Timeline struct {
+	Days []TimelineDay `json:"days"`
+	// NextCursor reads the next page, empty on the last one.
+	NextCursor string `json:"next_cursor"`
+}
+
+// encodeCursor turns the position of the last file of a page into an opaque cursor.
+func encodeCursor(key repositories.TimelineKey) (string, error) {
+	raw, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor reads back a cursor made by encodeCursor.
+func decodeCursor(cursor string) (*repositories.TimelineKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	key := &repositories.TimelineKey{}
+	if err := json.Unmarshal(raw, key); err != nil || key.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return key, nil
+}
+
+// GetTimeline reads a page of the pictures and videos of a user, most recently taken first, grouped by the day
+// they were taken in loc. Files without a capture date are placed at their upload date.
+// An empty cursor reads the first page. A day can continue on the next page, with the same date.
+//
+// Returns:
+//   - ErrInvalidCursor if cursor wasn't returned by a previous call.
+func GetTimeline(db *pgxpool.Pool, ownerID uuid.UUID, cursor string, limit int, loc *time.Location) (*Timeline, error) {
+	var after *repositories.TimelineKey
+	if cursor != "" {
+		var err error
+		if after, err = decodeCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+	// One more file tells whether there is a next page.
+	entries, err := repositories.NewFilesRepo(db).GetTimeline(ownerID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	timeline := &Timeline{Days: []TimelineDay{}}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		if timeline.NextCursor, err = encodeCursor(entries[limit-1].Key); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range entries {
+		date := e.Key.TakenAt.In(loc).Format(time.DateOnly)
+		if n := len(timeline.Days); n == 0 || timeline.Days[n-1].Date != date {
+			timeline.Days = append(timeline.Days, TimelineDay{Date: date})
+		}
+		day := &timeline.Days[len(timeline.Days)-1]
+		day.Files = append(day.Files, e.File)
+	}
+	return timeline, nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PurgeFile permanently deletes a file: its row, versions and thumbnail rows in one transaction, then the
+// thumbnail objects and the stored contents.
+//
+// Returns:
+//   - error: An error if the rows couldn't be deleted, in which case nothing was removed from storage.
+func PurgeFile(db *pgxpool.Pool, file *repositories.File) error {
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+
+	cleanup, err := DeleteFilesTx(db, t, []repositories.File{*file})
+	if err != nil {
+		return err
+	}
+	if err := t.Commit(context.Background()); err != nil {
+		return err
+	}
+	cleanup()
+	return nil
+}
+
+// DeleteFilesTx deletes the rows of files, their versions and every thumbnail row they reference inside t.
+// The stored contents and thumbnail objects are only released by the returned cleanup, to be called once t
+// has been committed.
+func DeleteFilesTx(db *pgxpool.Pool, t pgx.Tx, files []repositories.File) (func(), error) {
+	fr := repositories.NewFilesRepo(db).WithTx(t)
+	vr := repositories.NewFileVersionsRepo(db).WithTx(t)
+
+	var versions []repositories.FileVersion
+	var thumbnails []uuid.UUID
+	for _, f := range files {
+		fileVersions, err := vr.GetByFileID(f.ID)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, fileVersions...)
+		thumbnails = append(thumbnails, f.ThumbnailId)
+		for _, v := range fileVersions {
+			if v.ThumbnailId != nil {
+				thumbnails = append(thumbnails, *v.ThumbnailId)
+			}
+		}
+		// Cascades to the versions.
+		if err := fr.Delete(f.ID); err != nil {
+			return nil, err
+		}
+	}
+	// The thumbnails go after the files and versions, which reference them.
+	deleteThumbnails, err := deleteThumbnailsTx(db, t, thumbnails)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		deleteThumbnails()
+		for _, f := range files {
+			DeleteFileContent(db, &f)
+		}
+		for _, v := range versions {
+			DeleteVersionContent(db, &v)
+		}
+	}, nil
+}
+
+// deleteThumbnailsTx deletes thumbnail rows inside t, with their variants, video previews and streams, skipping
+// the ones that don't exist.
+//
+// Returns:
+//   - A function removing what had been generated for them from storage, to be called once t has been committed.
+func deleteThumbnailsTx(db *pgxpool.Pool, t pgx.Tx, ids []uuid.UUID) (func(), error) {
+	tr := repositories.NewThumbnailRepository(db).WithTx(t)
+	vr := repositories.NewThumbnailVariantsRepo(db).WithTx(t)
+	pr := repositories.NewVideoPreviewsRepo(db).WithTx(t)
+	sr := repositories.NewVideoStreamsRepo(db).WithTx(t)
+	var paths, prefixes []string
+	for _, id := range ids {
+		thumbnail, err := tr.GetByID(id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, err
+		}
+		variants, err := vr.GetByThumbnailID(thumbnail.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range variants {
+			paths = append(paths, v.StoragePath)
+		}
+		preview, err := pr.GetByThumbnailID(thumbnail.ID)
+		if err == nil {
+			prefixes = append(prefixes, preview.StoragePrefix)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		stream, err := sr.GetByThumbnailID(thumbnail.ID)
+		if err == nil {
+			prefixes = append(prefixes, stream.StoragePrefix)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		// Cascades to the variants, the preview and the stream.
+		if err := tr.DeleteByID(thumbnail.ID); err != nil {
+			return nil, err
+		}
+		if thumbnail.StoragePath != "" {
+			paths = append(paths, thumbnail.StoragePath)
+		}
+	}
+	return func() {
+		for _, p := range paths {
+			DeleteFile(p)
+		}
+		for _, prefix := range prefixes {
+			deletePrefix(prefix)
+		}
+	}, nil
+}
+
+// PurgeExpiredTrash permanently deletes the files that have been in the trash for longer than retention.
+func PurgeExpiredTrash(db *pgxpool.Pool, retention time.Duration) {
+	expired, err := repositories.NewFilesRepo(db).GetTrashedBefore(time.Now().Add(-retention))
+	if err != nil {
+		log.Println("Error while getting expired trash:", err)
+		return
+	}
+	for _, file := range expired {
+		if err := PurgeFile(db, &file); err != nil {
+			log.Printf("Error while purging file %v from the trash: %v", file.ID, err)
+		}
+	}
+}
+
+// StartTrashPurger runs PurgeExpiredTrash every interval, for as long as the server runs.
+func StartTrashPurger(db *pgxpool.Pool, retention time.Duration, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			PurgeExpiredTrash(db, retention)
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// withFileLocked runs fn inside a transaction holding the row lock of a file.
+// Returns pgx.ErrNoRows if the file doesn't exist or is in the trash.
+func withFileLocked(db *pgxpool.Pool, fileID uuid.UUID, fn func(t pgx.Tx, file *repositories.File) error) error {
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	file, err := repositories.NewFilesRepo(db).WithTx(t).GetForUpdate(fileID)
+	if err != nil {
+		return err
+	}
+	if err := fn(t, file); err != nil {
+		return err
+	}
+	return t.Commit(context.Background())
+}
+
+// versionOf snapshots the current content of a file as a version.
+func versionOf(file *repositories.File) *repositories.FileVersion {
+	thumbnail := file.ThumbnailId
+	return &repositories.FileVersion{
+		FileID:      file.ID,
+		Version:     file.Version,
+		StoragePath: file.StoragePath,
+		Size:        file.Size,
+		MimeType:    file.MimeType,
+		ThumbnailId: &thumbnail,
+		ContentHash: file.ContentHash,
+		BlobHash:    file.BlobHash,
+		CreatedAt:   file.UpdatedAt,
+		Metadata:    file.Metadata,
+	}
+}
+
+// RegisterFileVersion makes content already stored in blob the new current version of an existing file.
+// The previous content is kept as a version, with its blob reference and thumbnail. If the version can't
+// be saved the reference to the blob is released.
+// Callers must have checked that the uploader has write access to the file.
+//
+// Returns:
+//   - pgx.ErrNoRows if the file doesn't exist or is in the trash.
+//   - userServices.ErrQuotaExceeded if the content doesn't fit in the owner's quota anymore.
+func RegisterFileVersion(db *pgxpool.Pool, info FileInfo, fileID uuid.UUID, blob *repositories.Blob) (*repositories.File, error) {
+	thumbnailUUID := uuid.New()
+	var updated *repositories.File
+	err := withFileLocked(db, fileID, func(t pgx.Tx, file *repositories.File) error {
+		if err := userServices.CheckQuotaLocked(db, t, file.OwnerID, blob.Size); err != nil {
+			return err
+		}
+		if err := repositories.NewThumbnailRepository(db).WithTx(t).Create(&repositories.Thumbnail{
+			ID:      thumbnailUUID,
+			OwnerId: file.OwnerID,
+		}); err != nil {
+			return err
+		}
+		if err := queueGeneration(db, t, file.OwnerID, thumbnailUUID, info.MimeType); err != nil {
+			return err
+		}
+		if err := repositories.NewFileVersionsRepo(db).WithTx(t).Create(versionOf(file)); err != nil {
+			return err
+		}
+		file.StoragePath = blob.StoragePath
+		file.Size = blob.Size
+		file.MimeType = info.MimeType
+		file.ThumbnailId = thumbnailUUID
+		file.ContentHash = blob.Hash
+		file.BlobHash = &blob.Hash
+		file.Metadata = nil // Probed again by its job.
+		file.Version++
+		file.UpdatedAt = time.Now()
+		updated = file
+		return repositories.NewFilesRepo(db).WithTx(t).ReplaceContent(file)
+	})
+	if err != nil {
+		releaseAfterFailure(db, blob)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// RestoreFileVersion makes a previous version the current content of a file again, under a new version number.
+// The replaced content is kept as a version, so restoring can be undone.
+//
+// Returns:
+//   - pgx.ErrNoRows if the file or the version doesn't exist.
+func RestoreFileVersion(db *pgxpool.Pool, fileID uuid.UUID, version int) (*repositories.File, error) {
+	var restored *repositories.File
+	err := withFileLocked(db, fileID, func(t pgx.Tx, file *repositories.File) error {
+		vr := repositories.NewFileVersionsRepo(db).WithTx(t)
+		v, err := vr.GetByVersion(fileID, version)
+		if err != nil {
+			return err
+		}
+		// The blob reference and thumbnail move from the version row to the file and the other way around.
+		if err := vr.Delete(v.ID); err != nil {
+			return err
+		}
+		if err := vr.Create(versionOf(file)); err != nil {
+			return err
+		}
+		file.StoragePath = v.StoragePath
+		file.Size = v.Size
+		file.MimeType = v.MimeType
+		file.ContentHash = v.ContentHash
+		file.BlobHash = v.BlobHash
+		file.Metadata = v.Metadata
+		file.Version++
+		file.UpdatedAt = time.Now()
+		if v.ThumbnailId != nil {
+			file.ThumbnailId = *v.ThumbnailId
+		} else {
+			// files.thumbnail_id can't be empty, give the content a new thumbnail.
+			file.ThumbnailId = uuid.New()
+			if err := repositories.NewThumbnailRepository(db).WithTx(t).Create(&repositories.Thumbnail{
+				ID:      file.ThumbnailId,
+				OwnerId: file.OwnerID,
+			}); err != nil {
+				return err
+			}
+			if err := queueGeneration(db, t, file.OwnerID, file.ThumbnailId, file.MimeType); err != nil {
+				return err
+			}
+		}
+		restored = file
+		return repositories.NewFilesRepo(db).WithTx(t).ReplaceContent(file)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// PruneFileVersions deletes the versions of a file beyond the keep newest ones and those uploaded before
+// a given time, see FileVersionsRepo.Prune. Their content and thumbnails are released afterwards.
+//
+// Returns:
+//   - The number of deleted versions.
+//   - pgx.ErrNoRows if the file doesn't exist or is in the trash.
+func PruneFileVersions(db *pgxpool.Pool, fileID uuid.UUID, keep int, before *time.Time) (int, error) {
+	var pruned []repositories.FileVersion
+	var deleteThumbnails func()
+	err := withFileLocked(db, fileID, func(t pgx.Tx, file *repositories.File) error {
+		var err error
+		pruned, err = repositories.NewFileVersionsRepo(db).WithTx(t).Prune(fileID, keep, before)
+		if err != nil {
+			return err
+		}
+		var thumbnails []uuid.UUID
+		for _, v := range pruned {
+			if v.ThumbnailId != nil {
+				thumbnails = append(thumbnails, *v.ThumbnailId)
+			}
+		}
+		deleteThumbnails, err = deleteThumbnailsTx(db, t, thumbnails)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	go func() {
+		deleteThumbnails()
+		for _, v := range pruned {
+			DeleteVersionContent(db, &v)
+		}
+	}()
+	return len(pruned), nil
+}
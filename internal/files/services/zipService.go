@@ -0,0 +1,176 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ZipEntry is an entry of a ZIP archive: a file, or a directory when File is nil.
+type ZipEntry struct {
+	Name string // Slash separated path inside the archive, directories end with a slash.
+	File *repositories.File
+}
+
+// preferredExtensions overrides the extension guessed for common mime types, which has several candidates.
+var preferredExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"text/plain":      ".txt",
+	"video/quicktime": ".mov",
+	"audio/mpeg":      ".mp3",
+}
+
+// zipNamer gives unique names to archive entries, adding ` (1)`, ` (2)`... before the extension of duplicates.
+// Names are compared case-insensitively so the archive extracts the same on every file system.
+type zipNamer map[string]bool
+
+func (n zipNamer) name(dir, base, ext string) string {
+	candidate := path.Join(dir, base+ext)
+	for i := 1; n[strings.ToLower(candidate)]; i++ {
+		candidate = path.Join(dir, fmt.Sprintf("%v (%d)%v", base, i, ext))
+	}
+	n[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// sanitizeEntryName makes a file or folder name safe to use as a single path element of an archive.
+func sanitizeEntryName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "unnamed"
+	}
+	return name
+}
+
+// extensionFor returns the extension of a file deduced from its mime type, for the files stored without one.
+func extensionFor(mimeType string) string {
+	if ext, ok := preferredExtensions[mimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// entryParts splits the name of the entry of a file into its base and extension. Files named without an extension,
+// such as the ones uploaded when only the name before the first dot was kept, get the extension of their mime type.
+func entryParts(f *repositories.File) (string, string) {
+	name := sanitizeEntryName(f.OriginalName)
+	if ext := FullExtension(name); ext != "" {
+		return strings.TrimSuffix(name, ext), ext
+	}
+	return name, extensionFor(f.MimeType)
+}
+
+// ZipEntriesForFiles names the entries of an archive holding files, all at its root.
+func ZipEntriesForFiles(files []repositories.File) []ZipEntry {
+	namer := zipNamer{}
+	entries := make([]ZipEntry, 0, len(files))
+	for i := range files {
+		f := &files[i]
+		base, ext := entryParts(f)
+		entries = append(entries, ZipEntry{
+			Name: namer.name("", base, ext),
+			File: f,
+		})
+	}
+	return entries
+}
+
+// ZipEntriesForFolder names the entries of an archive holding a folder, its sub-folders and the files
+// they contain, leaving out the files in the trash. The folder itself is the root directory of the archive.
+func ZipEntriesForFolder(db *pgxpool.Pool, folder *repositories.Folder) ([]ZipEntry, error) {
+	folders, err := repositories.NewFoldersRepo(db).GetTree(folder.ID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := repositories.NewFilesRepo(db).GetInFolderTree(folder.ID)
+	if err != nil {
+		return nil, err
+	}
+	namer := zipNamer{}
+	// Parents come first, so their directory is always named before their children.
+	dirs := map[string]string{}
+	var entries []ZipEntry
+	for _, f := range folders {
+		parent := ""
+		if f.ParentID != nil {
+			parent = dirs[f.ParentID.String()]
+		}
+		dir := namer.name(parent, sanitizeEntryName(f.Name), "")
+		dirs[f.ID.String()] = dir
+		entries = append(entries, ZipEntry{Name: dir + "/"})
+	}
+	for i := range files {
+		f := &files[i]
+		if f.DeletedAt != nil || f.FolderID == nil {
+			continue
+		}
+		base, ext := entryParts(f)
+		entries = append(entries, ZipEntry{
+			Name: namer.name(dirs[f.FolderID.String()], base, ext),
+			File: f,
+		})
+	}
+	return entries, nil
+}
+
+// WriteZip streams an archive of entries to w, reading each file from the storage backend as it goes.
+// Contents that are already compressed (images, videos, audio, archives) are stored as is, the others deflated.
+//
+// Returns:
+//   - An error if a content can't be read or w fails, the archive is then incomplete.
+func WriteZip(ctx context.Context, w io.Writer, entries []ZipEntry) error {
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.Name}
+		if entry.File == nil {
+			if _, err := zw.CreateHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+		header.Modified = entry.File.UpdatedAt
+		header.Method = zip.Deflate
+		if isCompressed(entry.File.MimeType) {
+			header.Method = zip.Store
+		}
+		dst, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		src, err := boxed.GetInstance().Storage.Get(ctx, entry.File.StoragePath, 0, -1)
+		if err != nil {
+			return fmt.Errorf("content of file %v: %w", entry.File.ID, err)
+		}
+		_, err = io.Copy(dst, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// isCompressed reports whether content of a mime type wouldn't get any smaller by being deflated.
+func isCompressed(mimeType string) bool {
+	switch strings.Split(mimeType, "/")[0] {
+	case "image", "video", "audio":
+		return mimeType != "image/bmp" && mimeType != "image/svg+xml" && mimeType != "audio/wav"
+	}
+	switch mimeType {
+	case "application/zip", "application/gzip", "application/x-7z-compressed", "application/x-rar-compressed",
+		"application/x-bzip2", "application/x-xz", "application/zstd":
+		return true
+	}
+	return false
+}
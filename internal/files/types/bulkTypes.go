@@ -0,0 +1,8 @@
+package types
+
+type BulkFilesRequest struct {
+	Operation string   `json:"operation"` // `delete`, `restore`, `move` or `tag`.
+	Files     []string `json:"files"`     // Uuids of the files to apply it to.
+	Folder    string   `json:"folder"`    // Destination of `move`, empty for the root.
+	Tags      []string `json:"tags"`      // Names of the tags put on the files by `tag`.
+}
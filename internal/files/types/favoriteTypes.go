@@ -0,0 +1,5 @@
+package types
+
+type FavoritesRequest struct {
+	Files []string `json:"files"` // Uuids of the files to star or unstar.
+}
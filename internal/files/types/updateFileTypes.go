@@ -0,0 +1,8 @@
+package types
+
+type UpdateFileRequest struct {
+	Revision    int     `json:"revision"`    // Revision of the file the changes were made on, required.
+	Name        *string `json:"name"`        // The extension of the current name is kept. Omitted to keep the name.
+	Folder      *string `json:"folder"`      // Uuid of the destination folder, empty for the root. Omitted to stay.
+	Description *string `json:"description"` // Omitted to keep the description.
+}
@@ -0,0 +1,10 @@
+package types
+
+type RestoreVersionRequest struct {
+	Version int `json:"version"`
+}
+
+type PruneVersionsRequest struct {
+	Keep      int    `json:"keep"`       // Number of newest versions to keep, 0 for no limit.
+	OlderThan string `json:"older-than"` // Duration such as `720h`, versions uploaded before are deleted. Empty for no limit.
+}
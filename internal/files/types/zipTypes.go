@@ -0,0 +1,6 @@
+package types
+
+type DownloadZipRequest struct {
+	Files  []string `json:"files"`  // Uuids of the files to archive,
+	Folder string   `json:"folder"` // or of a folder to archive with everything below it.
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/folders/services"
+	folderTypes "github.com/David/Boxed/internal/folders/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// CreateFolderController creates a new folder for the authenticated user, either in the root or inside `parent`.
+//
+// Returns:
+//   - Responds with HTTP 201 (Created) and the new folder as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the name or parent are invalid.
+//   - Responds with HTTP 404 (Not Found) if the parent folder doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the parent folder belongs to another user.
+//   - Responds with HTTP 409 (Conflict) if a sibling folder already uses the name.
+func CreateFolderController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	db := boxed.GetInstance().DbConn
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body folderTypes.CreateFolderRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to create a folder.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if err := services.ValidateFolderName(body.Name); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`name` must not be empty, `.`, `..` or contain slashes.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	parentID, err := services.ResolveParent(db, userID, body.Parent)
+	if err != nil {
+		return folderError(c, err, "parent")
+	}
+	folder := &repositories.Folder{
+		ID:        uuid.New(),
+		OwnerID:   userID,
+		ParentID:  parentID,
+		Name:      body.Name,
+		CreatedAt: time.Now(),
+	}
+	if err := repositories.NewFoldersRepo(db).Create(folder); err != nil {
+		if services.IsNameConflict(err) {
+			e := &types.ErrorResponse{
+				Code:    types.FolderAlreadyExists,
+				Message: "A folder with this name already exists here.",
+			}
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while creating the folder. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusCreated, folder)
+}
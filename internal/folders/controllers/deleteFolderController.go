@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/folders/services"
+	"github.com/labstack/echo/v5"
+)
+
+// DeleteFolderController removes the folder identified by the `uuid` header, its sub-folders,
+// every file inside them and the files' thumbnails.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) for successful deletion.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid.
+//   - Responds with HTTP 404 (Not Found) if the folder doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the folder belongs to another user.
+func DeleteFolderController(c *echo.Context) error {
+	folder, err := ownedFolderFromHeader(c)
+	if folder == nil {
+		return err
+	}
+	if err := services.DeleteFolder(boxed.GetInstance().DbConn, folder); err != nil {
+		log.Println("Error while deleting folder:", err)
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while deleting the folder. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
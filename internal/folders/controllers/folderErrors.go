@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/folders/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// folderError translates the errors returned while resolving a folder into a response.
+// field names the request input the folder uuid came from.
+func folderError(c *echo.Context, err error, field string) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("The folder given in `%v` doesn't exist.", field),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	case errors.Is(err, services.ErrFolderNotOwned):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: fmt.Sprintf("This user can't access the folder given in `%v`.", field),
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	case errors.Is(err, services.ErrInvalidFolderID):
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("`%v` provided is not a valid uuid.", field),
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while looking up the folder. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
+
+// ownedFolderFromHeader resolves the folder given in the `uuid` header and checks that the authenticated user owns it.
+// When the returned folder is nil an error response has already been written and the caller must return the error as is.
+func ownedFolderFromHeader(c *echo.Context) (*repositories.Folder, error) {
+	id := c.Request().Header.Get("uuid")
+	if id == "" {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`uuid` must be provided.",
+		}
+		return nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	folderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, folderError(c, services.ErrInvalidFolderID, "uuid")
+	}
+	folder, err := services.GetOwnedFolder(boxed.GetInstance().DbConn, userID, folderID)
+	if err != nil {
+		return nil, folderError(c, err, "uuid")
+	}
+	return folder, nil
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/folders/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// GetFolderController lists the content of a folder: its metadata, its direct sub-folders and its files.
+// When no `uuid` header is provided the user's root is listed instead and `folder` is null.
+// Folders shared with the user, and their sub-folders, can be listed too.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the folder listing as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid.
+//   - Responds with HTTP 404 (Not Found) if the folder doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the folder isn't the user's nor shared with them.
+func GetFolderController(c *echo.Context) error {
+	db := boxed.GetInstance().DbConn
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	// The listed folder may be shared with the user, its content is then listed as its owner's.
+	ownerID := userID
+	var folderID *uuid.UUID
+	folder, err := services.ResolveFolder(db, userID, c.Request().Header.Get("uuid"), shareServices.AccessRead)
+	if err != nil {
+		return folderError(c, err, "uuid")
+	}
+	if folder != nil {
+		ownerID, folderID = folder.OwnerID, &folder.ID
+	}
+	folders, err := repositories.NewFoldersRepo(db).GetChildren(ownerID, folderID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal server error while getting folders, Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	files, err := repositories.NewFilesRepo(db).GetByFolderID(ownerID, folderID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal server error while getting files, Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	content := struct {
+		Folder  *repositories.Folder  `json:"folder"`
+		Folders []repositories.Folder `json:"folders"`
+		Files   []repositories.File   `json:"files"`
+	}{
+		Folder:  folder,
+		Folders: folders,
+		Files:   files,
+	}
+	return c.JSON(http.StatusOK, content)
+}
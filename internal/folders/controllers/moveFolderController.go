@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/folders/services"
+	folderTypes "github.com/David/Boxed/internal/folders/types"
+	"github.com/labstack/echo/v5"
+)
+
+// MoveFolderController moves the folder identified by the `uuid` header inside `parent`, or to the root when `parent` is empty.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated folder as JSON.
+//   - Responds with HTTP 400 (Bad Request) if a uuid is invalid or the folder would end up inside itself.
+//   - Responds with HTTP 404 (Not Found) if either folder doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if either folder belongs to another user.
+//   - Responds with HTTP 409 (Conflict) if the destination already holds a folder with the same name.
+func MoveFolderController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	db := boxed.GetInstance().DbConn
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	folder, err := ownedFolderFromHeader(c)
+	if folder == nil {
+		return err
+	}
+	var body folderTypes.MoveFolderRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to move a folder.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	parentID, err := services.ResolveParent(db, folder.OwnerID, body.Parent)
+	if err != nil {
+		return folderError(c, err, "parent")
+	}
+	if err := services.MoveFolder(db, folder, parentID); err != nil {
+		if errors.Is(err, services.ErrFolderCycle) {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFolderMove,
+				Message: "A folder can't be moved inside itself or one of its sub-folders.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		if services.IsNameConflict(err) {
+			e := &types.ErrorResponse{
+				Code:    types.FolderAlreadyExists,
+				Message: "A folder with this name already exists in the destination.",
+			}
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while moving the folder. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	folder.ParentID = parentID
+	return c.JSON(http.StatusOK, folder)
+}
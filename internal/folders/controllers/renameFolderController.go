@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/folders/services"
+	folderTypes "github.com/David/Boxed/internal/folders/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// RenameFolderController renames the folder identified by the `uuid` header.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the updated folder as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid or the new name are invalid.
+//   - Responds with HTTP 404 (Not Found) if the folder doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the folder belongs to another user.
+//   - Responds with HTTP 409 (Conflict) if a sibling folder already uses the name.
+func RenameFolderController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	folder, err := ownedFolderFromHeader(c)
+	if folder == nil {
+		return err
+	}
+	var body folderTypes.RenameFolderRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to rename a folder.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if err := services.ValidateFolderName(body.Name); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`name` must not be empty, `.`, `..` or contain slashes.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if err := repositories.NewFoldersRepo(boxed.GetInstance().DbConn).Rename(folder.ID, body.Name); err != nil {
+		if services.IsNameConflict(err) {
+			e := &types.ErrorResponse{
+				Code:    types.FolderAlreadyExists,
+				Message: "A folder with this name already exists here.",
+			}
+			return c.JSON(http.StatusConflict, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while renaming the folder. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	folder.Name = body.Name
+	return c.JSON(http.StatusOK, folder)
+}
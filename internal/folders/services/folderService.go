@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	fileServices "github.com/David/Boxed/internal/files/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidFolderName = errors.New("folder name is not valid")
+	ErrInvalidFolderID   = errors.New("folder uuid is not valid")
+	ErrFolderNotOwned    = errors.New("folder is not owned by this user")
+	ErrFolderCycle       = errors.New("a folder can't be moved inside itself")
+)
+
+// ValidateFolderName checks that name can be used as a folder name.
+// Names must not be empty, be "." or "..", nor contain path separators.
+func ValidateFolderName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return ErrInvalidFolderName
+	}
+	return nil
+}
+
+// IsNameConflict reports whether err was caused by a sibling folder already using the same name.
+func IsNameConflict(err error) bool {
+	var pge *pgconn.PgError
+	return errors.As(err, &pge) && pge.Code == "23505"
+}
+
+// GetOwnedFolder retrieves a folder and verifies that it belongs to ownerID.
+//
+// Returns:
+//   - pgx.ErrNoRows if the folder doesn't exist.
+//   - ErrFolderNotOwned if the folder belongs to somebody else.
+func GetOwnedFolder(c *pgxpool.Pool, ownerID, folderID uuid.UUID) (*repositories.Folder, error) {
+	folder, err := repositories.NewFoldersRepo(c).GetByID(folderID)
+	if err != nil {
+		return nil, err
+	}
+	if folder.OwnerID != ownerID {
+		return nil, ErrFolderNotOwned
+	}
+	return folder, nil
+}
+
+// ResolveParent parses an optional folder uuid and verifies its ownership.
+// An empty string means the owner's root and resolves to nil.
+func ResolveParent(c *pgxpool.Pool, ownerID uuid.UUID, raw string) (*uuid.UUID, error) {
+	folder, err := ResolveFolder(c, ownerID, raw, shareServices.AccessOwner)
+	if err != nil || folder == nil {
+		return nil, err
+	}
+	return &folder.ID, nil
+}
+
+// ResolveFolder parses an optional folder uuid and checks that userID has at least the needed access on it,
+// as its owner or through a share. An empty string means the user's root and resolves to a nil folder.
+//
+// Returns:
+//   - ErrInvalidFolderID if raw is not a uuid.
+//   - pgx.ErrNoRows if the folder doesn't exist.
+//   - ErrFolderNotOwned if the user's access is lower than needed.
+func ResolveFolder(c *pgxpool.Pool, userID uuid.UUID, raw string, need shareServices.Access) (*repositories.Folder, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, ErrInvalidFolderID
+	}
+	folder, err := shareServices.AuthorizeFolder(c, userID, id, need)
+	if errors.Is(err, shareServices.ErrForbidden) {
+		return nil, ErrFolderNotOwned
+	}
+	return folder, err
+}
+
+// UploadDestination resolves the optional folder files are uploaded to, which userID must be able to write to.
+// Files uploaded to a shared folder belong to the folder's owner.
+//
+// Returns:
+//   - The owner of the uploaded files and the folder, nil for the user's root.
+//   - The errors of ResolveFolder.
+func UploadDestination(c *pgxpool.Pool, userID uuid.UUID, raw string) (uuid.UUID, *uuid.UUID, error) {
+	folder, err := ResolveFolder(c, userID, raw, shareServices.AccessWrite)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if folder == nil {
+		return userID, nil, nil
+	}
+	return folder.OwnerID, &folder.ID, nil
+}
+
+// MoveFolder changes the parent of a folder, refusing to move it inside its own subtree.
+// The check and the move run in one transaction holding the lock of the owner's folder tree, so concurrent moves
+// can't create a cycle.
+func MoveFolder(c *pgxpool.Pool, folder *repositories.Folder, parentID *uuid.UUID) error {
+	t, err := c.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	repo := repositories.NewFoldersRepo(c).WithTx(t)
+	if err := repo.LockOwner(folder.OwnerID); err != nil {
+		return err
+	}
+	if parentID != nil {
+		inside, err := repo.IsDescendant(*parentID, folder.ID)
+		if err != nil {
+			return err
+		}
+		if inside {
+			return ErrFolderCycle
+		}
+	}
+	if err := repo.Move(folder.ID, parentID); err != nil {
+		return err
+	}
+	return t.Commit(context.Background())
+}
+
+// DeleteFolder removes a folder together with its sub-folders, their files and the files' thumbnails.
+// Database rows are removed inside a single transaction; once it commits the stored content is released.
+func DeleteFolder(c *pgxpool.Pool, folder *repositories.Folder) error {
+	t, err := c.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+
+	files, err := repositories.NewFilesRepo(c).WithTx(t).GetInFolderTree(folder.ID)
+	if err != nil {
+		return err
+	}
+	// The files go first, so their versions and thumbnails are collected before the cascade.
+	cleanup, err := fileServices.DeleteFilesTx(c, t, files)
+	if err != nil {
+		return err
+	}
+	if err := repositories.NewFoldersRepo(c).WithTx(t).Delete(folder.ID); err != nil {
+		return err
+	}
+	if err := t.Commit(context.Background()); err != nil {
+		return err
+	}
+	go cleanup()
+	return nil
+}
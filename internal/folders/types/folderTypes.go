@@ -0,0 +1,14 @@
+package types
+
+type CreateFolderRequest struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent"` // Empty to create the folder in the root.
+}
+
+type RenameFolderRequest struct {
+	Name string `json:"name"`
+}
+
+type MoveFolderRequest struct {
+	Parent string `json:"parent"` // Empty to move the folder to the root.
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// jobTimeout bounds the time a handler can spend on a job, unless it sets its own Timeout.
+	jobTimeout = 10 * time.Minute
+	// Failed jobs are retried after retryBase, doubled on every attempt up to retryMax.
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+	// A claimed job is considered lost staleGrace after its timeout, leaving its worker time to record the outcome.
+	staleGrace = time.Minute
+	// staleCheck is how often the jobs whose worker was lost are looked for.
+	staleCheck = time.Minute
+)
+
+// Handler runs the jobs of a kind.
+type Handler struct {
+	// Run does the work of a job. Returning an error retries the job with backoff, unless it is Permanent.
+	Run func(ctx context.Context, db *pgxpool.Pool, job *repositories.Job) error
+	// Dead, when set, is called once a job has failed for the last time.
+	Dead func(db *pgxpool.Pool, job *repositories.Job, err error)
+	// Timeout, when set, replaces jobTimeout for the jobs of this kind.
+	Timeout time.Duration
+	// Workers, when set, runs the jobs of this kind on that many workers of their own instead of the shared ones,
+	// so long jobs don't hold back the others.
+	Workers int
+}
+
+// timeout returns how long Run may spend on a job.
+func (h Handler) timeout() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+	return jobTimeout
+}
+
+// handlers maps the job kinds to their handler. It is filled by Register before the workers start.
+var handlers = map[string]Handler{}
+
+// wake lets Enqueue start an idle worker right away instead of waiting for the next poll.
+var wake = make(chan struct{}, 1)
+
+// Register sets the handler of a job kind. It must be called before StartWorkers.
+func Register(kind string, h Handler) {
+	handlers[kind] = h
+}
+
+// permanentError marks a failure that retrying won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails at once, without being retried.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Enqueue adds a job of kind to the queue, with payload encoded as JSON.
+// With a repository bound to a transaction the job only becomes runnable once it commits.
+func Enqueue(r *repositories.JobsRepo, kind string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := r.Enqueue(&repositories.Job{Kind: kind, Payload: raw}); err != nil {
+		return err
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// StartWorkers starts workers goroutines running the queued jobs, for as long as the server runs, along with the
+// workers of their own of the kinds setting Handler.Workers.
+// Idle workers look for new jobs every poll, and jobs left running by a crashed server are requeued.
+// A job is considered lost once it has been claimed for the timeout of its kind, plus staleGrace.
+func StartWorkers(db *pgxpool.Pool, workers int, poll time.Duration) {
+	var dedicated []string
+	for kind, h := range handlers {
+		if h.Workers > 0 {
+			dedicated = append(dedicated, kind)
+			for range h.Workers {
+				go work(db, poll, []string{kind}, false)
+			}
+		}
+	}
+	requeueStale(db)
+	go func() {
+		ticker := time.NewTicker(staleCheck)
+		defer ticker.Stop()
+		for range ticker.C {
+			requeueStale(db)
+		}
+	}()
+	for range workers {
+		go work(db, poll, dedicated, true)
+	}
+}
+
+// claimTimeouts returns how long the jobs of each kind may stay claimed before being considered lost.
+func claimTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(handlers))
+	for kind, h := range handlers {
+		timeouts[kind] = h.timeout() + staleGrace
+	}
+	return timeouts
+}
+
+// work runs jobs one after the other, waiting when the queue is empty.
+// It only claims the jobs of kinds, or of the other kinds when exclude is true, see JobsRepo.Claim.
+func work(db *pgxpool.Pool, poll time.Duration, kinds []string, exclude bool) {
+	repo := repositories.NewJobsRepo(db)
+	timeouts := claimTimeouts()
+	for {
+		job, err := repo.Claim(kinds, exclude, timeouts, jobTimeout+staleGrace)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				log.Println("Error while claiming a job:", err)
+			}
+			select {
+			case <-wake:
+			case <-time.After(poll):
+			}
+			continue
+		}
+		runJob(db, repo, job)
+	}
+}
+
+// runJob runs a claimed job and records its outcome: deleted when done, retried later or buried when it failed.
+func runJob(db *pgxpool.Pool, repo *repositories.JobsRepo, job *repositories.Job) {
+	handler, ok := handlers[job.Kind]
+	var err error
+	if !ok {
+		err = Permanent(fmt.Errorf("no handler for jobs of kind %q", job.Kind))
+	} else {
+		err = safeRun(handler, db, job)
+	}
+	if err == nil {
+		if err := repo.Complete(job.ID); err != nil {
+			log.Printf("Couldn't delete done job %v: %v", job.ID, err)
+		}
+		return
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Job %v (%v) failed for good after %d attempt(s): %v", job.ID, job.Kind, job.Attempts, err)
+		if buryErr := repo.Bury(job.ID, err.Error()); buryErr != nil {
+			log.Printf("Couldn't bury job %v: %v", job.ID, buryErr)
+		}
+		if ok && handler.Dead != nil {
+			handler.Dead(db, job, err)
+		}
+		return
+	}
+	if retryErr := repo.Retry(job.ID, time.Now().Add(backoff(job.Attempts)), err.Error()); retryErr != nil {
+		log.Printf("Couldn't requeue job %v: %v", job.ID, retryErr)
+	}
+}
+
+// safeRun runs a job with a timeout, turning a panic of the handler into an error.
+func safeRun(handler Handler, db *pgxpool.Pool, job *repositories.Job) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), handler.timeout())
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler.Run(ctx, db, job)
+}
+
+// backoff returns how long to wait before the next attempt of a job that failed attempts times.
+func backoff(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
+
+// requeueStale puts back in the queue the jobs running past their deadline, whose worker was lost.
+func requeueStale(db *pgxpool.Pool) {
+	n, err := repositories.NewJobsRepo(db).RequeueStale()
+	if err != nil {
+		log.Println("Error while requeuing stale jobs:", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("%d stale jobs requeued", n)
+	}
+}
@@ -4,9 +4,17 @@ import (
 	"strings"
 
 	boxed "github.com/David/Boxed"
+	albums "github.com/David/Boxed/internal/albums/controllers"
+	archives "github.com/David/Boxed/internal/archives/controllers"
 	auth "github.com/David/Boxed/internal/auth/controllers"
 	jwtMiddleware "github.com/David/Boxed/internal/auth/middleware"
 	files "github.com/David/Boxed/internal/files/controllers"
+	folders "github.com/David/Boxed/internal/folders/controllers"
+	sharelinks "github.com/David/Boxed/internal/sharelinks/controllers"
+	shares "github.com/David/Boxed/internal/shares/controllers"
+	tags "github.com/David/Boxed/internal/tags/controllers"
+	uploads "github.com/David/Boxed/internal/uploads/controllers"
+	users "github.com/David/Boxed/internal/users/controllers"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
@@ -21,6 +29,15 @@ func SetupControllers() *echo.Echo {
 	router.GET("/auth/login", auth.LoginController)
 	router.GET("/auth/register", auth.RegisterController)
 	router.GET("/auth/refresh", auth.RefreshTokenController)
+	// tus clients discover the protocol support without credentials.
+	router.OPTIONS("/api/uploads", uploads.OptionsUploadController)
+	router.OPTIONS("/api/uploads/:id", uploads.OptionsUploadController)
+	// Public share links, no account needed.
+	router.GET("/s/:token", sharelinks.ServeShareLinkController)
+	router.GET("/s/:token/thumbnail", sharelinks.ServeShareLinkThumbnailController)
+	// POST lets browsers send the password of protected links in a form body.
+	router.POST("/s/:token", sharelinks.ServeShareLinkController)
+	router.POST("/s/:token/thumbnail", sharelinks.ServeShareLinkThumbnailController)
 
 	jwtMiddleware := jwtMiddleware.NewJwtMiddleware(key, jwt.SigningMethodHS256)
 	validated := router.Group("/api") // Temporarily commented out
@@ -30,8 +47,66 @@ func SetupControllers() *echo.Echo {
 	validated.GET("/get-file", files.GetFileController)
 	validated.GET("/get-files", files.GetFilesController)
 	validated.GET("/serve-file", files.ServeFileController)
+	validated.GET("/serve-file/:uuid", files.ServeFileController)
 	validated.GET("/serve-thumbnail", files.ServeThumbnailController)
+	validated.GET("/serve-thumbnail/:uuid", files.ServeThumbnailController)
+	validated.GET("/stream/:uuid/*", files.ServeStreamController)
+	validated.GET("/video-preview/:uuid/:name", files.ServeVideoPreviewController)
+	validated.GET("/waveform/:uuid", files.GetWaveformController)
+	validated.PATCH("/update-file", files.UpdateFileController)
+	validated.PATCH("/update-file/:uuid", files.UpdateFileController)
 	validated.DELETE("/delete-file", files.DeleteFileController)
+	validated.POST("/download-zip", files.DownloadZipController)
+	validated.POST("/bulk-files", files.BulkFilesController)
+	validated.POST("/extract-archive", archives.ExtractArchiveController)
+	validated.GET("/extraction-jobs", archives.GetExtractionJobsController)
+	validated.GET("/extraction-jobs/:uuid", archives.GetExtractionJobsController)
+	validated.GET("/file-versions", files.GetFileVersionsController)
+	validated.GET("/file-versions/:uuid", files.GetFileVersionsController)
+	validated.GET("/serve-version/:uuid/:version", files.ServeFileVersionController)
+	validated.POST("/restore-version", files.RestoreFileVersionController)
+	validated.DELETE("/prune-versions", files.PruneFileVersionsController)
+	validated.GET("/trash", files.GetTrashController)
+	validated.POST("/restore-file", files.RestoreFileController)
+	validated.DELETE("/purge-file", files.PurgeFileController)
+	validated.POST("/create-folder", folders.CreateFolderController)
+	validated.GET("/get-folder", folders.GetFolderController)
+	validated.PATCH("/rename-folder", folders.RenameFolderController)
+	validated.PATCH("/move-folder", folders.MoveFolderController)
+	validated.DELETE("/delete-folder", folders.DeleteFolderController)
+	validated.POST("/create-share-link", sharelinks.CreateShareLinkController)
+	validated.GET("/share-links", sharelinks.GetShareLinksController)
+	validated.DELETE("/revoke-share-link", sharelinks.RevokeShareLinkController)
+	validated.POST("/share", shares.CreateShareController)
+	validated.GET("/shares", shares.GetSharesController)
+	validated.GET("/shared-with-me", shares.GetSharedWithMeController)
+	validated.DELETE("/unshare", shares.DeleteShareController)
+	validated.GET("/me/usage", users.GetUsageController)
+	validated.GET("/search", files.SearchFilesController)
+	validated.GET("/photos/timeline", files.GetTimelineController)
+	validated.POST("/albums", albums.CreateAlbumController)
+	validated.GET("/albums", albums.GetAlbumsController)
+	validated.GET("/albums/:uuid", albums.GetAlbumController)
+	validated.PATCH("/albums/:uuid", albums.UpdateAlbumController)
+	validated.DELETE("/albums/:uuid", albums.DeleteAlbumController)
+	validated.POST("/albums/:uuid/files", albums.AddAlbumFilesController)
+	validated.DELETE("/albums/:uuid/files", albums.RemoveAlbumFilesController)
+	validated.PUT("/albums/:uuid/order", albums.ReorderAlbumController)
+	validated.POST("/tags", tags.CreateTagController)
+	validated.GET("/tags", tags.GetTagsController)
+	validated.PATCH("/tags/:uuid", tags.RenameTagController)
+	validated.DELETE("/tags/:uuid", tags.DeleteTagController)
+	validated.POST("/tags/:uuid/merge", tags.MergeTagController)
+	validated.POST("/tags/files", tags.TagFilesController)
+	validated.DELETE("/tags/files", tags.UntagFilesController)
+	validated.POST("/favorites", files.AddFavoritesController)
+	validated.DELETE("/favorites", files.RemoveFavoritesController)
+
+	resumable := validated.Group("/uploads", uploads.TusMiddleware)
+	resumable.POST("", uploads.CreateUploadController)
+	resumable.HEAD("/:id", uploads.HeadUploadController)
+	resumable.PATCH("/:id", uploads.PatchUploadController)
+	resumable.DELETE("/:id", uploads.DeleteUploadController)
 	return router
 
 }
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/sharelinks/services"
+	linkTypes "github.com/David/Boxed/internal/sharelinks/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// CreateShareLinkController creates a public link to one of the authenticated user's files.
+// The link can be protected by a password, expire at a given date and allow a limited number of downloads.
+//
+// Returns:
+//   - Responds with HTTP 201 (Created) and the link as JSON, its `Token` completes the `/s/<token>` url.
+//   - Responds with HTTP 400 (Bad Request) if the body is invalid or the file doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the file belongs to another user.
+func CreateShareLinkController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body linkTypes.CreateShareLinkRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to create a link.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	fileID, err := uuid.Parse(body.File)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`file` must be a valid file uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`expires-at` must be in the future.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if body.MaxDownloads != nil && *body.MaxDownloads <= 0 {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`max-downloads` must be a positive number.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	db := boxed.GetInstance().DbConn
+	file, err := repositories.NewFilesRepo(db).GetByID(fileID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: fmt.Sprintf("There is no file with id %v.", fileID),
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while looking up the file. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if file.OwnerID != userID {
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user don't own this file.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	}
+	link, err := services.CreateShareLink(db, file, body.Password, body.ExpiresAt, body.MaxDownloads)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while creating the link. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusCreated, link)
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// GetShareLinksController lists the share links of the authenticated user, newest first.
+// The optional `file` header restricts the list to the links of one file.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the links on success.
+//   - Responds with HTTP 400 (Bad Request) if `file` is not a valid uuid.
+func GetShareLinksController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	var fileID *uuid.UUID
+	if raw := c.Request().Header.Get("file"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`file` provided is not a valid uuid.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		fileID = &id
+	}
+	links, err := repositories.NewShareLinksRepo(boxed.GetInstance().DbConn).GetByOwnerID(userID, fileID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while getting the links. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, links)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// RevokeShareLinkController deletes the share link identified by the `uuid` header, its url stops working at once.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) on success.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid or the link doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the link belongs to another user.
+func RevokeShareLinkController(c *echo.Context) error {
+	id, err := uuid.Parse(c.Request().Header.Get("uuid"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`uuid` must be provided as a valid uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	repo := repositories.NewShareLinksRepo(boxed.GetInstance().DbConn)
+	link, err := repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: "There is no link with this uuid.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while looking up the link. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if link.OwnerID != userID {
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user don't own this link.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	}
+	if err := repo.Delete(link.ID); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while revoking the link. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/internal/sharelinks/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// ServeShareLinkController streams the file of the share link given in the `:token` path parameter.
+// No account is needed; protected links take their password from the `Share-Password` header, or from the
+// `password` field when the link is opened with a POST form.
+//
+// Range and conditional requests are supported as for serve-file. Every response carrying content counts as a download,
+// whatever range it holds, while revalidations answered with 304 (Not Modified) don't.
+//
+// Returns:
+//   - Responds with the file content (HTTP 200, or HTTP 206 for ranges) if successful.
+//   - Responds with HTTP 401 (Unauthorized) if the password is missing or wrong.
+//   - Responds with HTTP 404 (Not Found) if the link doesn't exist.
+//   - Responds with HTTP 410 (Gone) if the link expired or reached its download limit.
+func ServeShareLinkController(c *echo.Context) error {
+	link, file, err := openShareLink(c)
+	if link == nil {
+		return err
+	}
+	db := boxed.GetInstance().DbConn
+	if err := services.UseDownload(db, link, false); err != nil {
+		return shareLinkError(c, err)
+	}
+	if err := fileServices.EnsureFileHash(repositories.NewFilesRepo(db), file); err != nil {
+		log.Printf("Couldn't hash file %v: %v", file.ID, err)
+	}
+	w := &downloadWriter{
+		ResponseWriter: c.Response(),
+		record:         func() error { return services.UseDownload(db, link, true) },
+	}
+	err = fileServices.ServeStoredContent(w, c.Request(), file.StoragePath, file.MimeType, file.ContentHash, file.UpdatedAt, "private, no-cache")
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: "The shared file couldn't be read.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	if w.refused != nil {
+		// Nothing was written yet, drop the headers describing the content.
+		for _, h := range []string{"Content-Length", "Content-Range", "Accept-Ranges", "Content-Type", "ETag", "Last-Modified"} {
+			c.Response().Header().Del(h)
+		}
+		return shareLinkError(c, w.refused)
+	}
+	return nil
+}
+
+// downloadWriter records the download of a share link once the response turns out to carry its content
+// (HTTP 200 or 206), so answers such as 304 (Not Modified) don't use up the downloads of the link.
+// When the download can't be recorded the response is held back and refused is set.
+type downloadWriter struct {
+	http.ResponseWriter
+	record      func() error // Records the download, see services.UseDownload.
+	wroteHeader bool
+	refused     error
+}
+
+func (w *downloadWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if code == http.StatusOK || code == http.StatusPartialContent {
+		if err := w.record(); err != nil {
+			w.refused = err
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *downloadWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.refused != nil {
+		return 0, w.refused
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// ServeShareLinkThumbnailController streams the thumbnail of the file of the share link given in the `:token` path parameter.
+// The link must be usable the same way as for ServeShareLinkController, but thumbnails don't count as downloads.
+// The `size` and `format` query parameters select a variant, like for files.ServeThumbnailController.
+//
+// Returns:
+//   - Responds with the thumbnail (HTTP 200, or HTTP 206 for ranges) if successful.
+//   - Responds with HTTP 400 (Bad Request) if the size or format isn't supported.
+//   - Responds with HTTP 401 (Unauthorized) if the password is missing or wrong.
+//   - Responds with HTTP 404 (Not Found) if the link doesn't exist or the file has no thumbnail.
+//   - Responds with HTTP 410 (Gone) if the link expired.
+func ServeShareLinkThumbnailController(c *echo.Context) error {
+	size, format := c.QueryParam("size"), c.QueryParam("format")
+	if err := fileServices.CheckThumbnailVariant(size, format); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`size` must be small, medium or large and `format` jpeg or webp.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	link, file, err := openShareLink(c)
+	if link == nil {
+		return err
+	}
+	repository := repositories.NewThumbnailRepository(boxed.GetInstance().DbConn)
+	thumbnail, err := repository.GetByID(file.ThumbnailId)
+	if err != nil || thumbnail.StoragePath == "" {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: "The shared file has no thumbnail.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	if err := fileServices.EnsureThumbnailHash(repository, thumbnail); err != nil {
+		log.Printf("Couldn't hash thumbnail %v: %v", thumbnail.ID, err)
+	}
+	variant, err := fileServices.GetThumbnailVariant(c.Request().Context(), boxed.GetInstance().DbConn, thumbnail, size, format)
+	if err != nil {
+		log.Printf("Couldn't get the %v %v variant of thumbnail %v: %v", size, format, thumbnail.ID, err)
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: "The thumbnail of the shared file couldn't be rendered in this size.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	err = fileServices.ServeStoredContent(c.Response(), c.Request(), variant.StoragePath, "image/"+variant.Format, variant.ContentHash, time.Time{}, "private, max-age=86400")
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileFetchFailed,
+			Message: "The thumbnail of the shared file couldn't be read.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	return nil
+}
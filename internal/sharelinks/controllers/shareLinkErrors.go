@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/sharelinks/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// shareLinkError translates the errors returned while opening a share link into a response.
+func shareLinkError(c *echo.Context, err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: "This link doesn't exist or its file was deleted.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	case errors.Is(err, services.ErrLinkExpired), errors.Is(err, services.ErrLinkExhausted):
+		e := &types.ErrorResponse{
+			Code:    types.ShareLinkUnavailable,
+			Message: "This link has expired or reached its download limit.",
+		}
+		return c.JSON(http.StatusGone, &e)
+	case errors.Is(err, services.ErrPasswordRequired):
+		e := &types.ErrorResponse{
+			Code:    types.SharePasswordRequired,
+			Message: "This link is protected, provide its password in the `Share-Password` header or a `password` form field.",
+		}
+		return c.JSON(http.StatusUnauthorized, &e)
+	case errors.Is(err, services.ErrWrongLinkPassword):
+		e := &types.ErrorResponse{
+			Code:    types.SharePasswordInvalid,
+			Message: "The password of this link is wrong.",
+		}
+		return c.JSON(http.StatusUnauthorized, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while opening the link. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
+
+// openShareLink resolves the link given in the `:token` path parameter, with the password from the
+// `Share-Password` header or the `password` field of a POST form. Passwords are never read from the query string,
+// which ends up in access logs and browser history.
+// When the returned link is nil an error response has already been written and the caller must return the error as is.
+func openShareLink(c *echo.Context) (*repositories.ShareLink, *repositories.File, error) {
+	password := c.Request().Header.Get("Share-Password")
+	if password == "" && c.Request().Method == http.MethodPost {
+		password = c.Request().PostFormValue("password")
+	}
+	link, file, err := services.OpenShareLink(boxed.GetInstance().DbConn, c.Param("token"), password)
+	if err != nil {
+		return nil, nil, shareLinkError(c, err)
+	}
+	return link, file, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrLinkExpired       = errors.New("share link has expired")
+	ErrLinkExhausted     = errors.New("share link has reached its download limit")
+	ErrPasswordRequired  = errors.New("share link requires a password")
+	ErrWrongLinkPassword = errors.New("share link password is wrong")
+)
+
+// generateToken returns a random url-safe token of 32 bytes of entropy.
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateShareLink creates a link to a file. An empty password, a nil expiresAt or a nil maxDownloads
+// leave the corresponding restriction out.
+func CreateShareLink(db *pgxpool.Pool, file *repositories.File, password string, expiresAt *time.Time, maxDownloads *int) (*repositories.ShareLink, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	link := &repositories.ShareLink{
+		ID:           uuid.New(),
+		Token:        token,
+		FileID:       file.ID,
+		OwnerID:      file.OwnerID,
+		ExpiresAt:    expiresAt,
+		MaxDownloads: maxDownloads,
+		CreatedAt:    time.Now(),
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		h := string(hash)
+		link.PasswordHash = &h
+	}
+	if err := repositories.NewShareLinksRepo(db).Create(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// OpenShareLink resolves a token into its link and file, checking the expiry and the password.
+//
+// Returns:
+//   - pgx.ErrNoRows if the link doesn't exist, or its file was deleted or moved to the trash.
+//   - ErrLinkExpired, ErrPasswordRequired or ErrWrongLinkPassword if the link can't be used.
+func OpenShareLink(db *pgxpool.Pool, token, password string) (*repositories.ShareLink, *repositories.File, error) {
+	link, err := repositories.NewShareLinksRepo(db).GetByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt) {
+		return nil, nil, ErrLinkExpired
+	}
+	if link.PasswordHash != nil {
+		if password == "" {
+			return nil, nil, ErrPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(*link.PasswordHash), []byte(password)) != nil {
+			return nil, nil, ErrWrongLinkPassword
+		}
+	}
+	file, err := repositories.NewFilesRepo(db).GetByID(link.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, file, nil
+}
+
+// UseDownload checks the download limit of a link before serving its content, recording the download
+// when counted is true. Downloads are recorded once the response is known to carry the content.
+//
+// Returns:
+//   - ErrLinkExhausted when every allowed download has been used.
+func UseDownload(db *pgxpool.Pool, link *repositories.ShareLink, counted bool) error {
+	if counted {
+		ok, err := repositories.NewShareLinksRepo(db).CountDownload(link.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrLinkExhausted
+		}
+		return nil
+	}
+	if link.MaxDownloads != nil && link.DownloadCount >= *link.MaxDownloads {
+		return ErrLinkExhausted
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package types
+
+import "time"
+
+type CreateShareLinkRequest struct {
+	File         string     `json:"file"`
+	Password     string     `json:"password"`      // Empty for a link without password.
+	ExpiresAt    *time.Time `json:"expires-at"`    // RFC 3339 date, omitted for a link that never expires.
+	MaxDownloads *int       `json:"max-downloads"` // Omitted for unlimited downloads.
+}
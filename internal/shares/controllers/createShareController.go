@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/shares/services"
+	shareTypes "github.com/David/Boxed/internal/shares/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// CreateShareController shares one of the authenticated user's files or folders with another user, found by email.
+// Sharing a folder gives access to everything below it. Sharing again with the same user replaces the permission.
+//
+// Returns:
+//   - Responds with HTTP 201 (Created) and the share as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the body is invalid, or the resource or the user doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the resource belongs to another user.
+func CreateShareController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body shareTypes.CreateShareRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide a valid body to share a resource.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if (body.File == "") == (body.Folder == "") {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "Exactly one of `file` and `folder` must be provided.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if body.Email == "" {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`email` of the user to share with must be provided.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+
+	db := boxed.GetInstance().DbConn
+	var fileID, folderID *uuid.UUID
+	field, raw := "file", body.File
+	if body.Folder != "" {
+		field, raw = "folder", body.Folder
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`" + field + "` must be a valid uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	// Only owners can share, a grantee with write access can't pass the resource on.
+	if field == "file" {
+		_, err = services.AuthorizeFile(db, userID, id, services.AccessOwner)
+		fileID = &id
+	} else {
+		_, err = services.AuthorizeFolder(db, userID, id, services.AccessOwner)
+		folderID = &id
+	}
+	if err != nil {
+		return accessError(c, err, "There is no "+field+" with this uuid.")
+	}
+
+	share, err := services.ShareResource(db, userID, fileID, folderID, body.Email, body.Permission)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: "There is no user with this email.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		case errors.Is(err, services.ErrInvalidPermission):
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`permission` must be `read` or `write`.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		case errors.Is(err, services.ErrShareWithSelf):
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "You can't share a resource with yourself.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while sharing the resource. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusCreated, share)
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// DeleteShareController removes the share identified by the `uuid` header.
+// The owner can revoke it and the grantee can leave it.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) on success.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid or the share doesn't exist.
+//   - Responds with HTTP 403 (Forbidden) if the user is neither the owner nor the grantee of the share.
+func DeleteShareController(c *echo.Context) error {
+	id, err := uuid.Parse(c.Request().Header.Get("uuid"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`uuid` must be provided as a valid uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	repo := repositories.NewSharesRepo(boxed.GetInstance().DbConn)
+	share, err := repo.GetByID(id)
+	if err != nil {
+		return accessError(c, err, "There is no share with this uuid.")
+	}
+	if share.OwnerID != userID && share.GranteeID != userID {
+		return accessError(c, services.ErrForbidden, "")
+	}
+	if err := repo.Delete(share.ID); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while removing the share. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// GetSharedWithMeController lists the files and folders other users have shared with the authenticated user,
+// each with the granted permission. The content of a shared folder is listed with the `get-folder` route.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the `files` and `folders` shared with the user.
+func GetSharedWithMeController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	repo := repositories.NewSharesRepo(boxed.GetInstance().DbConn)
+	files, err := repo.GetFilesSharedWith(userID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while getting shared files. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	folders, err := repo.GetFoldersSharedWith(userID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while getting shared folders. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"files":   files,
+		"folders": folders,
+	})
+}
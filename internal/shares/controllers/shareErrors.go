@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/shares/services"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// accessError responds to an error returned while authorizing access to a resource.
+// notFound is the message sent when the resource doesn't exist.
+func accessError(c *echo.Context, err error, notFound string) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: notFound,
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	case errors.Is(err, services.ErrForbidden):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user don't own this resource.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	}
+	e := &types.ErrorResponse{
+		Code:    types.DatabaseError,
+		Message: "Error while looking up the resource. Please try later.",
+	}
+	return c.JSON(http.StatusInternalServerError, &e)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Access is the level of access a user has on a file or folder. Levels are ordered, each one includes the previous.
+type Access int
+
+const (
+	AccessNone  Access = iota
+	AccessRead         // Granted by a `read` share.
+	AccessWrite        // Granted by a `write` share.
+	AccessOwner        // The user owns the resource.
+)
+
+// ErrForbidden is returned when a user doesn't have the access a request needs.
+var ErrForbidden = errors.New("user doesn't have access to this resource")
+
+// accessFromPermissions returns the highest access granted by a list of share permissions.
+func accessFromPermissions(permissions []string) Access {
+	access := AccessNone
+	for _, p := range permissions {
+		switch p {
+		case repositories.PermissionWrite:
+			return AccessWrite
+		case repositories.PermissionRead:
+			access = AccessRead
+		}
+	}
+	return access
+}
+
+// FileAccess returns the access userID has on a file: as its owner, or through shares of the file
+// or of any folder containing it.
+func FileAccess(db *pgxpool.Pool, userID uuid.UUID, file *repositories.File) (Access, error) {
+	if file.OwnerID == userID {
+		return AccessOwner, nil
+	}
+	permissions, err := repositories.NewSharesRepo(db).GetFilePermissions(userID, file.ID)
+	if err != nil {
+		return AccessNone, err
+	}
+	return accessFromPermissions(permissions), nil
+}
+
+// FolderAccess returns the access userID has on a folder: as its owner, or through shares of the folder
+// or of any of its parents.
+func FolderAccess(db *pgxpool.Pool, userID uuid.UUID, folder *repositories.Folder) (Access, error) {
+	if folder.OwnerID == userID {
+		return AccessOwner, nil
+	}
+	permissions, err := repositories.NewSharesRepo(db).GetFolderPermissions(userID, folder.ID)
+	if err != nil {
+		return AccessNone, err
+	}
+	return accessFromPermissions(permissions), nil
+}
+
+// AuthorizeFile retrieves a file and checks that userID has at least the needed access on it.
+//
+// Returns:
+//   - pgx.ErrNoRows if the file doesn't exist or is in the trash.
+//   - ErrForbidden if the user's access is lower than needed.
+func AuthorizeFile(db *pgxpool.Pool, userID, fileID uuid.UUID, need Access) (*repositories.File, error) {
+	file, err := repositories.NewFilesRepo(db).GetByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckFile(db, userID, file, need); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// CheckFile checks that userID has at least the needed access on an already retrieved file.
+// Returns ErrForbidden if the user's access is lower than needed.
+func CheckFile(db *pgxpool.Pool, userID uuid.UUID, file *repositories.File, need Access) error {
+	access, err := FileAccess(db, userID, file)
+	if err != nil {
+		return err
+	}
+	if access < need {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeFolder retrieves a folder and checks that userID has at least the needed access on it.
+//
+// Returns:
+//   - pgx.ErrNoRows if the folder doesn't exist.
+//   - ErrForbidden if the user's access is lower than needed.
+func AuthorizeFolder(db *pgxpool.Pool, userID, folderID uuid.UUID, need Access) (*repositories.Folder, error) {
+	folder, err := repositories.NewFoldersRepo(db).GetByID(folderID)
+	if err != nil {
+		return nil, err
+	}
+	access, err := FolderAccess(db, userID, folder)
+	if err != nil {
+		return nil, err
+	}
+	if access < need {
+		return nil, ErrForbidden
+	}
+	return folder, nil
+}
+
+// AuthorizeThumbnail checks that userID can read a thumbnail: it owns it, or can read the file using it.
+// Returns ErrForbidden otherwise.
+func AuthorizeThumbnail(db *pgxpool.Pool, userID uuid.UUID, thumbnail *repositories.Thumbnail) error {
+	if thumbnail.OwnerId == userID {
+		return nil
+	}
+	fileID, err := repositories.NewFilesRepo(db).GetIDByThumbnailID(thumbnail.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrForbidden
+		}
+		return err
+	}
+	_, err = AuthorizeFile(db, userID, fileID, AccessRead)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrForbidden // The file is in the trash.
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidPermission = errors.New("permission must be `read` or `write`")
+	ErrShareWithSelf     = errors.New("a resource can't be shared with its owner")
+)
+
+// ShareResource gives the user registered with granteeEmail access to a file or a folder of ownerID.
+// Exactly one of fileID and folderID must be set, and the caller must have checked ownerID owns it.
+// Sharing a resource again with the same user only changes the permission.
+//
+// Returns:
+//   - pgx.ErrNoRows if no user is registered with granteeEmail.
+//   - ErrInvalidPermission or ErrShareWithSelf if the share can't be given.
+func ShareResource(db *pgxpool.Pool, ownerID uuid.UUID, fileID, folderID *uuid.UUID, granteeEmail, permission string) (*repositories.Share, error) {
+	if permission != repositories.PermissionRead && permission != repositories.PermissionWrite {
+		return nil, ErrInvalidPermission
+	}
+	grantee, err := repositories.NewUserRepo(db).GetByEmail(granteeEmail)
+	if err != nil {
+		return nil, err
+	}
+	if grantee.ID == ownerID {
+		return nil, ErrShareWithSelf
+	}
+	share := &repositories.Share{
+		OwnerID:    ownerID,
+		GranteeID:  grantee.ID,
+		FileID:     fileID,
+		FolderID:   folderID,
+		Permission: permission,
+		CreatedAt:  time.Now(),
+	}
+	if err := repositories.NewSharesRepo(db).Save(share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
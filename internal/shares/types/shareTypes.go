@@ -0,0 +1,8 @@
+package types
+
+type CreateShareRequest struct {
+	File       string `json:"file"`   // Set to share a file,
+	Folder     string `json:"folder"` // or a folder and everything below it.
+	Email      string `json:"email"`  // Email of the user to share with.
+	Permission string `json:"permission"`
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotExist is returned when a key has no object stored under it.
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Backend stores opaque objects under slash separated keys such as `<user>/<file>.png`.
+// Keys are always relative to the backend; they are what the `storage_path` columns hold.
+type Backend interface {
+	// Put stores size bytes read from r under key, replacing any previous object.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get reads length bytes of an object starting at offset. A negative length reads until the end.
+	Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// Stat describes an object, or returns ErrNotExist.
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// List describes every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// LocalPather is implemented by backends whose objects already live on the local disk,
+// which lets external tools such as ffmpeg read them without a copy.
+type LocalPather interface {
+	Path(key string) (string, error)
+}
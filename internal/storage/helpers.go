@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+)
+
+// filePutter is implemented by backends that can take ownership of a local file more cheaply than a copy.
+type filePutter interface {
+	PutFile(ctx context.Context, key, localPath string) error
+}
+
+// PutFile stores the content of a local file under key. The local file is consumed: it is gone once PutFile succeeds.
+func PutFile(ctx context.Context, b Backend, key, localPath string) error {
+	if fp, ok := b.(filePutter); ok {
+		return fp.PutFile(ctx, key, localPath)
+	}
+	return putFileCopy(ctx, b, key, localPath)
+}
+
+func putFileCopy(ctx context.Context, b Backend, key, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = b.Put(ctx, key, f, info.Size())
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(localPath)
+}
+
+// Fetch makes an object available on the local disk, for tools that can only read files.
+// Backends implementing LocalPather are read in place; otherwise the object is downloaded to a temporary file.
+// The returned cleanup function must always be called once the file is no longer needed.
+func Fetch(ctx context.Context, b Backend, key string) (localPath string, cleanup func(), err error) {
+	if lp, ok := b.(LocalPather); ok {
+		p, err := lp.Path(key)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := os.Stat(p); err != nil {
+			return "", nil, ErrNotExist
+		}
+		return p, func() {}, nil
+	}
+	r, err := b.Get(ctx, key, 0, -1)
+	if err != nil {
+		return "", nil, err
+	}
+	defer r.Close()
+	// Keep the extension, ffmpeg relies on it for some formats.
+	tmp, err := os.CreateTemp("", "boxed-*"+path.Ext(key))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.Remove(tmp.Name()) }
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// DeletePrefix removes every object whose key starts with prefix.
+func DeletePrefix(ctx context.Context, b Backend, prefix string) error {
+	objects, err := b.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, o := range objects {
+		if err := b.Delete(ctx, o.Key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
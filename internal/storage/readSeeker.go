@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+// Open returns a seekable reader over an object, as needed by http.ServeContent.
+// Local files are opened directly; other backends issue a ranged Get whenever the reader is moved.
+func Open(ctx context.Context, b Backend, key string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	info, err := b.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lp, ok := b.(LocalPather); ok {
+		p, err := lp.Path(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, info, nil
+	}
+	return &rangeReader{ctx: ctx, b: b, key: key, size: info.Size}, info, nil
+}
+
+// rangeReader implements io.ReadSeekCloser on top of Backend.Get.
+// The underlying request is only made on the first Read after a Seek, so seeking to
+// compute the size (as http.ServeContent does) costs nothing.
+type rangeReader struct {
+	ctx    context.Context
+	b      Backend
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.b.Get(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("storage: negative position")
+	}
+	if abs != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = abs
+	return abs, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// CreateTagController creates an empty tag for the authenticated user. Tags are also created on the fly when
+// tagging files.
+//
+// Returns:
+//   - Responds with HTTP 201 (Created) and the new tag as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the name is invalid.
+//   - Responds with HTTP 409 (Conflict) if the user already has a tag with this name, ignoring case.
+func CreateTagController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	name, err := tagNameFromBody(c)
+	if name == "" {
+		return err
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	tag := &repositories.Tag{
+		ID:        uuid.New(),
+		OwnerID:   userID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := repositories.NewTagsRepo(boxed.GetInstance().DbConn).Create(tag); err != nil {
+		return tagError(c, err, "")
+	}
+	return c.JSON(http.StatusCreated, tag)
+}
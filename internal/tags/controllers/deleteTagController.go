@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// DeleteTagController deletes the tag given in the `:uuid` path parameter, taking it off every file. The files
+// themselves are left untouched.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) for successful deletion.
+//   - Responds with HTTP 400 (Bad Request) if the uuid is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the tag belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the tag doesn't exist.
+func DeleteTagController(c *echo.Context) error {
+	tag, err := ownedTagFromRequest(c)
+	if tag == nil {
+		return err
+	}
+	if err := repositories.NewTagsRepo(boxed.GetInstance().DbConn).Delete(tag.ID); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while deleting the tag. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// GetTagsController lists the tags of the authenticated user by name, with their number of files.
+// When a `file` query parameter is provided only the tags put on that file are returned.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the tags as JSON.
+//   - Responds with HTTP 400 (Bad Request) if `file` is not a valid uuid.
+func GetTagsController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	repo := repositories.NewTagsRepo(boxed.GetInstance().DbConn)
+	var tags []repositories.Tag
+	if raw := c.QueryParam("file"); raw != "" {
+		fileID, perr := uuid.Parse(raw)
+		if perr != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`file` provided is not a valid uuid.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		tags, err = repo.GetByFileID(userID, fileID)
+	} else {
+		tags, err = repo.GetByOwnerID(userID)
+	}
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while getting the tags. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/tags/services"
+	tagTypes "github.com/David/Boxed/internal/tags/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// MergeTagController merges the tag given in the `:uuid` path parameter into the tag given in `into`: every file
+// of the first one gets the second one, then the first one is deleted.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the tag the files were merged into as JSON.
+//   - Responds with HTTP 400 (Bad Request) if a uuid is invalid or both tags are the same.
+//   - Responds with HTTP 403 (Forbidden) if a tag belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if a tag doesn't exist.
+func MergeTagController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	tag, err := ownedTagFromRequest(c)
+	if tag == nil {
+		return err
+	}
+	var body tagTypes.MergeTagRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the tag to merge `into`.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	intoID, err := uuid.Parse(body.Into)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`into` provided is not a valid uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	db := boxed.GetInstance().DbConn
+	notFound := fmt.Sprintf("There is no tag with id %v.", intoID)
+	into, err := services.GetOwnedTag(db, tag.OwnerID, intoID)
+	if err != nil {
+		return tagError(c, err, notFound)
+	}
+	if err := services.MergeTag(db, tag, into); err != nil {
+		return tagError(c, err, notFound)
+	}
+	merged, err := repositories.NewTagsRepo(db).GetByID(into.ID)
+	if err != nil {
+		return tagError(c, err, notFound)
+	}
+	return c.JSON(http.StatusOK, merged)
+}
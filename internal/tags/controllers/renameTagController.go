@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/repositories"
+	"github.com/labstack/echo/v5"
+)
+
+// RenameTagController renames the tag given in the `:uuid` path parameter, on every file it is put on at once.
+// Use MergeTagController to fold it into another tag.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the renamed tag as JSON.
+//   - Responds with HTTP 400 (Bad Request) if the uuid or the name is invalid.
+//   - Responds with HTTP 403 (Forbidden) if the tag belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if the tag doesn't exist.
+//   - Responds with HTTP 409 (Conflict) if the user already has another tag with this name.
+func RenameTagController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	tag, err := ownedTagFromRequest(c)
+	if tag == nil {
+		return err
+	}
+	name, err := tagNameFromBody(c)
+	if name == "" {
+		return err
+	}
+	repo := repositories.NewTagsRepo(boxed.GetInstance().DbConn)
+	notFound := fmt.Sprintf("There is no tag with id %v.", tag.ID)
+	if err := repo.Rename(tag.ID, name); err != nil {
+		return tagError(c, err, notFound)
+	}
+	updated, err := repo.GetByID(tag.ID)
+	if err != nil {
+		return tagError(c, err, notFound)
+	}
+	return c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/internal/tags/services"
+	tagTypes "github.com/David/Boxed/internal/tags/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// tagError translates the errors returned while resolving or changing a tag into a response.
+// notFound is the message answered for pgx.ErrNoRows.
+func tagError(c *echo.Context, err error, notFound string) error {
+	if ok, err := fileServices.FileSelectionError(c, err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: notFound,
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	case errors.Is(err, services.ErrTagNotOwned):
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user don't own this tag.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	case services.IsNameConflict(err):
+		e := &types.ErrorResponse{
+			Code:    types.TagAlreadyExists,
+			Message: "A tag with this name already exists.",
+		}
+		return c.JSON(http.StatusConflict, &e)
+	case errors.Is(err, services.ErrInvalidTagName):
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "Tag names must not be blank, span several lines nor be longer than 100 characters.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	case errors.Is(err, services.ErrTooManyTags):
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: fmt.Sprintf("At most %d `tags` can be given at once.", services.MaxRequestTags),
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	case errors.Is(err, services.ErrMergeIntoSelf):
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`into` must be another tag.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	default:
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while accessing the tag. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+}
+
+// ownedTagFromRequest resolves the tag given in the `:uuid` path parameter and checks that the authenticated
+// user owns it.
+// When the returned tag is nil an error response has already been written and the caller must return the error as is.
+func ownedTagFromRequest(c *echo.Context) (*repositories.Tag, error) {
+	tagID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`uuid` provided is not a valid uuid.",
+		}
+		return nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	tag, err := services.GetOwnedTag(boxed.GetInstance().DbConn, userID, tagID)
+	if err != nil {
+		return nil, tagError(c, err, fmt.Sprintf("There is no tag with id %v.", tagID))
+	}
+	return tag, nil
+}
+
+// tagNameFromBody reads the `name` of a TagRequest body, trimmed and validated.
+// When the returned name is empty an error response has already been written and the caller must return the error as is.
+func tagNameFromBody(c *echo.Context) (string, error) {
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return "", c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body tagTypes.TagRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the `name` of the tag.",
+		}
+		return "", c.JSON(http.StatusBadRequest, &e)
+	}
+	names, err := services.ParseTagNames([]string{body.Name})
+	if err != nil {
+		return "", tagError(c, err, "")
+	}
+	return names[0], nil
+}
+
+// tagFilesFromBody reads the `files` and `tags` of a TagFilesRequest body, which must both hold at least one entry.
+// When the returned ids are nil an error response has already been written and the caller must return the error as is.
+func tagFilesFromBody(c *echo.Context) ([]uuid.UUID, []string, error) {
+	if c.Request().Header.Get("Content-Type") != "application/json" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/json`",
+		}
+		return nil, nil, c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	var body tagTypes.TagFilesRequest
+	if err := echo.BindBody(c, &body); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "No body provided. Please provide the `files` and the `tags`.",
+		}
+		return nil, nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	if len(body.Files) == 0 || len(body.Tags) == 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`files` and `tags` must each hold at least one entry.",
+		}
+		return nil, nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	fileIDs, err := fileServices.ParseFileIDs(body.Files)
+	if err != nil {
+		return nil, nil, tagError(c, err, "")
+	}
+	names, err := services.ParseTagNames(body.Tags)
+	if err != nil {
+		return nil, nil, tagError(c, err, "")
+	}
+	return fileIDs, names, nil
+}
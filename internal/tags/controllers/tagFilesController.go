@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/tags/services"
+	"github.com/labstack/echo/v5"
+)
+
+// TagFilesController puts every tag of `tags` on every file of `files`, creating the tags the authenticated user
+// doesn't have yet. Files that already have a tag keep it. Nothing is tagged if one of the files can't be.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) when the files are tagged.
+//   - Responds with HTTP 400 (Bad Request) if a file uuid or a tag name is invalid.
+//   - Responds with HTTP 403 (Forbidden) if a file belongs to another user.
+//   - Responds with HTTP 404 (Not Found) if a file doesn't exist or is in the trash.
+func TagFilesController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	fileIDs, names, err := tagFilesFromBody(c)
+	if fileIDs == nil {
+		return err
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if err := services.TagFiles(boxed.GetInstance().DbConn, userID, fileIDs, names); err != nil {
+		return tagError(c, err, "One of the `files` doesn't exist or is in the trash.")
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	fileServices "github.com/David/Boxed/internal/files/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxTagName is the maximum length of a tag name, in characters.
+	maxTagName = 100
+	// MaxRequestTags is the maximum number of tags that can be given in a single request.
+	MaxRequestTags = 100
+)
+
+var (
+	ErrInvalidTagName = errors.New("tag name is not valid")
+	ErrTooManyTags    = errors.New("too many tags in a single request")
+	ErrTagNotOwned    = errors.New("tag is not owned by this user")
+	ErrMergeIntoSelf  = errors.New("a tag can't be merged into itself")
+)
+
+// ValidateTagName checks that name can be used as a tag name: not blank, at most 100 characters and on a single line.
+func ValidateTagName(name string) error {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxTagName || strings.ContainsAny(name, "\r\n") {
+		return ErrInvalidTagName
+	}
+	return nil
+}
+
+// ParseTagNames trims the tag names of a request and drops duplicates, ignoring case but keeping the order.
+//
+// Returns:
+//   - ErrInvalidTagName if one of them can't be used as a tag name.
+//   - ErrTooManyTags if there are more than MaxRequestTags.
+func ParseTagNames(raw []string) ([]string, error) {
+	if len(raw) > MaxRequestTags {
+		return nil, ErrTooManyTags
+	}
+	names := make([]string, 0, len(raw))
+	seen := map[string]bool{}
+	for _, r := range raw {
+		name := strings.TrimSpace(r)
+		if err := ValidateTagName(name); err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// IsNameConflict reports whether err comes from the user already having a tag with the same name.
+func IsNameConflict(err error) bool {
+	var pge *pgconn.PgError
+	return errors.As(err, &pge) && pge.Code == "23505"
+}
+
+// GetOwnedTag retrieves a tag and verifies that it belongs to ownerID, tags aren't shared.
+//
+// Returns:
+//   - pgx.ErrNoRows if the tag doesn't exist.
+//   - ErrTagNotOwned if the tag belongs to somebody else.
+func GetOwnedTag(db *pgxpool.Pool, ownerID, tagID uuid.UUID) (*repositories.Tag, error) {
+	tag, err := repositories.NewTagsRepo(db).GetByID(tagID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.OwnerID != ownerID {
+		return nil, ErrTagNotOwned
+	}
+	return tag, nil
+}
+
+// MergeTag puts the tag into on every file of tag, then deletes tag. Both must belong to the same user.
+//
+// Returns:
+//   - ErrMergeIntoSelf if both tags are the same.
+//   - ErrTagNotOwned if into belongs to somebody else.
+func MergeTag(db *pgxpool.Pool, tag *repositories.Tag, into *repositories.Tag) error {
+	if tag.ID == into.ID {
+		return ErrMergeIntoSelf
+	}
+	if tag.OwnerID != into.OwnerID {
+		return ErrTagNotOwned
+	}
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	if err := repositories.NewTagsRepo(db).WithTx(t).Merge(tag.ID, into.ID); err != nil {
+		return err
+	}
+	return t.Commit(context.Background())
+}
+
+// TagFiles puts the tags named names on files of ownerID, creating the tags the user doesn't have yet.
+//
+// Returns:
+//   - The errors of fileServices.CheckOwnedFiles if a file can't be tagged.
+func TagFiles(db *pgxpool.Pool, ownerID uuid.UUID, fileIDs []uuid.UUID, names []string) error {
+	if err := fileServices.CheckOwnedFiles(db, ownerID, fileIDs); err != nil {
+		return err
+	}
+	t, err := db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rollbackErr := t.Rollback(context.Background()); rollbackErr != nil && rollbackErr != pgx.ErrTxClosed {
+			log.Printf("Rollback failed: %v", rollbackErr)
+		}
+	}()
+	repo := repositories.NewTagsRepo(db).WithTx(t)
+	tagIDs, err := repo.EnsureByNames(ownerID, names)
+	if err != nil {
+		return err
+	}
+	tagged, err := repo.TagFiles(fileIDs, tagIDs)
+	if err != nil {
+		return err
+	}
+	if len(tagged) != len(fileIDs) {
+		// A file was trashed or deleted since it was checked.
+		return pgx.ErrNoRows
+	}
+	return t.Commit(context.Background())
+}
+
+// UntagFiles takes the tags named names off files of ownerID. Names the user has no tag for are skipped.
+//
+// Returns:
+//   - The errors of fileServices.CheckOwnedFiles if a file can't be untagged.
+func UntagFiles(db *pgxpool.Pool, ownerID uuid.UUID, fileIDs []uuid.UUID, names []string) error {
+	if err := fileServices.CheckOwnedFiles(db, ownerID, fileIDs); err != nil {
+		return err
+	}
+	repo := repositories.NewTagsRepo(db)
+	tagIDs, err := repo.GetIDsByNames(ownerID, names)
+	if err != nil || len(tagIDs) == 0 {
+		return err
+	}
+	return repo.UntagFiles(fileIDs, tagIDs)
+}
@@ -0,0 +1,14 @@
+package types
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+type MergeTagRequest struct {
+	Into string `json:"into"` // Uuid of the tag taking over the files, which is kept.
+}
+
+type TagFilesRequest struct {
+	Files []string `json:"files"` // Uuids of the files to tag or untag.
+	Tags  []string `json:"tags"`  // Names of the tags, ignoring case. Missing tags are created when tagging.
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	folderServices "github.com/David/Boxed/internal/folders/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/internal/uploads/services"
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// CreateUploadController starts a resumable upload (tus `creation` extension).
+// The file is described by the `Upload-Length` header and the `Upload-Metadata` header,
+// which accepts the `filename`, `filetype`, `folder` and `version-of` keys. With `version-of` the upload
+// becomes a new version of that file once finalized.
+//
+// Returns:
+//   - Responds with HTTP 201 (Created) and the upload url in the `Location` header.
+//   - Responds with HTTP 400 (Bad Request) if the length, metadata, folder or file are invalid.
+//   - Responds with HTTP 403 (Forbidden) if the user can't write to the folder or file.
+//   - Responds with HTTP 413 (Request Entity Too Large) if the file would exceed the owner's storage quota.
+func CreateUploadController(c *echo.Context) error {
+	db := boxed.GetInstance().DbConn
+	length, err := strconv.ParseInt(c.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`Upload-Length` must be provided as a positive integer. Deferred lengths are not supported.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	metadata, err := services.ParseMetadata(c.Request().Header.Get("Upload-Metadata"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: err.Error(),
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	if metadata["filename"] == "" {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`Upload-Metadata` must contain a `filename` entry.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	mimeType := metadata["filetype"]
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	// The destination can be a folder shared with write access.
+	ownerID, folderID, err := folderServices.UploadDestination(db, userID, metadata["folder"])
+	if err != nil {
+		if errors.Is(err, folderServices.ErrFolderNotOwned) {
+			e := &types.ErrorResponse{
+				Code:    types.WrongOwner,
+				Message: "This user can't upload to the folder given in `folder`.",
+			}
+			return c.JSON(http.StatusForbidden, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "`folder` provided is not a valid folder uuid.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	// File receiving a new version, nil for a new file.
+	var versionOf *uuid.UUID
+	if raw := metadata["version-of"]; raw != "" {
+		fileID, err := uuid.Parse(raw)
+		if err != nil {
+			e := &types.ErrorResponse{
+				Code:    types.InvalidFields,
+				Message: "`version-of` provided is not a valid file uuid.",
+			}
+			return c.JSON(http.StatusBadRequest, &e)
+		}
+		file, err := shareServices.AuthorizeFile(db, userID, fileID, shareServices.AccessWrite)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				e := &types.ErrorResponse{
+					Code:    types.ResourceNotFound,
+					Message: fmt.Sprintf("There is no file with id %v to add a version to.", fileID),
+				}
+				return c.JSON(http.StatusBadRequest, &e)
+			}
+			if errors.Is(err, shareServices.ErrForbidden) {
+				e := &types.ErrorResponse{
+					Code:    types.WrongOwner,
+					Message: "This user can't write to the file given in `version-of`.",
+				}
+				return c.JSON(http.StatusForbidden, &e)
+			}
+			e := &types.ErrorResponse{
+				Code:    types.DatabaseError,
+				Message: "Error while looking up the file. Please try later.",
+			}
+			return c.JSON(http.StatusInternalServerError, &e)
+		}
+		versionOf = &file.ID
+		ownerID = file.OwnerID
+	}
+	// Checked again once every byte is received, as other uploads may have completed in between.
+	if err := userServices.CheckQuota(db, ownerID, length); err != nil {
+		return userServices.QuotaError(c, err)
+	}
+	upload, err := services.CreateUpload(db, userID, folderID, versionOf, fileServices.FileInfo{
+		Filename: metadata["filename"],
+		MimeType: mimeType,
+		Size:     length,
+	})
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.FileUploadFailed,
+			Message: "Error while creating the upload. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	c.Response().Header().Set("Location", fmt.Sprintf("/api/uploads/%v", upload.ID))
+	c.Response().Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	// Empty files are complete as soon as they are created.
+	if upload.UploadLength == 0 {
+		return finalize(c, upload, http.StatusCreated)
+	}
+	return c.NoContent(http.StatusCreated)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/uploads/services"
+	"github.com/labstack/echo/v5"
+)
+
+// DeleteUploadController discards a resumable upload and the data received so far (tus `termination` extension).
+//
+// Returns:
+//   - Responds with HTTP 204 (No Content) once the upload is gone.
+//   - Responds with HTTP 423 (Locked) if a chunk is currently being written.
+func DeleteUploadController(c *echo.Context) error {
+	upload, err := ownedUploadFromParam(c)
+	if upload == nil {
+		return err
+	}
+	unlock, ok := services.LockUpload(upload.ID)
+	if !ok {
+		e := &types.ErrorResponse{
+			Code:    types.UploadLocked,
+			Message: "A chunk is being written to this upload, try again once it's done.",
+		}
+		return c.JSON(http.StatusLocked, &e)
+	}
+	defer unlock()
+	if err := services.TerminateUpload(boxed.GetInstance().DbConn, upload); err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceDeleteFailed,
+			Message: "Internal error while deleting the upload. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+// HeadUploadController reports how many bytes of a resumable upload have been received.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the `Upload-Offset` and `Upload-Length` headers.
+//   - Responds with HTTP 404 (Not Found) if the upload doesn't exist anymore.
+//   - Responds with HTTP 403 (Forbidden) if the upload belongs to another user.
+func HeadUploadController(c *echo.Context) error {
+	upload, err := ownedUploadFromParam(c)
+	if upload == nil {
+		return err
+	}
+	setUploadHeaders(c, upload)
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/David/Boxed/internal/uploads/services"
+	"github.com/labstack/echo/v5"
+)
+
+// OptionsUploadController describes the tus protocol support of the server.
+//
+// Returns:
+//   - Responds with HTTP 204 (No Content) and the `Tus-Version` and `Tus-Extension` headers.
+func OptionsUploadController(c *echo.Context) error {
+	h := c.Response().Header()
+	h.Set("Tus-Resumable", services.TusVersion)
+	h.Set("Tus-Version", services.TusVersion)
+	h.Set("Tus-Extension", services.TusExtensions)
+	return c.NoContent(http.StatusNoContent)
+}
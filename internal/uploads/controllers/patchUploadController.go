@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	"github.com/David/Boxed/internal/uploads/services"
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// PatchUploadController writes a chunk of a resumable upload, starting at the `Upload-Offset` header.
+// Once every byte has been received the upload is finalized into a regular file,
+// whose uuid is returned in the `Boxed-File-Id` header.
+// Sending an empty chunk at the end of a complete upload retries a failed finalization.
+//
+// Returns:
+//   - Responds with HTTP 204 (No Content) and the new `Upload-Offset` header.
+//   - Responds with HTTP 403 (Forbidden) or 404 (Not Found) if the file receiving a version can't be written to anymore.
+//   - Responds with HTTP 413 (Request Entity Too Large) if the content doesn't fit in the owner's quota anymore.
+//   - Responds with HTTP 409 (Conflict) if `Upload-Offset` doesn't match the received bytes.
+//   - Responds with HTTP 413 (Request Entity Too Large) if the chunk goes past `Upload-Length`, nothing of it is kept.
+//   - Responds with HTTP 415 (Unsupported Media Type) if the Content-Type isn't `application/offset+octet-stream`.
+//   - Responds with HTTP 423 (Locked) if another request is writing to the same upload.
+func PatchUploadController(c *echo.Context) error {
+	defer c.Request().Body.Close()
+	db := boxed.GetInstance().DbConn
+	if c.Request().Header.Get("Content-Type") != "application/offset+octet-stream" {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFormat,
+			Message: "The Content-Type request must be `application/offset+octet-stream`",
+		}
+		return c.JSON(http.StatusUnsupportedMediaType, &e)
+	}
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		e := &types.ErrorResponse{
+			Code:    types.MissingFields,
+			Message: "`Upload-Offset` must be provided as a positive integer.",
+		}
+		return c.JSON(http.StatusBadRequest, &e)
+	}
+	upload, err := ownedUploadFromParam(c)
+	if upload == nil {
+		return err
+	}
+	unlock, ok := services.LockUpload(upload.ID)
+	if !ok {
+		e := &types.ErrorResponse{
+			Code:    types.UploadLocked,
+			Message: "Another request is already writing to this upload.",
+		}
+		return c.JSON(http.StatusLocked, &e)
+	}
+	defer unlock()
+	// Reload now that the lock is held; a concurrent request may have moved the offset.
+	upload, err = repositories.NewUploadsRepo(db).GetByID(upload.ID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: "The upload doesn't exist anymore.",
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	// Chunks announcing more bytes than left are refused before reading them.
+	if size := c.Request().ContentLength; size > upload.UploadLength-offset && offset == upload.UploadOffset {
+		err = services.ErrChunkTooLarge
+	} else {
+		_, err = services.WriteChunk(db, upload, offset, c.Request().Body)
+	}
+	setUploadHeaders(c, upload)
+	if err != nil {
+		if errors.Is(err, services.ErrChunkTooLarge) {
+			e := &types.ErrorResponse{
+				Code:    types.UploadChunkTooLarge,
+				Message: "The chunk goes past `Upload-Length`, nothing of it was kept.",
+			}
+			return c.JSON(http.StatusRequestEntityTooLarge, &e)
+		}
+		if errors.Is(err, services.ErrOffsetMismatch) {
+			e := &types.ErrorResponse{
+				Code:    types.UploadOffsetMismatch,
+				Message: "`Upload-Offset` doesn't match the bytes received so far, check them with a HEAD request.",
+			}
+			return c.JSON(http.StatusConflict, &e)
+		}
+		log.Printf("Error while writing to upload %v: %v", upload.ID, err)
+		e := &types.ErrorResponse{
+			Code:    types.FileUploadFailed,
+			Message: "The chunk couldn't be completely written, resume from `Upload-Offset`.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	if upload.UploadOffset == upload.UploadLength {
+		return finalize(c, upload, http.StatusNoContent)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// finalize turns a complete upload into a file and responds with status.
+func finalize(c *echo.Context, upload *repositories.Upload, status int) error {
+	fileId, err := services.FinalizeUpload(boxed.GetInstance().DbConn, upload)
+	if errors.Is(err, userServices.ErrQuotaExceeded) {
+		return userServices.QuotaError(c, err)
+	}
+	if upload.VersionOf != nil && errors.Is(err, pgx.ErrNoRows) {
+		e := &types.ErrorResponse{
+			Code:    types.ResourceNotFound,
+			Message: fmt.Sprintf("There is no file with id %v to add a version to.", *upload.VersionOf),
+		}
+		return c.JSON(http.StatusNotFound, &e)
+	}
+	if upload.VersionOf != nil && errors.Is(err, shareServices.ErrForbidden) {
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user can't write to the file receiving this upload anymore.",
+		}
+		return c.JSON(http.StatusForbidden, &e)
+	}
+	if err != nil {
+		log.Printf("Error while finalizing upload %v: %v", upload.ID, err)
+		e := &types.ErrorResponse{
+			Code:    types.FileUploadFailed,
+			Message: "Every byte was received but the file couldn't be saved. Retry with an empty PATCH, or start over if the upload is gone.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	c.Response().Header().Set("Boxed-File-Id", fileId.String())
+	return c.NoContent(status)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/uploads/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v5"
+)
+
+// TusMiddleware adds the `Tus-Resumable` header to every response and rejects
+// requests made with a tus protocol version the server doesn't support.
+// OPTIONS requests are exempt, as clients use them to discover the supported versions.
+func TusMiddleware(n echo.HandlerFunc) echo.HandlerFunc {
+	return func(c *echo.Context) error {
+		c.Response().Header().Set("Tus-Resumable", services.TusVersion)
+		if c.Request().Method != http.MethodOptions && c.Request().Header.Get("Tus-Resumable") != services.TusVersion {
+			c.Response().Header().Set("Tus-Version", services.TusVersion)
+			e := &types.ErrorResponse{
+				Code:    types.TusVersionUnsupported,
+				Message: fmt.Sprintf("`Tus-Resumable: %v` must be provided.", services.TusVersion),
+			}
+			return c.JSON(http.StatusPreconditionFailed, &e)
+		}
+		return n(c)
+	}
+}
+
+// ownedUploadFromParam resolves the upload given in the `id` path parameter and checks that the authenticated user owns it.
+// When the returned upload is nil an error response has already been written and the caller must return the error as is.
+func ownedUploadFromParam(c *echo.Context) (*repositories.Upload, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InvalidFields,
+			Message: "The upload id provided is not valid.",
+		}
+		return nil, c.JSON(http.StatusBadRequest, &e)
+	}
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	upload, err := repositories.NewUploadsRepo(boxed.GetInstance().DbConn).GetByID(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Finalized, terminated or expired uploads are all gone for the client.
+			e := &types.ErrorResponse{
+				Code:    types.ResourceNotFound,
+				Message: fmt.Sprintf("No upload in progress with id: %v", id),
+			}
+			return nil, c.JSON(http.StatusNotFound, &e)
+		}
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Internal error while getting the upload, please try later.",
+		}
+		return nil, c.JSON(http.StatusInternalServerError, &e)
+	}
+	if upload.OwnerID != userID {
+		e := &types.ErrorResponse{
+			Code:    types.WrongOwner,
+			Message: "This user don't own this upload.",
+		}
+		return nil, c.JSON(http.StatusForbidden, &e)
+	}
+	return upload, nil
+}
+
+// setUploadHeaders writes the headers describing the state of an upload.
+func setUploadHeaders(c *echo.Context, upload *repositories.Upload) {
+	h := c.Response().Header()
+	h.Set("Upload-Offset", fmt.Sprint(upload.UploadOffset))
+	h.Set("Upload-Length", fmt.Sprint(upload.UploadLength))
+	h.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	h.Set("Cache-Control", "no-store")
+}
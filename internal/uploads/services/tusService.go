@@ -0,0 +1,255 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	boxed "github.com/David/Boxed"
+	fileServices "github.com/David/Boxed/internal/files/services"
+	folderServices "github.com/David/Boxed/internal/folders/services"
+	shareServices "github.com/David/Boxed/internal/shares/services"
+	userServices "github.com/David/Boxed/internal/users/services"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// TusVersion is the only tus protocol version supported by the server.
+	TusVersion = "1.0.0"
+	// TusExtensions lists the tus protocol extensions implemented by the server.
+	TusExtensions = "creation,expiration,termination"
+)
+
+var (
+	ErrOffsetMismatch = errors.New("upload offset doesn't match the stored offset")
+	ErrUploadBusy     = errors.New("another request is already writing to this upload")
+	ErrChunkTooLarge  = errors.New("chunk goes past the length of the upload")
+)
+
+// locks serializes the requests writing to the same upload.
+var locks sync.Map
+
+// LockUpload acquires the write lock of an upload without blocking.
+// It returns false if another request already holds it; otherwise the caller must call the returned unlock function.
+func LockUpload(id uuid.UUID) (unlock func(), ok bool) {
+	m, _ := locks.LoadOrStore(id, &sync.Mutex{})
+	mutex := m.(*sync.Mutex)
+	if !mutex.TryLock() {
+		return nil, false
+	}
+	return mutex.Unlock, true
+}
+
+// ParseMetadata decodes a tus `Upload-Metadata` header: comma separated `key base64(value)` pairs.
+func ParseMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		switch len(parts) {
+		case 1:
+			metadata[parts[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("metadata `%v` is not base64 encoded: %w", parts[0], err)
+			}
+			metadata[parts[0]] = string(value)
+		default:
+			return nil, fmt.Errorf("malformed metadata pair: %q", pair)
+		}
+	}
+	return metadata, nil
+}
+
+// CreateUpload registers a new resumable upload and creates the empty file its chunks will be written to.
+// A non nil versionOf makes the upload a new version of that file instead of a new file in folder.
+func CreateUpload(db *pgxpool.Pool, ownerID uuid.UUID, folder, versionOf *uuid.UUID, file fileServices.FileInfo) (*repositories.Upload, error) {
+	now := time.Now()
+	upload := &repositories.Upload{
+		ID:           uuid.New(),
+		OwnerID:      ownerID,
+		FolderID:     folder,
+		VersionOf:    versionOf,
+		Filename:     file.Filename,
+		MimeType:     file.MimeType,
+		UploadLength: file.Size,
+		ExpiresAt:    now.Add(boxed.GetInstance().UploadExpiration),
+		CreatedAt:    now,
+	}
+	upload.TempPath = path.Join(boxed.GetInstance().FolderPath, ".uploads", upload.ID.String())
+	if err := os.MkdirAll(filepath.Dir(upload.TempPath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(upload.TempPath)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	if err := repositories.NewUploadsRepo(db).Create(upload); err != nil {
+		os.Remove(upload.TempPath)
+		return nil, err
+	}
+	return upload, nil
+}
+
+// WriteChunk appends the content of body to an upload, starting at offset.
+// The stored offset is updated with whatever was written, even when reading body fails midway,
+// so the client can resume from there.
+//
+// A chunk going past the length of the upload is refused whole: the offset is left as it was, and the bytes written
+// after it are overwritten by the next chunk.
+//
+// Returns:
+//   - The new offset of the upload.
+//   - ErrOffsetMismatch if offset isn't the current offset of the upload.
+//   - ErrChunkTooLarge if body holds more than the bytes left to upload.
+func WriteChunk(db *pgxpool.Pool, upload *repositories.Upload, offset int64, body io.Reader) (int64, error) {
+	if offset != upload.UploadOffset {
+		return upload.UploadOffset, ErrOffsetMismatch
+	}
+	f, err := os.OpenFile(upload.TempPath, os.O_WRONLY, 0644)
+	if err != nil {
+		return upload.UploadOffset, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return upload.UploadOffset, err
+	}
+	written, copyErr := io.Copy(f, io.LimitReader(body, upload.UploadLength-offset))
+	if copyErr == nil {
+		if n, _ := io.ReadFull(body, make([]byte, 1)); n > 0 {
+			return upload.UploadOffset, ErrChunkTooLarge
+		}
+	}
+	upload.UploadOffset += written
+	upload.ExpiresAt = time.Now().Add(boxed.GetInstance().UploadExpiration)
+	if err := repositories.NewUploadsRepo(db).UpdateProgress(upload.ID, upload.UploadOffset, upload.ExpiresAt); err != nil {
+		return upload.UploadOffset, err
+	}
+	return upload.UploadOffset, copyErr
+}
+
+// FinalizeUpload turns a completed upload into a regular file, or into a new version of the file it targets.
+// The data is stored as a blob and goes through the same path as multipart uploads,
+// which saves the metadata and generates the thumbnail.
+// If storing the data fails the upload is kept so finalizing can be retried; if the metadata
+// can't be saved the upload is discarded, as its data has already been handed over to the blob.
+//
+// Returns:
+//   - The uuid of the new file, or of the file that received the version.
+//   - userServices.ErrQuotaExceeded if the content no longer fits in the owner's quota, the upload is then discarded.
+func FinalizeUpload(db *pgxpool.Pool, upload *repositories.Upload) (uuid.UUID, error) {
+	if upload.UploadOffset != upload.UploadLength {
+		return uuid.Nil, fmt.Errorf("upload %v is not complete", upload.ID)
+	}
+	// Access to the target may have been revoked since the upload was created.
+	ownerID, folderID := upload.OwnerID, upload.FolderID
+	if upload.VersionOf != nil {
+		file, err := shareServices.AuthorizeFile(db, upload.OwnerID, *upload.VersionOf, shareServices.AccessWrite)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		ownerID = file.OwnerID
+	} else if upload.FolderID != nil {
+		var err error
+		ownerID, folderID, err = folderServices.UploadDestination(db, upload.OwnerID, upload.FolderID.String())
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	// The upload is discarded when it no longer fits, keeping it wouldn't help.
+	if err := userServices.CheckQuota(db, ownerID, upload.UploadLength); err != nil {
+		if errors.Is(err, userServices.ErrQuotaExceeded) {
+			if terminateErr := TerminateUpload(db, upload); terminateErr != nil {
+				log.Printf("Couldn't discard upload %v: %v", upload.ID, terminateErr)
+			}
+		}
+		return uuid.Nil, err
+	}
+	info := fileServices.FileInfo{
+		Filename: upload.Filename,
+		MimeType: upload.MimeType,
+		Size:     upload.UploadLength,
+	}
+	hash, err := fileServices.HashFile(upload.TempPath)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	blob, err := fileServices.StoreBlob(db, upload.TempPath, hash, upload.UploadLength)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	fileId := uuid.New()
+	if upload.VersionOf != nil {
+		fileId = *upload.VersionOf
+		_, err = fileServices.RegisterFileVersion(db, info, fileId, blob)
+	} else {
+		err = fileServices.RegisterStoredFile(db, ownerID, info, fileId, folderID, blob)
+	}
+	if err != nil {
+		if terminateErr := TerminateUpload(db, upload); terminateErr != nil {
+			log.Printf("Couldn't discard upload %v: %v", upload.ID, terminateErr)
+		}
+		return uuid.Nil, err
+	}
+	if err := repositories.NewUploadsRepo(db).Delete(upload.ID); err != nil {
+		log.Printf("Upload %v finalized but its row couldn't be deleted: %v", upload.ID, err)
+	}
+	locks.Delete(upload.ID)
+	return fileId, nil
+}
+
+// TerminateUpload discards an upload and the data received so far.
+func TerminateUpload(db *pgxpool.Pool, upload *repositories.Upload) error {
+	if err := repositories.NewUploadsRepo(db).Delete(upload.ID); err != nil {
+		return err
+	}
+	if err := os.Remove(upload.TempPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Couldn't remove the data of upload %v: %v", upload.ID, err)
+	}
+	locks.Delete(upload.ID)
+	return nil
+}
+
+// ReapExpiredUploads terminates every upload that hasn't received data before its expiration date.
+func ReapExpiredUploads(db *pgxpool.Pool) {
+	expired, err := repositories.NewUploadsRepo(db).GetExpired(time.Now())
+	if err != nil {
+		log.Println("Error while getting expired uploads:", err)
+		return
+	}
+	for _, upload := range expired {
+		unlock, ok := LockUpload(upload.ID)
+		if !ok {
+			continue // Still being written to.
+		}
+		if err := TerminateUpload(db, &upload); err != nil {
+			log.Printf("Error while removing expired upload %v: %v", upload.ID, err)
+		}
+		unlock()
+	}
+}
+
+// StartUploadReaper runs ReapExpiredUploads every interval, for as long as the server runs.
+func StartUploadReaper(db *pgxpool.Pool, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ReapExpiredUploads(db)
+		}
+	}()
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	"github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/common/utils"
+	"github.com/David/Boxed/internal/users/services"
+	"github.com/labstack/echo/v5"
+)
+
+// GetUsageController reports the storage used by the authenticated user and their quota, in bytes.
+// The usage is broken down by mime category (`image`, `video`, `audio`, `text`, `application`...) plus thumbnails.
+//
+// Returns:
+//   - Responds with HTTP 200 (OK) and the usage as JSON.
+func GetUsageController(c *echo.Context) error {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.InternalServerError, // Couldn't get jwt, so it's a middleware error.
+			Message: "Error while getting user from jwt, please try again.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	usage, err := services.GetUsage(boxed.GetInstance().DbConn, userID)
+	if err != nil {
+		e := &types.ErrorResponse{
+			Code:    types.DatabaseError,
+			Message: "Error while measuring the storage usage. Please try later.",
+		}
+		return c.JSON(http.StatusInternalServerError, &e)
+	}
+	return c.JSON(http.StatusOK, usage)
+}
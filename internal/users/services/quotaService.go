@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	boxed "github.com/David/Boxed"
+	commonTypes "github.com/David/Boxed/internal/common/types"
+	"github.com/David/Boxed/internal/users/types"
+	"github.com/David/Boxed/repositories"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v5"
+)
+
+// ErrQuotaExceeded is returned when storing new content would take a user over their quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Quota returns the quota of a user in bytes: their own, or the server-wide DEFAULT_QUOTA. 0 means unlimited.
+func Quota(db *pgxpool.Pool, userID uuid.UUID) (int64, error) {
+	quota, err := repositories.NewUserRepo(db).GetQuota(userID)
+	if err != nil {
+		return 0, err
+	}
+	if quota == nil {
+		return boxed.GetInstance().DefaultQuota, nil
+	}
+	return *quota, nil
+}
+
+// GetUsage measures the storage used by a user: their files (the trash included), the previous versions
+// of those files, their thumbnails and the HLS renditions of their videos.
+func GetUsage(db *pgxpool.Pool, userID uuid.UUID) (*types.Usage, error) {
+	quota, err := Quota(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return usageOf(repositories.NewUsageRepo(db), userID, quota)
+}
+
+// usageOf measures the storage used by a user with ur, see GetUsage.
+func usageOf(ur *repositories.UsageRepo, userID uuid.UUID, quota int64) (*types.Usage, error) {
+	categories, err := ur.GetContentByCategory(userID)
+	if err != nil {
+		return nil, err
+	}
+	thumbnails, err := ur.GetThumbnails(userID)
+	if err != nil {
+		return nil, err
+	}
+	streams, err := ur.GetStreams(userID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &types.Usage{
+		Used:       thumbnails + streams,
+		Quota:      quota,
+		Categories: categories,
+		Thumbnails: thumbnails,
+		Streams:    streams,
+	}
+	for _, size := range categories {
+		usage.Used += size
+	}
+	return usage, nil
+}
+
+// CheckQuota checks that a user can store size more bytes. Uploads call it to refuse content before storing it,
+// CheckQuotaLocked has the final say once it is registered.
+// Returns ErrQuotaExceeded if it would take them over their quota.
+func CheckQuota(db *pgxpool.Pool, userID uuid.UUID, size int64) error {
+	usage, err := GetUsage(db, userID)
+	if err != nil {
+		return err
+	}
+	if usage.Quota > 0 && usage.Used+size > usage.Quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// CheckQuotaLocked checks that a user can store size more bytes, inside the transaction t registering the content.
+// The user's row stays locked until t ends, so concurrent uploads of a user are counted one after the other
+// instead of each fitting on its own and exceeding the quota together.
+// Returns ErrQuotaExceeded if it would take them over their quota.
+func CheckQuotaLocked(db *pgxpool.Pool, t pgx.Tx, userID uuid.UUID, size int64) error {
+	quota, err := repositories.NewUserRepo(db).WithTx(t).GetQuotaForUpdate(userID)
+	if err != nil {
+		return err
+	}
+	limit := boxed.GetInstance().DefaultQuota
+	if quota != nil {
+		limit = *quota
+	}
+	if limit == 0 {
+		return nil
+	}
+	usage, err := usageOf(repositories.NewUsageRepo(db).WithTx(t), userID, limit)
+	if err != nil {
+		return err
+	}
+	if usage.Used+size > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// QuotaError responds to an error returned while checking the storage quota of the owner of an upload.
+func QuotaError(c *echo.Context, err error) error {
+	if errors.Is(err, ErrQuotaExceeded) {
+		e := &commonTypes.ErrorResponse{
+			Code:    commonTypes.QuotaExceeded,
+			Message: "This upload would exceed the storage quota of the owner of its destination.",
+		}
+		return c.JSON(http.StatusRequestEntityTooLarge, &e)
+	}
+	e := &commonTypes.ErrorResponse{
+		Code:    commonTypes.DatabaseError,
+		Message: "Error while checking the storage quota. Please try later.",
+	}
+	return c.JSON(http.StatusInternalServerError, &e)
+}
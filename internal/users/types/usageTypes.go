@@ -0,0 +1,10 @@
+package types
+
+// Usage is the storage used by a user against their quota, in bytes.
+type Usage struct {
+	Used       int64            // Contents plus thumbnails and video streams.
+	Quota      int64            // 0 when unlimited.
+	Categories map[string]int64 // Contents, files in the trash and previous versions included, by mime category.
+	Thumbnails int64
+	Streams    int64 // HLS renditions of the videos.
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE folders (
+  id UUID PRIMARY KEY,
+  owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+  parent_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ DEFAULT now()
+);
+-- Sibling folders can't share a name. Root folders have a NULL parent, so it is coalesced to the nil uuid.
+CREATE UNIQUE INDEX folders_owner_parent_name_idx
+  ON folders (owner_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), name);
+CREATE INDEX folders_parent_id_idx ON folders (parent_id);
+
+ALTER TABLE files ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE CASCADE;
+CREATE INDEX files_folder_id_idx ON files (folder_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS files_folder_id_idx;
+ALTER TABLE files DROP COLUMN IF EXISTS folder_id;
+DROP TABLE IF EXISTS folders;
+-- +goose StatementEnd
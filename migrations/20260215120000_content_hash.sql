@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SHA-256 of the stored content, used as a strong ETag. Rows created before this migration are hashed lazily.
+ALTER TABLE files ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE thumbnails ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE thumbnails DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE files DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- `storage_path` used to hold absolute paths such as `<FOLDER_PATH>/<owner>/<file>`.
+-- It now holds keys relative to the storage backend, such as `<owner>/<file>`.
+UPDATE files
+   SET storage_path = substr(storage_path, strpos(storage_path, owner_id::text || '/'))
+ WHERE storage_path LIKE '/%' AND strpos(storage_path, owner_id::text || '/') > 0;
+UPDATE thumbnails
+   SET storage_path = substr(storage_path, strpos(storage_path, owner_id::text || '/'))
+ WHERE storage_path LIKE '/%' AND strpos(storage_path, owner_id::text || '/') > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The absolute FOLDER_PATH isn't known to the database, keys are kept as they are.
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Content-addressed storage: identical uploads share a single stored object.
+CREATE TABLE blobs (
+  hash TEXT PRIMARY KEY, -- Hex encoded SHA-256 of the content.
+  storage_path TEXT NOT NULL,
+  size BIGINT NOT NULL,
+  ref_count INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ DEFAULT now()
+);
+-- NULL for files uploaded before deduplication, which still own their storage_path.
+ALTER TABLE files ADD COLUMN blob_hash TEXT REFERENCES blobs(hash);
+CREATE INDEX files_blob_hash_idx ON files (blob_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS files_blob_hash_idx;
+ALTER TABLE files DROP COLUMN IF EXISTS blob_hash;
+DROP TABLE IF EXISTS blobs;
+-- +goose StatementEnd
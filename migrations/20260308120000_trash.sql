@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Files with a deleted_at are in the trash; they are purged once the retention period is over.
+ALTER TABLE files ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX files_deleted_at_idx ON files (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS files_deleted_at_idx;
+ALTER TABLE files DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- `version` is the number of the current content, `updated_at` when it was uploaded.
+ALTER TABLE files ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE files ADD COLUMN updated_at TIMESTAMPTZ;
+UPDATE files SET updated_at = COALESCE(created_at, now());
+ALTER TABLE files ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE files ALTER COLUMN updated_at SET DEFAULT now();
+
+-- Previous contents of a file. Each version holds its own blob reference and thumbnail.
+CREATE TABLE file_versions (
+  id UUID PRIMARY KEY,
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  version INTEGER NOT NULL,
+  storage_path TEXT NOT NULL,
+  size BIGINT NOT NULL,
+  mime_type TEXT NOT NULL,
+  thumbnail_id UUID REFERENCES thumbnails(id),
+  content_hash TEXT NOT NULL DEFAULT '',
+  blob_hash TEXT REFERENCES blobs(hash),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (file_id, version)
+);
+CREATE INDEX file_versions_blob_hash_idx ON file_versions (blob_hash);
+
+-- Resumable uploads can target an existing file, becoming its new version once finalized.
+ALTER TABLE uploads ADD COLUMN version_of UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE uploads DROP COLUMN IF EXISTS version_of;
+DROP TABLE IF EXISTS file_versions;
+ALTER TABLE files DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE files DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
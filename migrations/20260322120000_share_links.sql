@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Public links giving access to a file without an account.
+CREATE TABLE share_links (
+  id UUID PRIMARY KEY,
+  token TEXT UNIQUE NOT NULL,
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  password_hash TEXT, -- bcrypt hash, NULL when the link isn't protected.
+  expires_at TIMESTAMPTZ, -- NULL when the link never expires.
+  max_downloads INTEGER, -- NULL for unlimited downloads.
+  download_count INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX share_links_file_id_idx ON share_links (file_id);
+CREATE INDEX share_links_owner_id_idx ON share_links (owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS share_links;
+-- +goose StatementEnd
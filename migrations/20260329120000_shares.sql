@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Files and folders shared with other users. A folder share applies to everything below it.
+CREATE TABLE shares (
+  id UUID PRIMARY KEY,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  grantee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+  folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+  permission TEXT NOT NULL CHECK (permission IN ('read', 'write')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK ((file_id IS NULL) <> (folder_id IS NULL))
+);
+CREATE UNIQUE INDEX shares_grantee_file_idx ON shares (grantee_id, file_id) WHERE file_id IS NOT NULL;
+CREATE UNIQUE INDEX shares_grantee_folder_idx ON shares (grantee_id, folder_id) WHERE folder_id IS NOT NULL;
+CREATE INDEX shares_owner_id_idx ON shares (owner_id);
+CREATE INDEX shares_file_id_idx ON shares (file_id);
+CREATE INDEX shares_folder_id_idx ON shares (folder_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS shares;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NULL uses the server-wide DEFAULT_QUOTA, 0 means unlimited.
+ALTER TABLE users ADD COLUMN quota_bytes BIGINT CHECK (quota_bytes >= 0);
+-- Size of the generated thumbnail, counted in its owner's usage.
+ALTER TABLE thumbnails ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE thumbnails DROP COLUMN IF EXISTS size;
+ALTER TABLE users DROP COLUMN IF EXISTS quota_bytes;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Background extractions of archive files, polled by the user who started them.
+CREATE TABLE extraction_jobs (
+  id UUID PRIMARY KEY,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- User who started the extraction.
+  file_id UUID REFERENCES files(id) ON DELETE SET NULL, -- The archive.
+  folder_id UUID REFERENCES folders(id) ON DELETE SET NULL, -- Folder receiving the content, once created.
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+  total_entries INTEGER,
+  processed_entries INTEGER NOT NULL DEFAULT 0,
+  skipped_entries INTEGER NOT NULL DEFAULT 0, -- Unsafe paths, links and devices.
+  extracted_bytes BIGINT NOT NULL DEFAULT 0,
+  error TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX extraction_jobs_owner_id_idx ON extraction_jobs (owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS extraction_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Queue of background work, claimed by the workers with FOR UPDATE SKIP LOCKED.
+CREATE TABLE jobs (
+  id UUID PRIMARY KEY,
+  kind TEXT NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}',
+  status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'dead')), -- Done jobs are deleted.
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 5,
+  run_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Not claimed before, pushed back after each failure.
+  locked_at TIMESTAMPTZ, -- When a worker claimed it, to requeue the jobs of a crashed server.
+  last_error TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX jobs_queued_idx ON jobs (run_at) WHERE status = 'queued';
+CREATE INDEX jobs_running_idx ON jobs (locked_at) WHERE status = 'running';
+
+ALTER TABLE thumbnails ADD COLUMN status TEXT NOT NULL DEFAULT 'pending'
+  CHECK (status IN ('pending', 'ready', 'failed', 'unsupported'));
+UPDATE thumbnails SET status = 'ready' WHERE storage_path <> '';
+-- Thumbnails lost by a restart before this migration get generated again.
+INSERT INTO jobs (id, kind, payload)
+SELECT gen_random_uuid(), 'thumbnail', jsonb_build_object('thumbnail', id) FROM thumbnails WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE thumbnails DROP COLUMN IF EXISTS status;
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Other sizes and formats of a thumbnail, generated the first time they are requested.
+CREATE TABLE thumbnail_variants (
+  id UUID PRIMARY KEY,
+  thumbnail_id UUID NOT NULL REFERENCES thumbnails(id) ON DELETE CASCADE,
+  name TEXT NOT NULL, -- small, medium or large.
+  format TEXT NOT NULL, -- jpeg or webp.
+  storage_path TEXT NOT NULL,
+  content_hash TEXT NOT NULL,
+  size BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (thumbnail_id, name, format)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS thumbnail_variants;
+-- +goose StatementEnd
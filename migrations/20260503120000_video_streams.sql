@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- HLS renditions of a video content, stored next to its thumbnail: a file version keeps its stream the same way.
+CREATE TABLE video_streams (
+  thumbnail_id UUID PRIMARY KEY REFERENCES thumbnails(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+  renditions TEXT[] NOT NULL DEFAULT '{}', -- Names of the transcoded renditions, such as 720p.
+  storage_prefix TEXT NOT NULL, -- Key prefix of the playlists and segments.
+  size BIGINT NOT NULL DEFAULT 0, -- Of every playlist and segment.
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS video_streams;
+-- +goose StatementEnd
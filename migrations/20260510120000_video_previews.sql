@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Scrubbing previews of a video content, stored next to its thumbnail: a sprite sheet of frames taken at a fixed
+-- interval and the WebVTT track mapping time ranges to them.
+CREATE TABLE video_previews (
+  thumbnail_id UUID PRIMARY KEY REFERENCES thumbnails(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+  storage_prefix TEXT NOT NULL, -- Key prefix of the sprite sheet and the track.
+  interval_seconds INTEGER NOT NULL DEFAULT 0, -- Between two frames.
+  frames INTEGER NOT NULL DEFAULT 0,
+  size BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Videos uploaded before get their preview too.
+INSERT INTO video_previews (thumbnail_id, storage_prefix)
+SELECT t.id, t.owner_id::text || '/thumbnail/' || t.id::text || '/preview'
+FROM thumbnails t
+WHERE EXISTS (SELECT 1 FROM files f WHERE f.thumbnail_id = t.id AND f.mime_type LIKE 'video/%')
+   OR EXISTS (SELECT 1 FROM file_versions v WHERE v.thumbnail_id = t.id AND v.mime_type LIKE 'video/%');
+INSERT INTO jobs (id, kind, payload)
+SELECT gen_random_uuid(), 'preview', jsonb_build_object('thumbnail', thumbnail_id) FROM video_previews;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM jobs WHERE kind = 'preview';
+DROP TABLE IF EXISTS video_previews;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- PDF and text contents uploaded before they had a thumbnail renderer get one now.
+WITH requeued AS (
+  UPDATE thumbnails SET status = 'pending'
+  WHERE status = 'unsupported' AND id IN (
+    SELECT thumbnail_id FROM files
+    WHERE mime_type = 'application/pdf' OR mime_type LIKE 'text/%'
+       OR mime_type IN ('application/json', 'application/javascript', 'application/xml', 'application/x-sh',
+                        'application/x-yaml', 'application/yaml', 'application/toml', 'application/sql')
+    UNION
+    SELECT thumbnail_id FROM file_versions
+    WHERE mime_type = 'application/pdf' OR mime_type LIKE 'text/%'
+       OR mime_type IN ('application/json', 'application/javascript', 'application/xml', 'application/x-sh',
+                        'application/x-yaml', 'application/yaml', 'application/toml', 'application/sql')
+  )
+  RETURNING id
+)
+INSERT INTO jobs (id, kind, payload)
+SELECT gen_random_uuid(), 'thumbnail', jsonb_build_object('thumbnail', id) FROM requeued;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1; -- Generated thumbnails are kept.
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- What probing a content found out (duration, bitrate, codec...), NULL until it has been probed.
+ALTER TABLE files ADD COLUMN metadata JSONB;
+ALTER TABLE file_versions ADD COLUMN metadata JSONB;
+
+-- Peaks of an audio content, for the UI to draw its waveform.
+CREATE TABLE waveforms (
+  thumbnail_id UUID PRIMARY KEY REFERENCES thumbnails(id) ON DELETE CASCADE,
+  duration DOUBLE PRECISION NOT NULL, -- In seconds.
+  peaks INTEGER[] NOT NULL, -- From 0 to 100, evenly spread over the duration.
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Audio contents uploaded before get probed, and their cover art as thumbnail.
+WITH audio AS (
+  SELECT thumbnail_id FROM files WHERE mime_type LIKE 'audio/%'
+  UNION
+  SELECT thumbnail_id FROM file_versions WHERE mime_type LIKE 'audio/%' AND thumbnail_id IS NOT NULL
+), requeued AS (
+  UPDATE thumbnails SET status = 'pending'
+  WHERE status = 'unsupported' AND id IN (SELECT thumbnail_id FROM audio)
+  RETURNING id
+)
+INSERT INTO jobs (id, kind, payload)
+SELECT gen_random_uuid(), 'thumbnail', jsonb_build_object('thumbnail', id) FROM requeued
+UNION ALL
+SELECT gen_random_uuid(), 'media', jsonb_build_object('thumbnail', thumbnail_id) FROM audio;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM jobs WHERE kind = 'media';
+DROP TABLE IF EXISTS waveforms;
+ALTER TABLE file_versions DROP COLUMN IF EXISTS metadata;
+ALTER TABLE files DROP COLUMN IF EXISTS metadata;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Pictures and videos uploaded before get probed too, and the thumbnails of pictures are rendered again,
+-- upright. Their variants are dropped and rendered again on their next request, over the same objects.
+WITH media AS (
+  SELECT thumbnail_id, mime_type FROM files WHERE mime_type LIKE 'image/%' OR mime_type LIKE 'video/%'
+  UNION
+  SELECT thumbnail_id, mime_type FROM file_versions
+  WHERE (mime_type LIKE 'image/%' OR mime_type LIKE 'video/%') AND thumbnail_id IS NOT NULL
+), dropped AS (
+  DELETE FROM thumbnail_variants WHERE thumbnail_id IN (SELECT thumbnail_id FROM media WHERE mime_type LIKE 'image/%')
+), requeued AS (
+  UPDATE thumbnails SET status = 'pending'
+  WHERE status = 'ready' AND id IN (SELECT thumbnail_id FROM media WHERE mime_type LIKE 'image/%')
+  RETURNING id
+)
+INSERT INTO jobs (id, kind, payload)
+SELECT gen_random_uuid(), 'thumbnail', jsonb_build_object('thumbnail', id) FROM requeued
+UNION ALL
+SELECT DISTINCT ON (thumbnail_id) gen_random_uuid(), 'media', jsonb_build_object('thumbnail', thumbnail_id) FROM media;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM jobs WHERE kind = 'media' AND payload->>'thumbnail' IN (
+  SELECT thumbnail_id::text FROM files WHERE mime_type LIKE 'image/%' OR mime_type LIKE 'video/%'
+  UNION
+  SELECT thumbnail_id::text FROM file_versions WHERE mime_type LIKE 'image/%' OR mime_type LIKE 'video/%'
+);
+UPDATE files SET metadata = NULL WHERE mime_type LIKE 'image/%' OR mime_type LIKE 'video/%';
+UPDATE file_versions SET metadata = NULL WHERE mime_type LIKE 'image/%' OR mime_type LIKE 'video/%';
+-- +goose StatementEnd
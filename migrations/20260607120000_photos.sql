@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Capture date of the current content, copied from its metadata so the timeline can be sorted by an index.
+ALTER TABLE files ADD COLUMN taken_at TIMESTAMPTZ;
+UPDATE files SET taken_at = (metadata->>'taken_at')::timestamptz WHERE metadata ? 'taken_at';
+CREATE INDEX files_timeline_idx ON files (owner_id, COALESCE(taken_at, created_at) DESC, id DESC)
+  WHERE deleted_at IS NULL AND (mime_type LIKE 'image/%' OR mime_type LIKE 'video/%');
+
+-- Named collections of files, independent of the folders they are stored in.
+CREATE TABLE albums (
+  id UUID PRIMARY KEY,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  cover_file_id UUID REFERENCES files(id) ON DELETE SET NULL, -- NULL to use the first file as cover.
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX albums_owner_id_idx ON albums (owner_id);
+
+CREATE TABLE album_files (
+  album_id UUID NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  position INTEGER NOT NULL, -- Files are shown by increasing position.
+  added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (album_id, file_id)
+);
+CREATE INDEX album_files_file_id_idx ON album_files (file_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS album_files;
+DROP TABLE IF EXISTS albums;
+DROP INDEX IF EXISTS files_timeline_idx;
+ALTER TABLE files DROP COLUMN IF EXISTS taken_at;
+-- +goose StatementEnd
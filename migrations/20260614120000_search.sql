@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Words of the file names, split on dots, dashes and underscores so `invoice_2026-03.pdf` matches `invoice`.
+ALTER TABLE files ADD COLUMN name_vector TSVECTOR
+  GENERATED ALWAYS AS (to_tsvector('simple', translate(original_name, '._-', '   '))) STORED;
+CREATE INDEX files_name_vector_idx ON files USING GIN (name_vector);
+
+-- Text extracted from PDFs, text and office documents, for search.
+CREATE TABLE file_texts (
+  thumbnail_id UUID PRIMARY KEY REFERENCES thumbnails(id) ON DELETE CASCADE,
+  content TEXT NOT NULL,
+  content_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX file_texts_content_vector_idx ON file_texts USING GIN (content_vector);
+
+-- Documents uploaded before get their text extracted.
+INSERT INTO jobs (id, kind, payload)
+SELECT gen_random_uuid(), 'text', jsonb_build_object('thumbnail', thumbnail_id) FROM (
+  SELECT thumbnail_id, mime_type FROM files
+  UNION
+  SELECT thumbnail_id, mime_type FROM file_versions WHERE thumbnail_id IS NOT NULL
+) contents
+WHERE mime_type LIKE 'text/%' OR split_part(mime_type, ';', 1) IN (
+  'application/pdf', 'application/json', 'application/javascript', 'application/xml', 'application/x-sh',
+  'application/x-yaml', 'application/yaml', 'application/toml', 'application/sql',
+  'application/vnd.openxmlformats-officedocument.wordprocessingml.document',
+  'application/vnd.openxmlformats-officedocument.presentationml.presentation',
+  'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet',
+  'application/vnd.oasis.opendocument.text',
+  'application/vnd.oasis.opendocument.presentation',
+  'application/vnd.oasis.opendocument.spreadsheet'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM jobs WHERE kind = 'text';
+DROP TABLE IF EXISTS file_texts;
+DROP INDEX IF EXISTS files_name_vector_idx;
+ALTER TABLE files DROP COLUMN IF EXISTS name_vector;
+-- +goose StatementEnd
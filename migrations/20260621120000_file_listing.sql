@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Keyset pagination of the file listings, one index per sort order. The id tells files with the same value apart.
+CREATE INDEX files_owner_name_idx ON files (owner_id, lower(original_name), id) WHERE deleted_at IS NULL;
+CREATE INDEX files_owner_size_idx ON files (owner_id, size, id) WHERE deleted_at IS NULL;
+CREATE INDEX files_owner_created_at_idx ON files (owner_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX files_owner_mime_type_idx ON files (owner_id, mime_type, id) WHERE deleted_at IS NULL;
+
+-- `name contains` filters.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX files_original_name_trgm_idx ON files USING GIN (original_name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS files_original_name_trgm_idx;
+DROP INDEX IF EXISTS files_owner_mime_type_idx;
+DROP INDEX IF EXISTS files_owner_created_at_idx;
+DROP INDEX IF EXISTS files_owner_size_idx;
+DROP INDEX IF EXISTS files_owner_name_idx;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Labels users put on their files, across folders.
+CREATE TABLE tags (
+  id UUID PRIMARY KEY,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX tags_owner_name_idx ON tags (owner_id, lower(name));
+
+CREATE TABLE file_tags (
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (file_id, tag_id)
+);
+CREATE INDEX file_tags_tag_id_idx ON file_tags (tag_id);
+
+-- Files users starred.
+CREATE TABLE favorites (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, file_id)
+);
+CREATE INDEX favorites_file_id_idx ON favorites (file_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS file_tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
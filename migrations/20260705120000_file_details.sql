@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Free text users attach to their files.
+ALTER TABLE files ADD COLUMN description TEXT NOT NULL DEFAULT '';
+-- Bumped by every change of a file, so clients editing it can tell whether it changed under them.
+ALTER TABLE files ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN IF EXISTS revision;
+ALTER TABLE files DROP COLUMN IF EXISTS description;
+-- +goose StatementEnd
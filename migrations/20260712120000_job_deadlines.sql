@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- When a running job is considered lost: its claim plus the timeout of its kind.
+ALTER TABLE jobs ADD COLUMN deadline_at TIMESTAMPTZ;
+-- Jobs running during the migration were left by a stopped server.
+UPDATE jobs SET deadline_at = locked_at WHERE status = 'running';
+DROP INDEX IF EXISTS jobs_running_idx;
+CREATE INDEX jobs_running_idx ON jobs (deadline_at) WHERE status = 'running';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS jobs_running_idx;
+ALTER TABLE jobs DROP COLUMN IF EXISTS deadline_at;
+CREATE INDEX jobs_running_idx ON jobs (locked_at) WHERE status = 'running';
+-- +goose StatementEnd
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Album model represents the structure of the "albums" table.
+// An album is a named collection of files, independent of the folders they are stored in.
+type Album struct {
+	ID          uuid.UUID  `db:"id"`
+	OwnerID     uuid.UUID  `db:"owner_id"`
+	Name        string     `db:"name"`
+	CoverFileID *uuid.UUID `db:"cover_file_id" json:"-"` // nil to use the first file as cover.
+	CreatedAt   time.Time  `db:"created_at"`
+	// Cover is the file shown for the album: the chosen one, or the first file. nil for empty albums.
+	Cover     *uuid.UUID `db:"-"`
+	FileCount int        `db:"-"`
+}
+
+// albumColumns lists the columns scanned by scanAlbum, in order. Files in the trash are left out of the cover
+// and the count.
+const albumColumns = `a.id, a.owner_id, a.name, a.cover_file_id, a.created_at,
+    (SELECT af.file_id FROM album_files af JOIN files f ON f.id = af.file_id AND f.deleted_at IS NULL
+     WHERE af.album_id = a.id ORDER BY af.file_id IS NOT DISTINCT FROM a.cover_file_id DESC, af.position LIMIT 1),
+    (SELECT count(*) FROM album_files af JOIN files f ON f.id = af.file_id AND f.deleted_at IS NULL
+     WHERE af.album_id = a.id)`
+
+// scanAlbum reads a row selected with albumColumns into a.
+func scanAlbum(row pgx.Row, a *Album) error {
+	return row.Scan(&a.ID, &a.OwnerID, &a.Name, &a.CoverFileID, &a.CreatedAt, &a.Cover, &a.FileCount)
+}
+
+// AlbumsRepository interface exposes CRUD operations for albums and their files.
+type AlbumsRepository interface {
+	Create(a *Album) error
+	GetByID(id uuid.UUID) (*Album, error)
+	GetByOwnerID(ownerID uuid.UUID) ([]Album, error)
+	GetFiles(id uuid.UUID) ([]File, error)
+	Rename(id uuid.UUID, name string) error
+	SetCover(id uuid.UUID, fileID *uuid.UUID) error
+	AddFiles(id uuid.UUID, fileIDs []uuid.UUID) error
+	RemoveFiles(id uuid.UUID, fileIDs []uuid.UUID) error
+	Reorder(id uuid.UUID, fileIDs []uuid.UUID) error
+	Contains(id, fileID uuid.UUID) (bool, error)
+	Delete(id uuid.UUID) error
+}
+
+// AlbumsRepo implements the AlbumsRepository interface using pgx for PostgreSQL interaction.
+type AlbumsRepo struct {
+	db DBTX
+}
+
+// NewAlbumsRepo initializes a new instance of AlbumsRepo.
+func NewAlbumsRepo(db *pgxpool.Pool) *AlbumsRepo {
+	return &AlbumsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *AlbumsRepo) WithTx(tx pgx.Tx) *AlbumsRepo {
+	return &AlbumsRepo{db: tx}
+}
+
+// Create inserts a new, empty album in the "albums" table.
+func (r *AlbumsRepo) Create(a *Album) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	query := `INSERT INTO albums (id, owner_id, name, cover_file_id, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(context.Background(), query, a.ID, a.OwnerID, a.Name, a.CoverFileID, a.CreatedAt)
+	return err
+}
+
+// GetByID retrieves an album by its ID.
+func (r *AlbumsRepo) GetByID(id uuid.UUID) (*Album, error) {
+	a := &Album{}
+	query := `SELECT ` + albumColumns + ` FROM albums a WHERE a.id = $1`
+	err := scanAlbum(r.db.QueryRow(context.Background(), query, id), a)
+	return a, err
+}
+
+// GetByOwnerID retrieves the albums of a user, newest first.
+func (r *AlbumsRepo) GetByOwnerID(ownerID uuid.UUID) ([]Album, error) {
+	query := `SELECT ` + albumColumns + ` FROM albums a WHERE a.owner_id = $1 ORDER BY a.created_at DESC`
+	rows, err := r.db.Query(context.Background(), query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []Album{}
+	for rows.Next() {
+		a := Album{}
+		if err := scanAlbum(rows, &a); err != nil {
+			return nil, err
+		}
+		albums = append(albums, a)
+	}
+	return albums, rows.Err()
+}
+
+// GetFiles retrieves the files of an album in their order, without the ones in the trash.
+func (r *AlbumsRepo) GetFiles(id uuid.UUID) ([]File, error) {
+	query := `
+        SELECT ` + fileColumns + ` FROM files
+        JOIN album_files af ON af.file_id = files.id
+        WHERE af.album_id = $1 AND files.deleted_at IS NULL
+        ORDER BY af.position`
+	return (&FilesRepo{db: r.db}).queryFiles(query, id)
+}
+
+// Rename changes the name of an album.
+func (r *AlbumsRepo) Rename(id uuid.UUID, name string) error {
+	query := "UPDATE albums SET name = $1 WHERE id = $2"
+	_, err := r.db.Exec(context.Background(), query, name, id)
+	return err
+}
+
+// SetCover chooses the file shown for an album. A nil fileID goes back to its first file.
+func (r *AlbumsRepo) SetCover(id uuid.UUID, fileID *uuid.UUID) error {
+	query := "UPDATE albums SET cover_file_id = $1 WHERE id = $2"
+	_, err := r.db.Exec(context.Background(), query, fileID, id)
+	return err
+}
+
+// AddFiles appends files at the end of an album, in the given order. Files already in it keep their place.
+func (r *AlbumsRepo) AddFiles(id uuid.UUID, fileIDs []uuid.UUID) error {
+	query := `
+        INSERT INTO album_files (album_id, file_id, position)
+        SELECT $1, file_id,
+            (SELECT COALESCE(max(position), 0) FROM album_files WHERE album_id = $1) + row_number() OVER (ORDER BY ord)
+        FROM unnest($2::uuid[]) WITH ORDINALITY AS added(file_id, ord)
+        WHERE NOT EXISTS (SELECT 1 FROM album_files WHERE album_id = $1 AND file_id = added.file_id)
+        ON CONFLICT (album_id, file_id) DO NOTHING`
+	_, err := r.db.Exec(context.Background(), query, id, fileIDs)
+	return err
+}
+
+// RemoveFiles takes files out of an album, going back to the first file as cover if the chosen one is removed.
+// The files themselves are left untouched.
+func (r *AlbumsRepo) RemoveFiles(id uuid.UUID, fileIDs []uuid.UUID) error {
+	query := `
+        WITH removed AS (DELETE FROM album_files WHERE album_id = $1 AND file_id = ANY($2))
+        UPDATE albums SET cover_file_id = NULL WHERE id = $1 AND cover_file_id = ANY($2)`
+	_, err := r.db.Exec(context.Background(), query, id, fileIDs)
+	return err
+}
+
+// Reorder puts the given files first in an album, in the given order. The other files follow them, keeping
+// their current order.
+func (r *AlbumsRepo) Reorder(id uuid.UUID, fileIDs []uuid.UUID) error {
+	query := `
+        UPDATE album_files af SET position = ranked.position
+        FROM (
+            SELECT file_id, row_number() OVER (ORDER BY array_position($2::uuid[], file_id) NULLS LAST, position) AS position
+            FROM album_files WHERE album_id = $1
+        ) ranked
+        WHERE af.album_id = $1 AND af.file_id = ranked.file_id`
+	_, err := r.db.Exec(context.Background(), query, id, fileIDs)
+	return err
+}
+
+// Contains reports whether a file is in an album.
+func (r *AlbumsRepo) Contains(id, fileID uuid.UUID) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM album_files WHERE album_id = $1 AND file_id = $2)"
+	var found bool
+	err := r.db.QueryRow(context.Background(), query, id, fileID).Scan(&found)
+	return found, err
+}
+
+// Delete removes an album by its ID, its files are left untouched.
+func (r *AlbumsRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM albums WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Blob model represents the structure of the "blobs" table.
+// A blob is a stored object shared by every file with the same content.
+type Blob struct {
+	Hash        string    `db:"hash"`
+	StoragePath string    `db:"storage_path"`
+	Size        int64     `db:"size"`
+	RefCount    int       `db:"ref_count"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// BlobsRepository interface exposes the reference counting operations on blobs.
+type BlobsRepository interface {
+	Acquire(b *Blob) (created bool, err error)
+	Release(hash string) (*Blob, error)
+	LockUnreferenced(hash string) (*Blob, error)
+	Delete(hash string) error
+}
+
+// BlobsRepo implements the BlobsRepository interface using pgx for PostgreSQL interaction.
+type BlobsRepo struct {
+	db DBTX
+}
+
+// NewBlobsRepo initializes a new instance of BlobsRepo.
+func NewBlobsRepo(db *pgxpool.Pool) *BlobsRepo {
+	return &BlobsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *BlobsRepo) WithTx(tx pgx.Tx) *BlobsRepo {
+	return &BlobsRepo{db: tx}
+}
+
+// Acquire adds a reference to the blob with b.Hash, creating the row with a single reference when it doesn't exist.
+// The row stays locked until the surrounding transaction ends, so concurrent uploads of the same content wait
+// for the first one to store the object.
+// A row left without references is taken back the same way, its object may already be deleted.
+//
+// Returns:
+//   - created: true when the blob has just got its first reference and the object still has to be stored.
+func (r *BlobsRepo) Acquire(b *Blob) (bool, error) {
+	query := `
+        INSERT INTO blobs (hash, storage_path, size, ref_count, created_at) VALUES ($1, $2, $3, 1, $4)
+        ON CONFLICT (hash) DO UPDATE SET ref_count = blobs.ref_count + 1
+        RETURNING storage_path, ref_count, (ref_count = 1)`
+	var created bool
+	err := r.db.QueryRow(context.Background(), query, b.Hash, b.StoragePath, b.Size, b.CreatedAt).
+		Scan(&b.StoragePath, &b.RefCount, &created)
+	return created, err
+}
+
+// Release removes a reference to a blob and returns its updated row.
+// Once RefCount reaches zero the row is kept as a mark until the caller deletes the object and then the row.
+func (r *BlobsRepo) Release(hash string) (*Blob, error) {
+	b := &Blob{}
+	query := `
+        UPDATE blobs SET ref_count = ref_count - 1 WHERE hash = $1
+        RETURNING hash, storage_path, size, ref_count, created_at`
+	err := r.db.QueryRow(context.Background(), query, hash).
+		Scan(&b.Hash, &b.StoragePath, &b.Size, &b.RefCount, &b.CreatedAt)
+	return b, err
+}
+
+// LockUnreferenced locks the row of a blob that isn't referenced anymore until the surrounding transaction ends,
+// so that nobody acquires it while its object is being deleted.
+//
+// Returns:
+//   - pgx.ErrNoRows if the blob doesn't exist or has been referenced again.
+func (r *BlobsRepo) LockUnreferenced(hash string) (*Blob, error) {
+	b := &Blob{}
+	query := `
+        SELECT hash, storage_path, size, ref_count, created_at FROM blobs
+        WHERE hash = $1 AND ref_count <= 0 FOR UPDATE`
+	err := r.db.QueryRow(context.Background(), query, hash).
+		Scan(&b.Hash, &b.StoragePath, &b.Size, &b.RefCount, &b.CreatedAt)
+	return b, err
+}
+
+// Delete removes a blob row that isn't referenced anymore.
+func (r *BlobsRepo) Delete(hash string) error {
+	query := "DELETE FROM blobs WHERE hash = $1 AND ref_count <= 0"
+	_, err := r.db.Exec(context.Background(), query, hash)
+	return err
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statuses of an extraction job.
+const (
+	ExtractionPending = "pending"
+	ExtractionRunning = "running"
+	ExtractionDone    = "done"
+	ExtractionFailed  = "failed"
+)
+
+// ExtractionJob model represents the structure of the "extraction_jobs" table.
+type ExtractionJob struct {
+	ID               uuid.UUID  `db:"id"`
+	OwnerID          uuid.UUID  `db:"owner_id"`
+	FileID           *uuid.UUID `db:"file_id"`   // nil once the archive has been purged.
+	FolderID         *uuid.UUID `db:"folder_id"` // nil until the destination folder is created.
+	Status           string     `db:"status"`
+	TotalEntries     *int       `db:"total_entries"` // nil until the archive has been read.
+	ProcessedEntries int        `db:"processed_entries"`
+	SkippedEntries   int        `db:"skipped_entries"`
+	ExtractedBytes   int64      `db:"extracted_bytes"`
+	Error            *string    `db:"error"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at"`
+}
+
+// extractionJobColumns lists the columns scanned by scanExtractionJob, in order.
+const extractionJobColumns = "id, owner_id, file_id, folder_id, status, total_entries, processed_entries, skipped_entries, extracted_bytes, error, created_at, updated_at"
+
+// scanExtractionJob reads a row selected with extractionJobColumns into j.
+func scanExtractionJob(row pgx.Row, j *ExtractionJob) error {
+	return row.Scan(&j.ID, &j.OwnerID, &j.FileID, &j.FolderID, &j.Status, &j.TotalEntries, &j.ProcessedEntries,
+		&j.SkippedEntries, &j.ExtractedBytes, &j.Error, &j.CreatedAt, &j.UpdatedAt)
+}
+
+// ExtractionJobsRepository interface exposes CRUD operations for extraction jobs.
+type ExtractionJobsRepository interface {
+	Create(j *ExtractionJob) error
+	GetByID(id uuid.UUID) (*ExtractionJob, error)
+	GetByOwnerID(ownerID uuid.UUID) ([]ExtractionJob, error)
+	Update(j *ExtractionJob) error
+	FailUnfinished(reason string) (int64, error)
+}
+
+// ExtractionJobsRepo implements the ExtractionJobsRepository interface using pgx for PostgreSQL interaction.
+type ExtractionJobsRepo struct {
+	db DBTX
+}
+
+// NewExtractionJobsRepo initializes a new instance of ExtractionJobsRepo.
+func NewExtractionJobsRepo(db *pgxpool.Pool) *ExtractionJobsRepo {
+	return &ExtractionJobsRepo{db: db}
+}
+
+// Create inserts a new job in the "extraction_jobs" table.
+func (r *ExtractionJobsRepo) Create(j *ExtractionJob) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	query := `INSERT INTO extraction_jobs (` + extractionJobColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err := r.db.Exec(context.Background(), query, j.ID, j.OwnerID, j.FileID, j.FolderID, j.Status, j.TotalEntries,
+		j.ProcessedEntries, j.SkippedEntries, j.ExtractedBytes, j.Error, j.CreatedAt, j.UpdatedAt)
+	return err
+}
+
+// GetByID retrieves a job by its ID.
+func (r *ExtractionJobsRepo) GetByID(id uuid.UUID) (*ExtractionJob, error) {
+	j := &ExtractionJob{}
+	query := `SELECT ` + extractionJobColumns + ` FROM extraction_jobs WHERE id = $1`
+	err := scanExtractionJob(r.db.QueryRow(context.Background(), query, id), j)
+	return j, err
+}
+
+// GetByOwnerID retrieves the jobs started by a user, newest first.
+func (r *ExtractionJobsRepo) GetByOwnerID(ownerID uuid.UUID) ([]ExtractionJob, error) {
+	query := `SELECT ` + extractionJobColumns + ` FROM extraction_jobs WHERE owner_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(context.Background(), query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []ExtractionJob{}
+	for rows.Next() {
+		j := ExtractionJob{}
+		if err := scanExtractionJob(rows, &j); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// Update saves the status, progress, destination folder and error of a job, and stamps its updated_at.
+func (r *ExtractionJobsRepo) Update(j *ExtractionJob) error {
+	j.UpdatedAt = time.Now()
+	query := `
+        UPDATE extraction_jobs
+        SET folder_id = $2, status = $3, total_entries = $4, processed_entries = $5, skipped_entries = $6,
+            extracted_bytes = $7, error = $8, updated_at = $9
+        WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, j.ID, j.FolderID, j.Status, j.TotalEntries, j.ProcessedEntries,
+		j.SkippedEntries, j.ExtractedBytes, j.Error, j.UpdatedAt)
+	return err
+}
+
+// FailUnfinished marks every pending or running job as failed with reason.
+//
+// Returns:
+//   - The number of jobs marked as failed.
+func (r *ExtractionJobsRepo) FailUnfinished(reason string) (int64, error) {
+	query := `
+        UPDATE extraction_jobs SET status = 'failed', error = $1, updated_at = now()
+        WHERE status IN ('pending', 'running')`
+	tag, err := r.db.Exec(context.Background(), query, reason)
+	return tag.RowsAffected(), err
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FavoritesRepository interface exposes the operations on the files users starred.
+type FavoritesRepository interface {
+	Add(userID uuid.UUID, fileIDs []uuid.UUID) error
+	Remove(userID uuid.UUID, fileIDs []uuid.UUID) error
+}
+
+// FavoritesRepo implements the FavoritesRepository interface using pgx for PostgreSQL interaction.
+type FavoritesRepo struct {
+	db DBTX
+}
+
+// NewFavoritesRepo initializes a new instance of FavoritesRepo.
+func NewFavoritesRepo(db *pgxpool.Pool) *FavoritesRepo {
+	return &FavoritesRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *FavoritesRepo) WithTx(tx pgx.Tx) *FavoritesRepo {
+	return &FavoritesRepo{db: tx}
+}
+
+// Add stars files for a user, skipping the ones already starred.
+func (r *FavoritesRepo) Add(userID uuid.UUID, fileIDs []uuid.UUID) error {
+	query := `
+        INSERT INTO favorites (user_id, file_id)
+        SELECT $1, file_id FROM unnest($2::uuid[]) AS file_id
+        ON CONFLICT (user_id, file_id) DO NOTHING`
+	_, err := r.db.Exec(context.Background(), query, userID, fileIDs)
+	return err
+}
+
+// Remove unstars files for a user.
+func (r *FavoritesRepo) Remove(userID uuid.UUID, fileIDs []uuid.UUID) error {
+	query := "DELETE FROM favorites WHERE user_id = $1 AND file_id = ANY($2)"
+	_, err := r.db.Exec(context.Background(), query, userID, fileIDs)
+	return err
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FileText model represents the structure of the "file_texts" table.
+// It holds the text extracted from a content, which is identified by its thumbnail, for search.
+type FileText struct {
+	ThumbnailID uuid.UUID `db:"thumbnail_id"`
+	Content     string    `db:"content"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// FileTextsRepository interface exposes CRUD operations for extracted texts.
+type FileTextsRepository interface {
+	Save(t *FileText) error
+}
+
+// FileTextsRepo implements the FileTextsRepository interface using pgx for PostgreSQL interaction.
+type FileTextsRepo struct {
+	db DBTX
+}
+
+// NewFileTextsRepo initializes a new instance of FileTextsRepo.
+func NewFileTextsRepo(db *pgxpool.Pool) *FileTextsRepo {
+	return &FileTextsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *FileTextsRepo) WithTx(tx pgx.Tx) *FileTextsRepo {
+	return &FileTextsRepo{db: tx}
+}
+
+// Save inserts the text of a content, or replaces the one it already has.
+func (r *FileTextsRepo) Save(t *FileText) error {
+	t.CreatedAt = time.Now()
+	query := `
+        INSERT INTO file_texts (thumbnail_id, content, created_at) VALUES ($1, $2, $3)
+        ON CONFLICT (thumbnail_id) DO UPDATE SET content = EXCLUDED.content, created_at = EXCLUDED.created_at`
+	_, err := r.db.Exec(context.Background(), query, t.ThumbnailID, t.Content, t.CreatedAt)
+	return err
+}
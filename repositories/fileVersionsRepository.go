@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FileVersion model represents the structure of the "file_versions" table.
+// A version is a previous content of a file, kept when a new one is uploaded.
+type FileVersion struct {
+	ID          uuid.UUID      `db:"id"`
+	FileID      uuid.UUID      `db:"file_id"`
+	Version     int            `db:"version"`
+	StoragePath string         `db:"storage_path"`
+	Size        int64          `db:"size"`
+	MimeType    string         `db:"mime_type"`
+	ThumbnailId *uuid.UUID     `db:"thumbnail_id"`
+	ContentHash string         `db:"content_hash"`
+	BlobHash    *string        `db:"blob_hash" json:"-"`
+	CreatedAt   time.Time      `db:"created_at"` // When this content was uploaded.
+	Metadata    *MediaMetadata `db:"metadata"`
+}
+
+// fileVersionColumns lists the columns scanned by scanFileVersion, in order.
+const fileVersionColumns = "id, file_id, version, storage_path, size, mime_type, thumbnail_id, content_hash, blob_hash, created_at, metadata"
+
+// scanFileVersion reads a row selected with fileVersionColumns into v.
+func scanFileVersion(row pgx.Row, v *FileVersion) error {
+	return row.Scan(&v.ID, &v.FileID, &v.Version, &v.StoragePath, &v.Size, &v.MimeType,
+		&v.ThumbnailId, &v.ContentHash, &v.BlobHash, &v.CreatedAt, &v.Metadata)
+}
+
+// FileVersionsRepository interface exposes CRUD operations for file versions.
+type FileVersionsRepository interface {
+	Create(v *FileVersion) error
+	GetByFileID(fileID uuid.UUID) ([]FileVersion, error)
+	GetByVersion(fileID uuid.UUID, version int) (*FileVersion, error)
+	Delete(id uuid.UUID) error
+	Prune(fileID uuid.UUID, keep int, before *time.Time) ([]FileVersion, error)
+}
+
+// FileVersionsRepo implements the FileVersionsRepository interface using pgx for PostgreSQL interaction.
+type FileVersionsRepo struct {
+	db DBTX
+}
+
+// NewFileVersionsRepo initializes a new instance of FileVersionsRepo.
+func NewFileVersionsRepo(db *pgxpool.Pool) *FileVersionsRepo {
+	return &FileVersionsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *FileVersionsRepo) WithTx(tx pgx.Tx) *FileVersionsRepo {
+	return &FileVersionsRepo{db: tx}
+}
+
+// Create inserts a new version in the "file_versions" table.
+func (r *FileVersionsRepo) Create(v *FileVersion) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	query := `INSERT INTO file_versions (` + fileVersionColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := r.db.Exec(context.Background(), query, v.ID, v.FileID, v.Version, v.StoragePath, v.Size, v.MimeType,
+		v.ThumbnailId, v.ContentHash, v.BlobHash, v.CreatedAt, v.Metadata)
+	return err
+}
+
+// GetByFileID retrieves the previous versions of a file, newest first.
+func (r *FileVersionsRepo) GetByFileID(fileID uuid.UUID) ([]FileVersion, error) {
+	query := `SELECT ` + fileVersionColumns + ` FROM file_versions WHERE file_id = $1 ORDER BY version DESC`
+	return r.queryVersions(query, fileID)
+}
+
+// GetByVersion retrieves a version of a file by its number.
+func (r *FileVersionsRepo) GetByVersion(fileID uuid.UUID, version int) (*FileVersion, error) {
+	v := &FileVersion{}
+	query := `SELECT ` + fileVersionColumns + ` FROM file_versions WHERE file_id = $1 AND version = $2`
+	err := scanFileVersion(r.db.QueryRow(context.Background(), query, fileID, version), v)
+	return v, err
+}
+
+// Delete removes a version by its ID.
+func (r *FileVersionsRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM file_versions WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
+
+// Prune deletes the versions of a file beyond the keep newest ones and those uploaded before a given time.
+// A keep of 0 or a nil before disables the corresponding limit.
+//
+// Returns:
+//   - The deleted versions, whose content and thumbnails still have to be released.
+func (r *FileVersionsRepo) Prune(fileID uuid.UUID, keep int, before *time.Time) ([]FileVersion, error) {
+	query := `
+        DELETE FROM file_versions WHERE id IN (
+            SELECT id FROM (
+                SELECT id, created_at, row_number() OVER (ORDER BY version DESC) AS position
+                FROM file_versions WHERE file_id = $1
+            ) ranked
+            WHERE ($2 > 0 AND position > $2) OR created_at < $3
+        )
+        RETURNING ` + fileVersionColumns
+	return r.queryVersions(query, fileID, keep, before)
+}
+
+// queryVersions runs a query returning fileVersionColumns and collects every row.
+func (r *FileVersionsRepo) queryVersions(query string, args ...any) ([]FileVersion, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []FileVersion{}
+	for rows.Next() {
+		v := FileVersion{}
+		if err := scanFileVersion(rows, &v); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
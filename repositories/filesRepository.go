@@ -2,22 +2,73 @@ package repositories
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // File model represents the structure of the "files" table.
 type File struct {
-	ID           uuid.UUID `db:"id"`
-	OwnerID      uuid.UUID `db:"owner_id"`
-	OriginalName string    `db:"original_name"`
-	StoragePath  string    `db:"storage_path"`
-	Size         int64     `db:"size"`
-	MimeType     string    `db:"mime_type"`
-	ThumbnailId  uuid.UUID `db:"thumbnail_id"`
-	CreatedAt    time.Time `db:"created_at"`
+	ID           uuid.UUID  `db:"id"`
+	OwnerID      uuid.UUID  `db:"owner_id"`
+	OriginalName string     `db:"original_name"`
+	StoragePath  string     `db:"storage_path"`
+	Size         int64      `db:"size"`
+	MimeType     string     `db:"mime_type"`
+	ThumbnailId  uuid.UUID  `db:"thumbnail_id"`
+	FolderID     *uuid.UUID `db:"folder_id"`          // nil when the file lives in the owner's root.
+	ContentHash  string     `db:"content_hash"`       // Hex encoded SHA-256 of the content.
+	BlobHash     *string    `db:"blob_hash" json:"-"` // Blob holding the content, nil for files stored before deduplication.
+	CreatedAt    time.Time  `db:"created_at"`
+	DeletedAt    *time.Time `db:"deleted_at"` // Set while the file sits in the trash.
+	Version      int        `db:"version"`    // Number of the current content, see FileVersion.
+	UpdatedAt    time.Time  `db:"updated_at"` // When the current content was uploaded.
+	// Metadata is what probing the current content found out, nil until it has been probed.
+	Metadata    *MediaMetadata `db:"metadata"`
+	Description string         `db:"description"`
+	// Revision is bumped by every change of the file: name, folder, description, content, trash. Clients send
+	// back the one they read to change the file, so they don't overwrite changes they haven't seen.
+	Revision int `db:"revision"`
+}
+
+// MediaMetadata is what probing a content found out about it, stored as JSON in the "metadata" columns.
+// Fields that don't apply to the content, or that it doesn't record, are left out.
+type MediaMetadata struct {
+	Duration   float64 `json:"duration,omitempty"` // In seconds.
+	Bitrate    int64   `json:"bitrate,omitempty"`  // In bit/s.
+	Codec      string  `json:"codec,omitempty"`    // Of the video or picture, or of the audio for audio files.
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	SampleRate int     `json:"sample_rate,omitempty"` // In Hz.
+	Channels   int     `json:"channels,omitempty"`
+	Width      int     `json:"width,omitempty"` // As displayed, once rotated upright.
+	Height     int     `json:"height,omitempty"`
+	// Pictures and videos.
+	CameraMake  string     `json:"camera_make,omitempty"`
+	CameraModel string     `json:"camera_model,omitempty"`
+	TakenAt     *time.Time `json:"taken_at,omitempty"`
+	Orientation int        `json:"orientation,omitempty"` // EXIF orientation of pictures, from 1 to 8.
+	Latitude    *float64   `json:"latitude,omitempty"`
+	Longitude   *float64   `json:"longitude,omitempty"`
+}
+
+// fileColumns lists the columns scanned by scanFile, in order.
+const fileColumns = "id, owner_id, original_name, storage_path, size, mime_type, thumbnail_id, folder_id, content_hash, blob_hash, created_at, deleted_at, version, updated_at, metadata, description, revision"
+
+// scanFile reads a row selected with fileColumns into f.
+func scanFile(row pgx.Row, f *File) error {
+	return row.Scan(fileFields(f)...)
+}
+
+// fileFields returns the scan destinations of fileColumns, for queries selecting more columns after them.
+func fileFields(f *File) []any {
+	return []any{&f.ID, &f.OwnerID, &f.OriginalName, &f.StoragePath, &f.Size, &f.MimeType, &f.ThumbnailId,
+		&f.FolderID, &f.ContentHash, &f.BlobHash, &f.CreatedAt, &f.DeletedAt, &f.Version, &f.UpdatedAt, &f.Metadata,
+		&f.Description, &f.Revision}
 }
 
 // FilesRepository interface exposes CRUD operations for files.
@@ -25,12 +76,34 @@ type FilesRepository interface {
 	Create(file *File) error
 	GetByID(id uuid.UUID) (*File, error)
 	GetByOwnerID(ownerID uuid.UUID) ([]File, error)
+	GetByOwnerIDWithOptions(ownerID uuid.UUID, opts ListOptions) ([]ListedFile, error)
+	GetByIDs(ids []uuid.UUID) ([]File, error)
+	GetByFolderID(ownerID uuid.UUID, folderID *uuid.UUID) ([]File, error)
+	GetInFolderTree(folderID uuid.UUID) ([]File, error)
+	GetForUpdate(id uuid.UUID) (*File, error)
+	GetIDByThumbnailID(thumbnailID uuid.UUID) (uuid.UUID, error)
+	GetThumbnailSource(thumbnailID uuid.UUID) (*ThumbnailSource, error)
+	GetTrashedByID(id uuid.UUID) (*File, error)
+	GetTrashedByIDs(ids []uuid.UUID) ([]File, error)
+	GetTrashByOwnerID(ownerID uuid.UUID) ([]File, error)
+	GetTrashedBefore(before time.Time) ([]File, error)
+	GetTimeline(ownerID uuid.UUID, after *TimelineKey, limit int) ([]TimelineEntry, error)
+	Search(ownerID uuid.UUID, opts SearchOptions) ([]SearchResult, error)
+	SetContentHash(id uuid.UUID, hash string) error
+	SetMetadataByThumbnailID(thumbnailID uuid.UUID, metadata *MediaMetadata) error
+	ReplaceContent(file *File) error
+	UpdateDetails(file *File) error
+	Trash(id uuid.UUID) error
+	TrashByIDs(ids []uuid.UUID) ([]uuid.UUID, error)
+	Restore(id uuid.UUID) error
+	RestoreByIDs(ids []uuid.UUID) ([]uuid.UUID, error)
+	MoveByIDs(ids []uuid.UUID, folderID *uuid.UUID) ([]uuid.UUID, error)
 	Delete(id uuid.UUID) error
 }
 
 // FilesRepo implements the FilesRepository interface using pgx for PostgreSQL interaction.
 type FilesRepo struct {
-	db *pgxpool.Pool
+	db DBTX
 }
 
 // NewFilesRepo initializes a new instance of FilesRepo.
@@ -38,6 +111,11 @@ func NewFilesRepo(db *pgxpool.Pool) *FilesRepo {
 	return &FilesRepo{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *FilesRepo) WithTx(tx pgx.Tx) *FilesRepo {
+	return &FilesRepo{db: tx}
+}
+
 // Create inserts a new file record in the "files" table.
 // Create adds a new file entry into the `files` table.
 //
@@ -50,11 +128,21 @@ func (r *FilesRepo) Create(file *File) error {
 	if file.ID == uuid.Nil {
 		file.ID = uuid.New()
 	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	if file.Revision == 0 {
+		file.Revision = 1
+	}
+	if file.UpdatedAt.IsZero() {
+		file.UpdatedAt = file.CreatedAt
+	}
 	query := `
-        INSERT INTO files (id, owner_id, original_name, storage_path, size, mime_type, thumbnail_id, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+        INSERT INTO files (` + fileColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
 	_, err := r.db.Exec(context.Background(), query, file.ID, file.OwnerID, file.OriginalName, file.StoragePath,
-		file.Size, file.MimeType, file.ThumbnailId, file.CreatedAt)
+		file.Size, file.MimeType, file.ThumbnailId, file.FolderID, file.ContentHash, file.BlobHash, file.CreatedAt,
+		file.DeletedAt, file.Version, file.UpdatedAt, file.Metadata, file.Description, file.Revision)
 	return err
 }
 
@@ -66,21 +154,398 @@ func (r *FilesRepo) Create(file *File) error {
 //
 // Returns:
 //   - (*File, error): A pointer to the file's metadata if found; otherwise, an error.
+//
+// Files in the trash are not returned, see GetTrashedByID.
 func (r *FilesRepo) GetByID(id uuid.UUID) (*File, error) {
 	file := &File{}
-	query := `SELECT id, owner_id, original_name, storage_path, size, mime_type, thumbnail_id, created_at
-              FROM files WHERE id = $1`
-	err := r.db.QueryRow(context.Background(), query, id).
-		Scan(&file.ID, &file.OwnerID, &file.OriginalName, &file.StoragePath, &file.Size,
-			&file.MimeType, &file.ThumbnailId, &file.CreatedAt)
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = $1 AND deleted_at IS NULL`
+	err := scanFile(r.db.QueryRow(context.Background(), query, id), file)
 	return file, err
 }
 
 // GetByOwnerID retrieves all files owned by a specific user ID.
 func (r *FilesRepo) GetByOwnerID(ownerID uuid.UUID) ([]File, error) {
-	query := `SELECT id, owner_id, original_name, storage_path, size, mime_type, thumbnail_id, created_at
-              FROM files WHERE owner_id = $1`
-	rows, err := r.db.Query(context.Background(), query, ownerID)
+	query := `SELECT ` + fileColumns + ` FROM files WHERE owner_id = $1 AND deleted_at IS NULL`
+	return r.queryFiles(query, ownerID)
+}
+
+// likeEscaper escapes the characters that are special in LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match itself literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// FileSort is a column the file listings can be sorted by.
+type FileSort string
+
+const (
+	SortByName      FileSort = "name" // Case insensitive.
+	SortBySize      FileSort = "size"
+	SortByCreatedAt FileSort = "created_at"
+	SortByMime      FileSort = "mime"
+)
+
+// fileSortColumns maps the sorts to the expression files are ordered by, and the type its values are read back as.
+var fileSortColumns = map[FileSort]struct{ expr, cast string }{
+	SortByName:      {"lower(original_name)", "text"},
+	SortBySize:      {"size", "bigint"},
+	SortByCreatedAt: {"created_at", "timestamptz"},
+	SortByMime:      {"mime_type", "text"},
+}
+
+// ValidFileSort reports whether files can be sorted by s.
+func ValidFileSort(s FileSort) bool {
+	_, ok := fileSortColumns[s]
+	return ok
+}
+
+// listKeyLayouts are the layouts of the timestamps PostgreSQL writes as text, depending on the offset of the time zone.
+var listKeyLayouts = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999-07:00:00",
+}
+
+// ValidListValue reports whether v can be read back as a value of the column files are sorted by with s.
+func ValidListValue(s FileSort, v string) bool {
+	column, ok := fileSortColumns[s]
+	if !ok {
+		column = fileSortColumns[SortByCreatedAt]
+	}
+	switch column.cast {
+	case "bigint":
+		_, err := strconv.ParseInt(v, 10, 64)
+		return err == nil
+	case "timestamptz":
+		for _, layout := range listKeyLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return utf8.ValidString(v) && !strings.ContainsRune(v, 0)
+}
+
+// ListKey is the position of a file in a sorted listing: the value it is sorted by, as text, then its ID to tell
+// files with the same value apart.
+type ListKey struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// ListOptions are the page, order and filters of a file listing. Zero values leave the corresponding filter out.
+type ListOptions struct {
+	Folder        *uuid.UUID // Only the files directly inside this folder, nil for all the owner's files.
+	Sort          FileSort
+	Descending    bool
+	MimePrefix    string // `image/`, `application/pdf`...
+	NameContains  string // Case insensitive.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Tag           string    // Only the files with the tag of this name, ignoring case.
+	Favorites     bool      // Only the starred files.
+	UserID        uuid.UUID // The user whose tags and favorites Tag and Favorites refer to.
+	After         *ListKey  // Only the files coming after this position, to read the next page.
+	Limit         int
+}
+
+// ListedFile is a file of a listing with its position.
+type ListedFile struct {
+	File
+	Key ListKey `json:"-"`
+}
+
+// GetByOwnerIDWithOptions retrieves a page of the files of a user, filtered and sorted by opts.
+func (r *FilesRepo) GetByOwnerIDWithOptions(ownerID uuid.UUID, opts ListOptions) ([]ListedFile, error) {
+	column, ok := fileSortColumns[opts.Sort]
+	if !ok {
+		column = fileSortColumns[SortByCreatedAt]
+	}
+	order, after := "ASC", ">"
+	if opts.Descending {
+		order, after = "DESC", "<"
+	}
+	var afterValue *string
+	var afterID *uuid.UUID
+	if opts.After != nil {
+		afterValue, afterID = &opts.After.Value, &opts.After.ID
+	}
+	query := `
+        SELECT ` + fileColumns + `, (` + column.expr + `)::text FROM files
+        WHERE owner_id = $1 AND deleted_at IS NULL
+            AND ($2::uuid IS NULL OR folder_id = $2)
+            AND ($3 = '' OR mime_type LIKE $3 || '%')
+            AND ($4 = '' OR original_name ILIKE '%' || $4 || '%')
+            AND ($5::timestamptz IS NULL OR created_at >= $5)
+            AND ($6::timestamptz IS NULL OR created_at < $6)
+            AND ($7::text IS NULL OR (` + column.expr + `, id) ` + after + ` (($7::text)::` + column.cast + `, $8::uuid))
+            AND ` + tagFilter("$10", "$11", "$12") + `
+        ORDER BY ` + column.expr + ` ` + order + `, id ` + order + `
+        LIMIT $9`
+	rows, err := r.db.Query(context.Background(), query, ownerID, opts.Folder, escapeLike(opts.MimePrefix),
+		escapeLike(opts.NameContains), opts.CreatedAfter, opts.CreatedBefore, afterValue, afterID, opts.Limit,
+		opts.Tag, opts.Favorites, opts.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []ListedFile{}
+	for rows.Next() {
+		f := ListedFile{}
+		if err := rows.Scan(append(fileFields(&f.File), &f.Key.Value)...); err != nil {
+			return nil, err
+		}
+		f.Key.ID = f.ID
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// tagFilter returns the condition keeping the files of the "files" table with the tag named by the text parameter
+// tag, unless it is empty, and starred when the boolean parameter favorites is true, tags and favorites being
+// those of the user parameter.
+func tagFilter(tag, favorites, user string) string {
+	return `(` + tag + `::text = '' OR EXISTS (
+                SELECT 1 FROM file_tags ft JOIN tags tg ON tg.id = ft.tag_id
+                WHERE ft.file_id = files.id AND tg.owner_id = ` + user + `::uuid AND lower(tg.name) = lower(` + tag + `)))
+            AND (NOT ` + favorites + `::boolean OR EXISTS (
+                SELECT 1 FROM favorites fav WHERE fav.file_id = files.id AND fav.user_id = ` + user + `::uuid))`
+}
+
+// GetByIDs retrieves the files with the given IDs, in no particular order. IDs of missing files and files in
+// the trash are skipped.
+func (r *FilesRepo) GetByIDs(ids []uuid.UUID) ([]File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = ANY($1) AND deleted_at IS NULL`
+	return r.queryFiles(query, ids)
+}
+
+// GetByFolderID retrieves the files stored directly inside a folder.
+// A nil folderID lists the files in the owner's root.
+func (r *FilesRepo) GetByFolderID(ownerID uuid.UUID, folderID *uuid.UUID) ([]File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files
+              WHERE owner_id = $1 AND folder_id IS NOT DISTINCT FROM $2 AND deleted_at IS NULL`
+	return r.queryFiles(query, ownerID, folderID)
+}
+
+// GetInFolderTree retrieves every file stored in a folder or in any of its sub-folders,
+// including the ones in the trash.
+func (r *FilesRepo) GetInFolderTree(folderID uuid.UUID) ([]File, error) {
+	query := `
+        WITH RECURSIVE tree AS (
+            SELECT id FROM folders WHERE id = $1
+            UNION ALL
+            SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id
+        )
+        SELECT ` + fileColumns + ` FROM files WHERE folder_id IN (SELECT id FROM tree)`
+	return r.queryFiles(query, folderID)
+}
+
+// GetForUpdate retrieves a file that isn't in the trash and locks its row until the surrounding transaction ends.
+func (r *FilesRepo) GetForUpdate(id uuid.UUID) (*File, error) {
+	file := &File{}
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	err := scanFile(r.db.QueryRow(context.Background(), query, id), file)
+	return file, err
+}
+
+// GetIDByThumbnailID retrieves the id of the file whose current content or one of whose versions uses a thumbnail.
+func (r *FilesRepo) GetIDByThumbnailID(thumbnailID uuid.UUID) (uuid.UUID, error) {
+	query := `
+        SELECT id FROM files WHERE thumbnail_id = $1
+        UNION ALL
+        SELECT file_id FROM file_versions WHERE thumbnail_id = $1
+        LIMIT 1`
+	var id uuid.UUID
+	err := r.db.QueryRow(context.Background(), query, thumbnailID).Scan(&id)
+	return id, err
+}
+
+// ThumbnailSource is the content a thumbnail is generated from: the current content of a file, or one of its versions.
+type ThumbnailSource struct {
+	FileID       uuid.UUID
+	OriginalName string
+	StoragePath  string
+	MimeType     string
+}
+
+// GetThumbnailSource retrieves the content using a thumbnail, trashed files included.
+func (r *FilesRepo) GetThumbnailSource(thumbnailID uuid.UUID) (*ThumbnailSource, error) {
+	query := `
+        SELECT id, original_name, storage_path, mime_type FROM files WHERE thumbnail_id = $1
+        UNION ALL
+        SELECT f.id, f.original_name, v.storage_path, v.mime_type
+        FROM file_versions v JOIN files f ON f.id = v.file_id WHERE v.thumbnail_id = $1
+        LIMIT 1`
+	src := &ThumbnailSource{}
+	err := r.db.QueryRow(context.Background(), query, thumbnailID).Scan(&src.FileID, &src.OriginalName, &src.StoragePath, &src.MimeType)
+	return src, err
+}
+
+// GetTrashedByID retrieves a file that is in the trash.
+func (r *FilesRepo) GetTrashedByID(id uuid.UUID) (*File, error) {
+	file := &File{}
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = $1 AND deleted_at IS NOT NULL`
+	err := scanFile(r.db.QueryRow(context.Background(), query, id), file)
+	return file, err
+}
+
+// GetTrashedByIDs retrieves the files in the trash with the given IDs, in no particular order. IDs of missing
+// files and files out of the trash are skipped.
+func (r *FilesRepo) GetTrashedByIDs(ids []uuid.UUID) ([]File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE id = ANY($1) AND deleted_at IS NOT NULL`
+	return r.queryFiles(query, ids)
+}
+
+// GetTrashByOwnerID retrieves the files a user has in the trash, most recently deleted first.
+func (r *FilesRepo) GetTrashByOwnerID(ownerID uuid.UUID) ([]File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files
+              WHERE owner_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	return r.queryFiles(query, ownerID)
+}
+
+// GetTrashedBefore retrieves the files that were moved to the trash before a given time.
+func (r *FilesRepo) GetTrashedBefore(before time.Time) ([]File, error) {
+	query := `SELECT ` + fileColumns + ` FROM files WHERE deleted_at < $1`
+	return r.queryFiles(query, before)
+}
+
+// TimelineKey is the position of a file in a user's timeline: the date its content was taken, or uploaded
+// when unknown, then its ID to tell files taken at the same time apart.
+type TimelineKey struct {
+	TakenAt time.Time `json:"t"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// TimelineEntry is a file of a user's timeline with its position.
+type TimelineEntry struct {
+	File
+	Key TimelineKey `json:"-"`
+}
+
+// GetTimeline retrieves the pictures and videos of a user, most recently taken first, stopping after limit files.
+// A non nil after only returns the files coming after that position, to read the next page.
+func (r *FilesRepo) GetTimeline(ownerID uuid.UUID, after *TimelineKey, limit int) ([]TimelineEntry, error) {
+	var afterTime *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterTime, afterID = &after.TakenAt, &after.ID
+	}
+	query := `
+        SELECT ` + fileColumns + `, COALESCE(taken_at, created_at) FROM files
+        WHERE owner_id = $1 AND deleted_at IS NULL AND (mime_type LIKE 'image/%' OR mime_type LIKE 'video/%')
+            AND ($2::timestamptz IS NULL OR (COALESCE(taken_at, created_at), id) < ($2, $3::uuid))
+        ORDER BY COALESCE(taken_at, created_at) DESC, id DESC
+        LIMIT $4`
+	rows, err := r.db.Query(context.Background(), query, ownerID, afterTime, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []TimelineEntry{}
+	for rows.Next() {
+		e := TimelineEntry{}
+		if err := rows.Scan(append(fileFields(&e.File), &e.Key.TakenAt)...); err != nil {
+			return nil, err
+		}
+		e.Key.ID = e.ID
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SearchOptions are the criteria of a search. Zero values leave the corresponding filter out.
+type SearchOptions struct {
+	Query         string // Web search syntax: `"exact phrase"`, `or`, `-excluded`.
+	MimePrefix    string // `image/`, `application/pdf`...
+	MinSize       int64
+	MaxSize       int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Tag           string // Only the files with the owner's tag of this name, ignoring case.
+	Favorites     bool   // Only the files the owner starred.
+	Limit         int
+	Offset        int
+}
+
+// SearchResult is a file matching a search, with its rank and the matching parts of its name and text.
+// Matches are surrounded with HighlightStart and HighlightStop.
+type SearchResult struct {
+	File
+	Rank float32
+	// NameHighlight is the name with dots, dashes and underscores turned into spaces, as it was searched.
+	NameHighlight string
+	Snippet       string // Empty when the text of the content didn't match.
+}
+
+// Markers surrounding the matches in the highlights of a SearchResult, from Unicode's private use area so they
+// can't be mistaken for the text.
+const (
+	HighlightStart = "\ue000"
+	HighlightStop  = "\ue001"
+)
+
+// Search retrieves the files of a user whose name, tags or extracted text match opts.Query, best matches first.
+// Names weigh more than tags, which weigh more than text. An empty query matches every file, newest first.
+func (r *FilesRepo) Search(ownerID uuid.UUID, opts SearchOptions) ([]SearchResult, error) {
+	headline := `StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `"`
+	query := `
+        SELECT ` + fileColumns + `, rank,
+            CASE WHEN $2 = '' THEN translate(original_name, '._-', '   ')
+                ELSE ts_headline('simple', translate(original_name, '._-', '   '), websearch_to_tsquery('simple', $2),
+                    $10::text || ', HighlightAll=true') END,
+            CASE WHEN text_match THEN ts_headline('simple', text_content, websearch_to_tsquery('simple', $2),
+                $10::text || ', MaxFragments=2, MaxWords=20, MinWords=5') ELSE '' END
+        FROM (
+            SELECT files.*, t.content AS text_content,
+                COALESCE($2 <> '' AND t.content_vector @@ websearch_to_tsquery('simple', $2), false) AS text_match,
+                CASE WHEN $2 = '' THEN 0 ELSE ts_rank(setweight(files.name_vector, 'A') ||
+                    setweight(COALESCE(tv.tag_vector, ''::tsvector), 'B') ||
+                    setweight(COALESCE(t.content_vector, ''::tsvector), 'C'), websearch_to_tsquery('simple', $2)) END AS rank
+            FROM files LEFT JOIN file_texts t ON t.thumbnail_id = files.thumbnail_id
+            CROSS JOIN LATERAL (
+                SELECT to_tsvector('simple', translate(string_agg(tags.name, ' '), '._-', '   ')) AS tag_vector
+                FROM file_tags JOIN tags ON tags.id = file_tags.tag_id
+                WHERE file_tags.file_id = files.id AND tags.owner_id = $1
+            ) tv
+            WHERE files.owner_id = $1 AND files.deleted_at IS NULL
+                AND ($2 = '' OR files.name_vector @@ websearch_to_tsquery('simple', $2)
+                    OR tv.tag_vector @@ websearch_to_tsquery('simple', $2)
+                    OR t.content_vector @@ websearch_to_tsquery('simple', $2))
+                AND ($3 = '' OR files.mime_type LIKE $3 || '%')
+                AND ($4 = 0 OR files.size >= $4) AND ($5 = 0 OR files.size <= $5)
+                AND ($6::timestamptz IS NULL OR files.created_at >= $6)
+                AND ($7::timestamptz IS NULL OR files.created_at < $7)
+                AND ` + tagFilter("$11", "$12", "$1") + `
+            ORDER BY rank DESC, files.created_at DESC, files.id
+            LIMIT $8 OFFSET $9
+        ) matches
+        ORDER BY rank DESC, created_at DESC, id`
+	rows, err := r.db.Query(context.Background(), query, ownerID, opts.Query, escapeLike(opts.MimePrefix), opts.MinSize,
+		opts.MaxSize, opts.CreatedAfter, opts.CreatedBefore, opts.Limit, opts.Offset, headline, opts.Tag, opts.Favorites)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		res := SearchResult{}
+		if err := rows.Scan(append(fileFields(&res.File), &res.Rank, &res.NameHighlight, &res.Snippet)...); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// queryFiles runs a query selecting fileColumns and collects every row.
+func (r *FilesRepo) queryFiles(query string, args ...any) ([]File, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -89,14 +554,129 @@ func (r *FilesRepo) GetByOwnerID(ownerID uuid.UUID) ([]File, error) {
 	files := []File{}
 	for rows.Next() {
 		file := File{}
-		err := rows.Scan(&file.ID, &file.OwnerID, &file.OriginalName, &file.StoragePath, &file.Size,
-			&file.MimeType, &file.ThumbnailId, &file.CreatedAt)
-		if err != nil {
+		if err := scanFile(rows, &file); err != nil {
 			return nil, err
 		}
 		files = append(files, file)
 	}
-	return files, nil
+	return files, rows.Err()
+}
+
+// SetContentHash stores the hash of a file created before hashes were recorded.
+func (r *FilesRepo) SetContentHash(id uuid.UUID, hash string) error {
+	query := "UPDATE files SET content_hash = $1 WHERE id = $2"
+	_, err := r.db.Exec(context.Background(), query, hash, id)
+	return err
+}
+
+// SetMetadataByThumbnailID stores the metadata of the content using a thumbnail, whether it is the current
+// content of a file or one of its versions.
+func (r *FilesRepo) SetMetadataByThumbnailID(thumbnailID uuid.UUID, metadata *MediaMetadata) error {
+	query := `
+        WITH versions AS (UPDATE file_versions SET metadata = $2 WHERE thumbnail_id = $1)
+        UPDATE files SET metadata = $2, taken_at = $3 WHERE thumbnail_id = $1`
+	_, err := r.db.Exec(context.Background(), query, thumbnailID, metadata, takenAt(metadata))
+	return err
+}
+
+// takenAt returns the capture date recorded in metadata, nil when there is none.
+func takenAt(metadata *MediaMetadata) *time.Time {
+	if metadata == nil {
+		return nil
+	}
+	return metadata.TakenAt
+}
+
+// ReplaceContent stores a new current content for a file: its storage path, size, mime type, thumbnail,
+// hashes, version number, upload date and metadata.
+func (r *FilesRepo) ReplaceContent(file *File) error {
+	query := `
+        UPDATE files SET storage_path = $1, size = $2, mime_type = $3, thumbnail_id = $4, content_hash = $5,
+            blob_hash = $6, version = $7, updated_at = $8, metadata = $9, taken_at = $10, revision = revision + 1
+        WHERE id = $11`
+	return r.execOne(query, file.StoragePath, file.Size, file.MimeType, file.ThumbnailId, file.ContentHash,
+		file.BlobHash, file.Version, file.UpdatedAt, file.Metadata, takenAt(file.Metadata), file.ID)
+}
+
+// UpdateDetails stores the name, folder and description of a file, unless it is in the trash or its revision is
+// no longer file.Revision. file.Revision is then set to the new revision.
+//
+// Returns:
+//   - pgx.ErrNoRows if the file is in the trash, doesn't exist or changed since file.Revision.
+func (r *FilesRepo) UpdateDetails(file *File) error {
+	query := `
+        UPDATE files SET original_name = $1, folder_id = $2, description = $3, revision = revision + 1
+        WHERE id = $4 AND revision = $5 AND deleted_at IS NULL
+        RETURNING revision`
+	return r.db.QueryRow(context.Background(), query, file.OriginalName, file.FolderID, file.Description, file.ID,
+		file.Revision).Scan(&file.Revision)
+}
+
+// Trash moves a file to the trash.
+func (r *FilesRepo) Trash(id uuid.UUID) error {
+	query := "UPDATE files SET deleted_at = now(), revision = revision + 1 WHERE id = $1 AND deleted_at IS NULL"
+	return r.execOne(query, id)
+}
+
+// TrashByIDs moves files to the trash, skipping the ones already in it.
+//
+// Returns:
+//   - The IDs of the files moved to the trash.
+func (r *FilesRepo) TrashByIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+        UPDATE files SET deleted_at = now(), revision = revision + 1
+        WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id`
+	return r.queryIDs(query, ids)
+}
+
+// Restore takes a file out of the trash.
+func (r *FilesRepo) Restore(id uuid.UUID) error {
+	query := "UPDATE files SET deleted_at = NULL, revision = revision + 1 WHERE id = $1 AND deleted_at IS NOT NULL"
+	return r.execOne(query, id)
+}
+
+// RestoreByIDs takes files out of the trash, skipping the ones that aren't in it.
+//
+// Returns:
+//   - The IDs of the files taken out of the trash.
+func (r *FilesRepo) RestoreByIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+        UPDATE files SET deleted_at = NULL, revision = revision + 1
+        WHERE id = ANY($1) AND deleted_at IS NOT NULL RETURNING id`
+	return r.queryIDs(query, ids)
+}
+
+// MoveByIDs moves files inside a folder, or to their owner's root when folderID is nil, skipping the ones in
+// the trash.
+//
+// Returns:
+//   - The IDs of the files moved.
+func (r *FilesRepo) MoveByIDs(ids []uuid.UUID, folderID *uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+        UPDATE files SET folder_id = $2, revision = revision + 1
+        WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id`
+	return r.queryIDs(query, ids, folderID)
+}
+
+// queryIDs runs a query returning file IDs and collects every row.
+func (r *FilesRepo) queryIDs(query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// execOne runs a statement that must affect exactly one row, returning pgx.ErrNoRows otherwise.
+func (r *FilesRepo) execOne(query string, args ...any) error {
+	tag, err := r.db.Exec(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
 }
 
 // Delete removes a file record by its ID.
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Folder model represents the structure of the "folders" table.
+type Folder struct {
+	ID        uuid.UUID  `db:"id"`
+	OwnerID   uuid.UUID  `db:"owner_id"`
+	ParentID  *uuid.UUID `db:"parent_id"` // nil for folders placed in the owner's root.
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// FoldersRepository interface exposes CRUD operations for folders.
+type FoldersRepository interface {
+	Create(folder *Folder) error
+	GetByID(id uuid.UUID) (*Folder, error)
+	GetChildren(ownerID uuid.UUID, parentID *uuid.UUID) ([]Folder, error)
+	GetTree(id uuid.UUID) ([]Folder, error)
+	IsDescendant(id, ancestorID uuid.UUID) (bool, error)
+	Rename(id uuid.UUID, name string) error
+	LockOwner(ownerID uuid.UUID) error
+	Move(id uuid.UUID, parentID *uuid.UUID) error
+	Delete(id uuid.UUID) error
+}
+
+// FoldersRepo implements the FoldersRepository interface using pgx for PostgreSQL interaction.
+type FoldersRepo struct {
+	db DBTX
+}
+
+// NewFoldersRepo initializes a new instance of FoldersRepo.
+func NewFoldersRepo(db *pgxpool.Pool) *FoldersRepo {
+	return &FoldersRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *FoldersRepo) WithTx(tx pgx.Tx) *FoldersRepo {
+	return &FoldersRepo{db: tx}
+}
+
+// Create inserts a new folder record in the "folders" table.
+//
+// Parameters:
+//   - folder (*Folder): The folder to insert. A nil ParentID places it in the owner's root.
+//
+// Returns:
+//   - error: An error if the insertion fails, e.g. when a sibling folder already has the same name.
+func (r *FoldersRepo) Create(folder *Folder) error {
+	if folder.ID == uuid.Nil {
+		folder.ID = uuid.New()
+	}
+	query := `INSERT INTO folders (id, owner_id, parent_id, name, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(context.Background(), query, folder.ID, folder.OwnerID, folder.ParentID, folder.Name, folder.CreatedAt)
+	return err
+}
+
+// GetByID retrieves a folder by its unique ID.
+func (r *FoldersRepo) GetByID(id uuid.UUID) (*Folder, error) {
+	folder := &Folder{}
+	query := `SELECT id, owner_id, parent_id, name, created_at FROM folders WHERE id = $1`
+	err := r.db.QueryRow(context.Background(), query, id).
+		Scan(&folder.ID, &folder.OwnerID, &folder.ParentID, &folder.Name, &folder.CreatedAt)
+	return folder, err
+}
+
+// GetChildren retrieves the folders placed directly inside parentID.
+// A nil parentID lists the folders in the owner's root.
+func (r *FoldersRepo) GetChildren(ownerID uuid.UUID, parentID *uuid.UUID) ([]Folder, error) {
+	query := `SELECT id, owner_id, parent_id, name, created_at FROM folders
+              WHERE owner_id = $1 AND parent_id IS NOT DISTINCT FROM $2
+              ORDER BY name`
+	return r.queryFolders(query, ownerID, parentID)
+}
+
+// GetTree retrieves a folder and every folder below it, parents always coming before their children.
+func (r *FoldersRepo) GetTree(id uuid.UUID) ([]Folder, error) {
+	query := `
+        WITH RECURSIVE tree AS (
+            SELECT id, owner_id, parent_id, name, created_at, 0 AS depth FROM folders WHERE id = $1
+            UNION ALL
+            SELECT f.id, f.owner_id, f.parent_id, f.name, f.created_at, t.depth + 1
+            FROM folders f JOIN tree t ON f.parent_id = t.id
+        )
+        SELECT id, owner_id, parent_id, name, created_at FROM tree ORDER BY depth, name`
+	return r.queryFolders(query, id)
+}
+
+// queryFolders runs a query selecting the folder columns and collects every row.
+func (r *FoldersRepo) queryFolders(query string, args ...any) ([]Folder, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := []Folder{}
+	for rows.Next() {
+		folder := Folder{}
+		if err := rows.Scan(&folder.ID, &folder.OwnerID, &folder.ParentID, &folder.Name, &folder.CreatedAt); err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+	return folders, rows.Err()
+}
+
+// IsDescendant reports whether the folder id is ancestorID itself or lives somewhere below it.
+func (r *FoldersRepo) IsDescendant(id, ancestorID uuid.UUID) (bool, error) {
+	query := `
+        WITH RECURSIVE tree AS (
+            SELECT id FROM folders WHERE id = $2
+            UNION ALL
+            SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id
+        )
+        SELECT EXISTS (SELECT 1 FROM tree WHERE id = $1)`
+	var found bool
+	err := r.db.QueryRow(context.Background(), query, id, ancestorID).Scan(&found)
+	return found, err
+}
+
+// Rename changes the name of a folder.
+func (r *FoldersRepo) Rename(id uuid.UUID, name string) error {
+	query := "UPDATE folders SET name = $1 WHERE id = $2"
+	_, err := r.db.Exec(context.Background(), query, name, id)
+	return err
+}
+
+// LockOwner takes a lock on the folder tree of ownerID, released when the transaction ends. Moves hold it between
+// checking for cycles and moving, so two moves can't each put a folder inside the other.
+func (r *FoldersRepo) LockOwner(ownerID uuid.UUID) error {
+	query := "SELECT pg_advisory_xact_lock(hashtextextended('folders:' || $1::text, 0))"
+	_, err := r.db.Exec(context.Background(), query, ownerID)
+	return err
+}
+
+// Move changes the parent of a folder. A nil parentID moves it to the owner's root.
+func (r *FoldersRepo) Move(id uuid.UUID, parentID *uuid.UUID) error {
+	query := "UPDATE folders SET parent_id = $1 WHERE id = $2"
+	_, err := r.db.Exec(context.Background(), query, parentID, id)
+	return err
+}
+
+// Delete removes a folder record by its ID.
+// Sub-folders and the `files` rows inside them are removed by the database cascade.
+func (r *FoldersRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM folders WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
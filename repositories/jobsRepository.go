@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statuses of a job. Done jobs are deleted rather than kept.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDead    = "dead" // Failed too many times, or permanently; kept for inspection.
+)
+
+// Job model represents the structure of the "jobs" table.
+type Job struct {
+	ID          uuid.UUID       `db:"id"`
+	Kind        string          `db:"kind"` // Selects the handler running the job.
+	Payload     json.RawMessage `db:"payload"`
+	Status      string          `db:"status"`
+	Attempts    int             `db:"attempts"` // Including the running one.
+	MaxAttempts int             `db:"max_attempts"`
+	RunAt       time.Time       `db:"run_at"`
+	LockedAt    *time.Time      `db:"locked_at"`
+	DeadlineAt  *time.Time      `db:"deadline_at"` // Once passed, a running job is considered lost.
+	LastError   *string         `db:"last_error"`
+	CreatedAt   time.Time       `db:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at"`
+}
+
+// jobColumns lists the columns scanned by scanJob, in order.
+const jobColumns = "id, kind, payload, status, attempts, max_attempts, run_at, locked_at, deadline_at, last_error, created_at, updated_at"
+
+// scanJob reads a row selected with jobColumns into j.
+func scanJob(row pgx.Row, j *Job) error {
+	return row.Scan(&j.ID, &j.Kind, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedAt,
+		&j.DeadlineAt, &j.LastError, &j.CreatedAt, &j.UpdatedAt)
+}
+
+// JobsRepository interface exposes the operations of the job queue.
+type JobsRepository interface {
+	Enqueue(j *Job) error
+	Claim(kinds []string, exclude bool, timeouts map[string]time.Duration, fallback time.Duration) (*Job, error)
+	Complete(id uuid.UUID) error
+	Retry(id uuid.UUID, runAt time.Time, lastError string) error
+	Bury(id uuid.UUID, lastError string) error
+	RequeueStale() (int64, error)
+}
+
+// JobsRepo implements the JobsRepository interface using pgx for PostgreSQL interaction.
+type JobsRepo struct {
+	db DBTX
+}
+
+// NewJobsRepo initializes a new instance of JobsRepo.
+func NewJobsRepo(db *pgxpool.Pool) *JobsRepo {
+	return &JobsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+// Jobs enqueued inside tx only become visible to the workers once it commits.
+func (r *JobsRepo) WithTx(tx pgx.Tx) *JobsRepo {
+	return &JobsRepo{db: tx}
+}
+
+// Enqueue inserts a queued job, runnable from j.RunAt (now when zero).
+func (r *JobsRepo) Enqueue(j *Job) error {
+	now := time.Now()
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	if j.RunAt.IsZero() {
+		j.RunAt = now
+	}
+	if j.MaxAttempts == 0 {
+		j.MaxAttempts = 5
+	}
+	if j.Payload == nil {
+		j.Payload = json.RawMessage("{}")
+	}
+	j.Status, j.CreatedAt, j.UpdatedAt = JobQueued, now, now
+	query := `INSERT INTO jobs (` + jobColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err := r.db.Exec(context.Background(), query, j.ID, j.Kind, j.Payload, j.Status, j.Attempts, j.MaxAttempts,
+		j.RunAt, j.LockedAt, j.DeadlineAt, j.LastError, j.CreatedAt, j.UpdatedAt)
+	return err
+}
+
+// Claim marks the oldest runnable job of one of kinds as running and counts the attempt, or of any other kind when
+// exclude is true. Jobs claimed by other workers, even on other servers, are skipped rather than waited for.
+// The job is given until its deadline, now plus the timeout of its kind in timeouts, or fallback when it has none.
+// Returns pgx.ErrNoRows when no job is runnable.
+func (r *JobsRepo) Claim(kinds []string, exclude bool, timeouts map[string]time.Duration, fallback time.Duration) (*Job, error) {
+	seconds := make(map[string]float64, len(timeouts))
+	for kind, timeout := range timeouts {
+		seconds[kind] = timeout.Seconds()
+	}
+	j := &Job{}
+	query := `
+        UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_at = now(), updated_at = now(),
+            deadline_at = now() + make_interval(secs => coalesce(($3::jsonb ->> kind)::float8, $4))
+        WHERE id = (
+            SELECT id FROM jobs
+            WHERE status = 'queued' AND run_at <= now() AND coalesce(kind = ANY($1), false) <> $2
+            ORDER BY run_at
+            FOR UPDATE SKIP LOCKED
+            LIMIT 1
+        )
+        RETURNING ` + jobColumns
+	err := scanJob(r.db.QueryRow(context.Background(), query, kinds, exclude, seconds, fallback.Seconds()), j)
+	return j, err
+}
+
+// Complete deletes a job that ran successfully.
+func (r *JobsRepo) Complete(id uuid.UUID) error {
+	_, err := r.db.Exec(context.Background(), "DELETE FROM jobs WHERE id = $1", id)
+	return err
+}
+
+// Retry puts a failed job back in the queue, to run again from runAt.
+func (r *JobsRepo) Retry(id uuid.UUID, runAt time.Time, lastError string) error {
+	query := `
+        UPDATE jobs SET status = 'queued', run_at = $2, locked_at = NULL, deadline_at = NULL, last_error = $3, updated_at = now()
+        WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id, runAt, lastError)
+	return err
+}
+
+// Bury marks a job as dead, it won't run again.
+func (r *JobsRepo) Bury(id uuid.UUID, lastError string) error {
+	query := `
+        UPDATE jobs SET status = 'dead', locked_at = NULL, deadline_at = NULL, last_error = $2, updated_at = now()
+        WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id, lastError)
+	return err
+}
+
+// RequeueStale puts back in the queue the jobs still running past their deadline, whose worker
+// most likely died with its server.
+//
+// Returns:
+//   - The number of requeued jobs.
+func (r *JobsRepo) RequeueStale() (int64, error) {
+	query := `
+        UPDATE jobs SET status = 'queued', locked_at = NULL, deadline_at = NULL, last_error = 'worker lost',
+            updated_at = now()
+        WHERE status = 'running' AND deadline_at < now()`
+	tag, err := r.db.Exec(context.Background(), query)
+	return tag.RowsAffected(), err
+}
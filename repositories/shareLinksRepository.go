@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ShareLink model represents the structure of the "share_links" table.
+// A share link gives access to a file to anyone knowing its token.
+type ShareLink struct {
+	ID                uuid.UUID  `db:"id"`
+	Token             string     `db:"token"`
+	FileID            uuid.UUID  `db:"file_id"`
+	OwnerID           uuid.UUID  `db:"owner_id"`
+	PasswordHash      *string    `db:"password_hash" json:"-"`
+	PasswordProtected bool       `db:"-"`
+	ExpiresAt         *time.Time `db:"expires_at"`    // nil when the link never expires.
+	MaxDownloads      *int       `db:"max_downloads"` // nil for unlimited downloads.
+	DownloadCount     int        `db:"download_count"`
+	CreatedAt         time.Time  `db:"created_at"`
+}
+
+// shareLinkColumns lists the columns scanned by scanShareLink, in order.
+const shareLinkColumns = "id, token, file_id, owner_id, password_hash, expires_at, max_downloads, download_count, created_at"
+
+// scanShareLink reads a row selected with shareLinkColumns into l.
+func scanShareLink(row pgx.Row, l *ShareLink) error {
+	err := row.Scan(&l.ID, &l.Token, &l.FileID, &l.OwnerID, &l.PasswordHash, &l.ExpiresAt, &l.MaxDownloads,
+		&l.DownloadCount, &l.CreatedAt)
+	l.PasswordProtected = l.PasswordHash != nil
+	return err
+}
+
+// ShareLinksRepository interface exposes CRUD operations for share links.
+type ShareLinksRepository interface {
+	Create(l *ShareLink) error
+	GetByID(id uuid.UUID) (*ShareLink, error)
+	GetByToken(token string) (*ShareLink, error)
+	GetByOwnerID(ownerID uuid.UUID, fileID *uuid.UUID) ([]ShareLink, error)
+	CountDownload(id uuid.UUID) (bool, error)
+	Delete(id uuid.UUID) error
+}
+
+// ShareLinksRepo implements the ShareLinksRepository interface using pgx for PostgreSQL interaction.
+type ShareLinksRepo struct {
+	db DBTX
+}
+
+// NewShareLinksRepo initializes a new instance of ShareLinksRepo.
+func NewShareLinksRepo(db *pgxpool.Pool) *ShareLinksRepo {
+	return &ShareLinksRepo{db: db}
+}
+
+// Create inserts a new share link in the "share_links" table.
+func (r *ShareLinksRepo) Create(l *ShareLink) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	query := `INSERT INTO share_links (` + shareLinkColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.db.Exec(context.Background(), query, l.ID, l.Token, l.FileID, l.OwnerID, l.PasswordHash, l.ExpiresAt,
+		l.MaxDownloads, l.DownloadCount, l.CreatedAt)
+	l.PasswordProtected = l.PasswordHash != nil
+	return err
+}
+
+// GetByID retrieves a share link by its ID.
+func (r *ShareLinksRepo) GetByID(id uuid.UUID) (*ShareLink, error) {
+	l := &ShareLink{}
+	query := `SELECT ` + shareLinkColumns + ` FROM share_links WHERE id = $1`
+	err := scanShareLink(r.db.QueryRow(context.Background(), query, id), l)
+	return l, err
+}
+
+// GetByToken retrieves a share link by its token.
+func (r *ShareLinksRepo) GetByToken(token string) (*ShareLink, error) {
+	l := &ShareLink{}
+	query := `SELECT ` + shareLinkColumns + ` FROM share_links WHERE token = $1`
+	err := scanShareLink(r.db.QueryRow(context.Background(), query, token), l)
+	return l, err
+}
+
+// GetByOwnerID retrieves the share links created by a user, newest first.
+// A non nil fileID only returns the links of that file.
+func (r *ShareLinksRepo) GetByOwnerID(ownerID uuid.UUID, fileID *uuid.UUID) ([]ShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + ` FROM share_links
+              WHERE owner_id = $1 AND ($2::uuid IS NULL OR file_id = $2) ORDER BY created_at DESC`
+	rows, err := r.db.Query(context.Background(), query, ownerID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []ShareLink{}
+	for rows.Next() {
+		l := ShareLink{}
+		if err := scanShareLink(rows, &l); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// CountDownload records a download through a link, unless its download limit has already been reached.
+//
+// Returns:
+//   - false when the limit was reached and nothing was recorded.
+func (r *ShareLinksRepo) CountDownload(id uuid.UUID) (bool, error) {
+	query := `
+        UPDATE share_links SET download_count = download_count + 1
+        WHERE id = $1 AND (max_downloads IS NULL OR download_count < max_downloads)`
+	tag, err := r.db.Exec(context.Background(), query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Delete removes a share link by its ID.
+func (r *ShareLinksRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM share_links WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
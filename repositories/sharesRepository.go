@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Permissions a share can grant.
+const (
+	PermissionRead  = "read"  // List, download and preview.
+	PermissionWrite = "write" // Read, plus upload, add versions and delete.
+)
+
+// Share model represents the structure of the "shares" table.
+// A share gives another user access to a file, or to a folder and everything below it.
+type Share struct {
+	ID         uuid.UUID  `db:"id"`
+	OwnerID    uuid.UUID  `db:"owner_id"`
+	GranteeID  uuid.UUID  `db:"grantee_id"`
+	FileID     *uuid.UUID `db:"file_id"`   // Set when a file is shared.
+	FolderID   *uuid.UUID `db:"folder_id"` // Set when a folder is shared.
+	Permission string     `db:"permission"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// SharedFile is a file shared with a user, along with the permission granted on it.
+type SharedFile struct {
+	File
+	ShareID    uuid.UUID
+	Permission string
+}
+
+// SharedFolder is a folder shared with a user, along with the permission granted on it.
+type SharedFolder struct {
+	Folder
+	ShareID    uuid.UUID
+	Permission string
+}
+
+// shareColumns lists the columns scanned by scanShare, in order.
+const shareColumns = "id, owner_id, grantee_id, file_id, folder_id, permission, created_at"
+
+// scanShare reads a row selected with shareColumns into s.
+func scanShare(row pgx.Row, s *Share) error {
+	return row.Scan(&s.ID, &s.OwnerID, &s.GranteeID, &s.FileID, &s.FolderID, &s.Permission, &s.CreatedAt)
+}
+
+// SharesRepository interface exposes CRUD operations for shares.
+type SharesRepository interface {
+	Save(s *Share) error
+	GetByID(id uuid.UUID) (*Share, error)
+	GetByOwnerID(ownerID uuid.UUID) ([]Share, error)
+	GetFilesSharedWith(granteeID uuid.UUID) ([]SharedFile, error)
+	GetFoldersSharedWith(granteeID uuid.UUID) ([]SharedFolder, error)
+	GetFilePermissions(granteeID, fileID uuid.UUID) ([]string, error)
+	GetFolderPermissions(granteeID, folderID uuid.UUID) ([]string, error)
+	Delete(id uuid.UUID) error
+}
+
+// SharesRepo implements the SharesRepository interface using pgx for PostgreSQL interaction.
+type SharesRepo struct {
+	db DBTX
+}
+
+// NewSharesRepo initializes a new instance of SharesRepo.
+func NewSharesRepo(db *pgxpool.Pool) *SharesRepo {
+	return &SharesRepo{db: db}
+}
+
+// Save inserts a share, or updates the permission when the resource is already shared with the grantee.
+// s is filled with the stored row.
+func (r *SharesRepo) Save(s *Share) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	target := "(grantee_id, file_id) WHERE file_id IS NOT NULL"
+	if s.FolderID != nil {
+		target = "(grantee_id, folder_id) WHERE folder_id IS NOT NULL"
+	}
+	query := `
+        INSERT INTO shares (` + shareColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT ` + target + ` DO UPDATE SET permission = EXCLUDED.permission
+        RETURNING ` + shareColumns
+	row := r.db.QueryRow(context.Background(), query, s.ID, s.OwnerID, s.GranteeID, s.FileID, s.FolderID,
+		s.Permission, s.CreatedAt)
+	return scanShare(row, s)
+}
+
+// GetByID retrieves a share by its ID.
+func (r *SharesRepo) GetByID(id uuid.UUID) (*Share, error) {
+	s := &Share{}
+	query := `SELECT ` + shareColumns + ` FROM shares WHERE id = $1`
+	err := scanShare(r.db.QueryRow(context.Background(), query, id), s)
+	return s, err
+}
+
+// GetByOwnerID retrieves the shares a user has given, newest first.
+func (r *SharesRepo) GetByOwnerID(ownerID uuid.UUID) ([]Share, error) {
+	query := `SELECT ` + shareColumns + ` FROM shares WHERE owner_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(context.Background(), query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []Share{}
+	for rows.Next() {
+		s := Share{}
+		if err := scanShare(rows, &s); err != nil {
+			return nil, err
+		}
+		shares = append(shares, s)
+	}
+	return shares, rows.Err()
+}
+
+// GetFilesSharedWith retrieves the files directly shared with a user, leaving out the ones in the trash.
+func (r *SharesRepo) GetFilesSharedWith(granteeID uuid.UUID) ([]SharedFile, error) {
+	query := `
+        SELECT ` + fileColumns + `, share_id, permission FROM files
+        JOIN (SELECT id AS share_id, file_id, permission FROM shares WHERE grantee_id = $1) s ON s.file_id = files.id
+        WHERE deleted_at IS NULL
+        ORDER BY original_name`
+	rows, err := r.db.Query(context.Background(), query, granteeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []SharedFile{}
+	for rows.Next() {
+		f := SharedFile{}
+		if err := rows.Scan(append(fileFields(&f.File), &f.ShareID, &f.Permission)...); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// GetFoldersSharedWith retrieves the folders directly shared with a user.
+func (r *SharesRepo) GetFoldersSharedWith(granteeID uuid.UUID) ([]SharedFolder, error) {
+	query := `
+        SELECT f.id, f.owner_id, f.parent_id, f.name, f.created_at, s.id, s.permission
+        FROM folders f JOIN shares s ON s.folder_id = f.id
+        WHERE s.grantee_id = $1
+        ORDER BY f.name`
+	rows, err := r.db.Query(context.Background(), query, granteeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := []SharedFolder{}
+	for rows.Next() {
+		f := SharedFolder{}
+		err := rows.Scan(&f.ID, &f.OwnerID, &f.ParentID, &f.Name, &f.CreatedAt, &f.ShareID, &f.Permission)
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+	return folders, rows.Err()
+}
+
+// GetFilePermissions retrieves the permissions granted to a user on a file, directly or through any of the
+// folders containing it.
+func (r *SharesRepo) GetFilePermissions(granteeID, fileID uuid.UUID) ([]string, error) {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT folder_id AS id FROM files WHERE id = $2 AND folder_id IS NOT NULL
+            UNION ALL
+            SELECT f.parent_id FROM folders f JOIN ancestors a ON f.id = a.id WHERE f.parent_id IS NOT NULL
+        )
+        SELECT permission FROM shares
+        WHERE grantee_id = $1 AND (file_id = $2 OR folder_id IN (SELECT id FROM ancestors))`
+	return r.queryPermissions(query, granteeID, fileID)
+}
+
+// GetFolderPermissions retrieves the permissions granted to a user on a folder, directly or through any of
+// its parent folders.
+func (r *SharesRepo) GetFolderPermissions(granteeID, folderID uuid.UUID) ([]string, error) {
+	query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM folders WHERE id = $2
+            UNION ALL
+            SELECT f.id, f.parent_id FROM folders f JOIN ancestors a ON f.id = a.parent_id
+        )
+        SELECT permission FROM shares
+        WHERE grantee_id = $1 AND folder_id IN (SELECT id FROM ancestors)`
+	return r.queryPermissions(query, granteeID, folderID)
+}
+
+// queryPermissions runs a query selecting a single permission column and collects every row.
+func (r *SharesRepo) queryPermissions(query string, args ...any) ([]string, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
+
+// Delete removes a share by its ID.
+func (r *SharesRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM shares WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
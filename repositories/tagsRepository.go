@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Tag model represents the structure of the "tags" table.
+// A tag is a label a user puts on their files, across folders. Names are unique per user, ignoring case.
+type Tag struct {
+	ID        uuid.UUID `db:"id"`
+	OwnerID   uuid.UUID `db:"owner_id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	FileCount int       `db:"-"`
+}
+
+// tagColumns lists the columns scanned by scanTag, in order. Files in the trash are left out of the count.
+const tagColumns = `t.id, t.owner_id, t.name, t.created_at,
+    (SELECT count(*) FROM file_tags ft JOIN files f ON f.id = ft.file_id AND f.deleted_at IS NULL WHERE ft.tag_id = t.id)`
+
+// scanTag reads a row selected with tagColumns into t.
+func scanTag(row pgx.Row, t *Tag) error {
+	return row.Scan(&t.ID, &t.OwnerID, &t.Name, &t.CreatedAt, &t.FileCount)
+}
+
+// TagsRepository interface exposes CRUD operations for tags and the files they are put on.
+type TagsRepository interface {
+	Create(t *Tag) error
+	GetByID(id uuid.UUID) (*Tag, error)
+	GetByOwnerID(ownerID uuid.UUID) ([]Tag, error)
+	GetByFileID(ownerID, fileID uuid.UUID) ([]Tag, error)
+	GetIDsByNames(ownerID uuid.UUID, names []string) ([]uuid.UUID, error)
+	EnsureByNames(ownerID uuid.UUID, names []string) ([]uuid.UUID, error)
+	Rename(id uuid.UUID, name string) error
+	Merge(id, intoID uuid.UUID) error
+	TagFiles(fileIDs, tagIDs []uuid.UUID) ([]uuid.UUID, error)
+	UntagFiles(fileIDs, tagIDs []uuid.UUID) error
+	Delete(id uuid.UUID) error
+}
+
+// TagsRepo implements the TagsRepository interface using pgx for PostgreSQL interaction.
+type TagsRepo struct {
+	db DBTX
+}
+
+// NewTagsRepo initializes a new instance of TagsRepo.
+func NewTagsRepo(db *pgxpool.Pool) *TagsRepo {
+	return &TagsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *TagsRepo) WithTx(tx pgx.Tx) *TagsRepo {
+	return &TagsRepo{db: tx}
+}
+
+// Create inserts a new tag in the "tags" table.
+//
+// Returns:
+//   - error: An error if the insertion fails, e.g. when the user already has a tag with the same name.
+func (r *TagsRepo) Create(t *Tag) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	query := `INSERT INTO tags (id, owner_id, name, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(context.Background(), query, t.ID, t.OwnerID, t.Name, t.CreatedAt)
+	return err
+}
+
+// GetByID retrieves a tag by its ID.
+func (r *TagsRepo) GetByID(id uuid.UUID) (*Tag, error) {
+	t := &Tag{}
+	query := `SELECT ` + tagColumns + ` FROM tags t WHERE t.id = $1`
+	err := scanTag(r.db.QueryRow(context.Background(), query, id), t)
+	return t, err
+}
+
+// GetByOwnerID retrieves the tags of a user, by name.
+func (r *TagsRepo) GetByOwnerID(ownerID uuid.UUID) ([]Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags t WHERE t.owner_id = $1 ORDER BY lower(t.name)`
+	return r.queryTags(query, ownerID)
+}
+
+// GetByFileID retrieves the tags a user put on a file, by name.
+func (r *TagsRepo) GetByFileID(ownerID, fileID uuid.UUID) ([]Tag, error) {
+	query := `
+        SELECT ` + tagColumns + ` FROM tags t JOIN file_tags ft ON ft.tag_id = t.id
+        WHERE t.owner_id = $1 AND ft.file_id = $2 ORDER BY lower(t.name)`
+	return r.queryTags(query, ownerID, fileID)
+}
+
+// queryTags runs a query selecting tagColumns and collects every row.
+func (r *TagsRepo) queryTags(query string, args ...any) ([]Tag, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		t := Tag{}
+		if err := scanTag(rows, &t); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// GetIDsByNames retrieves the IDs of the tags of a user with the given names, ignoring case. Unknown names
+// are skipped.
+func (r *TagsRepo) GetIDsByNames(ownerID uuid.UUID, names []string) ([]uuid.UUID, error) {
+	query := `SELECT id FROM tags WHERE owner_id = $1 AND lower(name) IN (SELECT lower(n) FROM unnest($2::text[]) AS n)`
+	rows, err := r.db.Query(context.Background(), query, ownerID, names)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// EnsureByNames retrieves the IDs of the tags of a user with the given names, ignoring case, creating the missing ones.
+// Conflicting rows are updated rather than skipped so RETURNING also gives the tags created meanwhile by another
+// request, which a separate SELECT in the same statement wouldn't see.
+func (r *TagsRepo) EnsureByNames(ownerID uuid.UUID, names []string) ([]uuid.UUID, error) {
+	query := `
+        INSERT INTO tags (id, owner_id, name, created_at)
+        SELECT DISTINCT ON (lower(name)) gen_random_uuid(), $1, name, now() FROM unnest($2::text[]) AS name
+        ON CONFLICT (owner_id, lower(name)) DO UPDATE SET name = tags.name
+        RETURNING id`
+	rows, err := r.db.Query(context.Background(), query, ownerID, names)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// Rename changes the name of a tag.
+//
+// Returns:
+//   - error: An error if the user already has a tag with the new name.
+func (r *TagsRepo) Rename(id uuid.UUID, name string) error {
+	query := "UPDATE tags SET name = $1 WHERE id = $2"
+	_, err := r.db.Exec(context.Background(), query, name, id)
+	return err
+}
+
+// Merge puts the tag intoID on every file tagged with id, then deletes id.
+func (r *TagsRepo) Merge(id, intoID uuid.UUID) error {
+	query := `
+        INSERT INTO file_tags (file_id, tag_id)
+        SELECT file_id, $2 FROM file_tags WHERE tag_id = $1
+        ON CONFLICT (file_id, tag_id) DO NOTHING`
+	if _, err := r.db.Exec(context.Background(), query, id, intoID); err != nil {
+		return err
+	}
+	// Cascades to the tag's file_tags rows.
+	return r.Delete(id)
+}
+
+// TagFiles puts every tag on every file, skipping the ones they already have. Files that don't exist anymore or are
+// in the trash are left out, and kept from being deleted until the transaction ends.
+//
+// Returns:
+//   - The IDs of the files that were tagged.
+func (r *TagsRepo) TagFiles(fileIDs, tagIDs []uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+        WITH tagged AS (
+            SELECT id FROM files WHERE id = ANY($1) AND deleted_at IS NULL FOR KEY SHARE
+        ), inserted AS (
+            INSERT INTO file_tags (file_id, tag_id)
+            SELECT f.id, t FROM tagged f CROSS JOIN unnest($2::uuid[]) AS t
+            ON CONFLICT (file_id, tag_id) DO NOTHING
+        )
+        SELECT id FROM tagged`
+	rows, err := r.db.Query(context.Background(), query, fileIDs, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// UntagFiles takes every tag off every file. The tags are kept, even when no file has them anymore.
+func (r *TagsRepo) UntagFiles(fileIDs, tagIDs []uuid.UUID) error {
+	query := "DELETE FROM file_tags WHERE file_id = ANY($1) AND tag_id = ANY($2)"
+	_, err := r.db.Exec(context.Background(), query, fileIDs, tagIDs)
+	return err
+}
+
+// Delete removes a tag by its ID, taking it off every file.
+func (r *TagsRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM tags WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ThumbnailVariant model represents the structure of the "thumbnail_variants" table.
+// A variant is a thumbnail rendered at another size or in another format.
+type ThumbnailVariant struct {
+	ID          uuid.UUID `db:"id"`
+	ThumbnailID uuid.UUID `db:"thumbnail_id"`
+	Name        string    `db:"name"`   // small, medium or large.
+	Format      string    `db:"format"` // jpeg or webp.
+	StoragePath string    `db:"storage_path"`
+	ContentHash string    `db:"content_hash"`
+	Size        int64     `db:"size"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// thumbnailVariantColumns lists the columns scanned by scanThumbnailVariant, in order.
+const thumbnailVariantColumns = "id, thumbnail_id, name, format, storage_path, content_hash, size, created_at"
+
+// scanThumbnailVariant reads a row selected with thumbnailVariantColumns into v.
+func scanThumbnailVariant(row pgx.Row, v *ThumbnailVariant) error {
+	return row.Scan(&v.ID, &v.ThumbnailID, &v.Name, &v.Format, &v.StoragePath, &v.ContentHash, &v.Size, &v.CreatedAt)
+}
+
+// ThumbnailVariantsRepository interface exposes CRUD operations for thumbnail variants.
+type ThumbnailVariantsRepository interface {
+	Save(v *ThumbnailVariant) error
+	Get(thumbnailID uuid.UUID, name, format string) (*ThumbnailVariant, error)
+	GetByThumbnailID(thumbnailID uuid.UUID) ([]ThumbnailVariant, error)
+}
+
+// ThumbnailVariantsRepo implements the ThumbnailVariantsRepository interface using pgx for PostgreSQL interaction.
+type ThumbnailVariantsRepo struct {
+	db DBTX
+}
+
+// NewThumbnailVariantsRepo initializes a new instance of ThumbnailVariantsRepo.
+func NewThumbnailVariantsRepo(db *pgxpool.Pool) *ThumbnailVariantsRepo {
+	return &ThumbnailVariantsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *ThumbnailVariantsRepo) WithTx(tx pgx.Tx) *ThumbnailVariantsRepo {
+	return &ThumbnailVariantsRepo{db: tx}
+}
+
+// Save inserts a variant, or replaces the one already stored for the same thumbnail, name and format.
+func (r *ThumbnailVariantsRepo) Save(v *ThumbnailVariant) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	query := `
+        INSERT INTO thumbnail_variants (` + thumbnailVariantColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (thumbnail_id, name, format) DO UPDATE
+        SET storage_path = EXCLUDED.storage_path, content_hash = EXCLUDED.content_hash, size = EXCLUDED.size
+        RETURNING ` + thumbnailVariantColumns
+	row := r.db.QueryRow(context.Background(), query, v.ID, v.ThumbnailID, v.Name, v.Format, v.StoragePath,
+		v.ContentHash, v.Size, v.CreatedAt)
+	return scanThumbnailVariant(row, v)
+}
+
+// Get retrieves a variant of a thumbnail.
+func (r *ThumbnailVariantsRepo) Get(thumbnailID uuid.UUID, name, format string) (*ThumbnailVariant, error) {
+	v := &ThumbnailVariant{}
+	query := `SELECT ` + thumbnailVariantColumns + ` FROM thumbnail_variants WHERE thumbnail_id = $1 AND name = $2 AND format = $3`
+	err := scanThumbnailVariant(r.db.QueryRow(context.Background(), query, thumbnailID, name, format), v)
+	return v, err
+}
+
+// GetByThumbnailID retrieves every variant of a thumbnail.
+func (r *ThumbnailVariantsRepo) GetByThumbnailID(thumbnailID uuid.UUID) ([]ThumbnailVariant, error) {
+	query := `SELECT ` + thumbnailVariantColumns + ` FROM thumbnail_variants WHERE thumbnail_id = $1`
+	rows, err := r.db.Query(context.Background(), query, thumbnailID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []ThumbnailVariant{}
+	for rows.Next() {
+		v := ThumbnailVariant{}
+		if err := scanThumbnailVariant(rows, &v); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
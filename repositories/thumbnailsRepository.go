@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"strings"
+
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"strings"
 )
 
 type Thumbnail struct {
@@ -14,8 +16,19 @@ type Thumbnail struct {
 	OwnerId      uuid.UUID `db:"owner_id"`
 	OriginalName string    `db:"original_name"`
 	StoragePath  string    `db:"storage_path"`
+	ContentHash  string    `db:"content_hash"`
+	Size         int64     `db:"size"` // 0 until the thumbnail is generated.
+	Status       string    `db:"status"`
 }
 
+// Statuses of a thumbnail.
+const (
+	ThumbnailPending     = "pending" // Its generation job hasn't run yet, or is being retried.
+	ThumbnailReady       = "ready"
+	ThumbnailFailed      = "failed"      // Its generation job died.
+	ThumbnailUnsupported = "unsupported" // No thumbnail can be made for the mime type of the content.
+)
+
 type ThumbnailRepositoryInterface interface {
 	Create(t *Thumbnail) error
 	GetByID(id uuid.UUID) (Thumbnail, error)
@@ -23,13 +36,18 @@ type ThumbnailRepositoryInterface interface {
 	UpdateByID(t *Thumbnail) error
 }
 type ThumbnailRepository struct {
-	db *pgxpool.Pool
+	db DBTX
 }
 
 func NewThumbnailRepository(db *pgxpool.Pool) *ThumbnailRepository {
 	return &ThumbnailRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *ThumbnailRepository) WithTx(tx pgx.Tx) *ThumbnailRepository {
+	return &ThumbnailRepository{db: tx}
+}
+
 func (r *ThumbnailRepository) Create(t *Thumbnail) error {
 	query := "INSERT INTO thumbnails (id, owner_id, original_name, storage_path) VALUES ($1, $2, $3, $4)"
 	_, err := r.db.Exec(context.Background(), query, t.ID, t.OwnerId, t.OriginalName, t.StoragePath)
@@ -37,11 +55,11 @@ func (r *ThumbnailRepository) Create(t *Thumbnail) error {
 }
 
 func (r *ThumbnailRepository) GetByID(id uuid.UUID) (*Thumbnail, error) {
-	query := "SELECT id, owner_id, original_name, storage_path FROM thumbnails WHERE id = $1"
+	query := "SELECT id, owner_id, original_name, storage_path, content_hash, size, status FROM thumbnails WHERE id = $1"
 	row := r.db.QueryRow(context.Background(), query, id)
 
 	t := &Thumbnail{}
-	err := row.Scan(&t.ID, &t.OwnerId, &t.OriginalName, &t.StoragePath)
+	err := row.Scan(&t.ID, &t.OwnerId, &t.OriginalName, &t.StoragePath, &t.ContentHash, &t.Size, &t.Status)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +78,8 @@ func (r *ThumbnailRepository) DeleteByID(id uuid.UUID) error {
 // Parameters:
 // - t (*Thumbnail): A pointer to a `Thumbnail` struct.
 //   - `t.ID` (UUID): The unique identifier for the thumbnail. A valid non-nil UUID is required for this update to succeed.
-//   - `t.original_name` and `t.storage_path` (strings): Fields to be updated. If either field is an empty string or uninitialized, it will be ignored.
+//   - `t.original_name`, `t.storage_path`, `t.content_hash` and `t.status` (strings): Fields to be updated. If a field is an empty string or uninitialized, it will be ignored.
+//   - `t.size` (int64): Updated when greater than 0.
 //
 // Returns:
 // - error: Returns an error if:
@@ -78,22 +97,27 @@ func (r *ThumbnailRepository) UpdateByID(t *Thumbnail) error {
 	updates := []string{}
 	args := []any{}
 
-	if t.OriginalName != "" {
-		updates = append(updates, "original_name = $1")
-		args = append(args, t.OriginalName)
+	set := func(column, value string) {
+		if value != "" {
+			args = append(args, value)
+			updates = append(updates, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
 	}
-
-	if t.StoragePath != "" {
-		updates = append(updates, "storage_path = $2")
-		args = append(args, t.StoragePath)
+	set("original_name", t.OriginalName)
+	set("storage_path", t.StoragePath)
+	set("content_hash", t.ContentHash)
+	set("status", t.Status)
+	if t.Size > 0 {
+		args = append(args, t.Size)
+		updates = append(updates, fmt.Sprintf("size = $%d", len(args)))
 	}
 
 	if len(updates) == 0 {
 		return nil // No fields to update
 	}
 
-	query := fmt.Sprintf("UPDATE thumbnails SET %s WHERE id = $3", strings.Join(updates, ", "))
 	args = append(args, t.ID)
+	query := fmt.Sprintf("UPDATE thumbnails SET %s WHERE id = $%d", strings.Join(updates, ", "), len(args))
 
 	_, err := r.db.Exec(context.Background(), query, args...)
 	return err
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Upload model represents the structure of the "uploads" table.
+// Each row tracks a resumable upload whose bytes are still being written to TempPath.
+type Upload struct {
+	ID           uuid.UUID  `db:"id"`
+	OwnerID      uuid.UUID  `db:"owner_id"`
+	FolderID     *uuid.UUID `db:"folder_id"`
+	VersionOf    *uuid.UUID `db:"version_of"` // File the upload becomes a new version of, nil for a new file.
+	Filename     string     `db:"filename"`
+	MimeType     string     `db:"mime_type"`
+	UploadLength int64      `db:"upload_length"`
+	UploadOffset int64      `db:"upload_offset"`
+	TempPath     string     `db:"temp_path"`
+	ExpiresAt    time.Time  `db:"expires_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// UploadsRepository interface exposes CRUD operations for resumable uploads.
+type UploadsRepository interface {
+	Create(u *Upload) error
+	GetByID(id uuid.UUID) (*Upload, error)
+	GetExpired(now time.Time) ([]Upload, error)
+	UpdateProgress(id uuid.UUID, offset int64, expiresAt time.Time) error
+	Delete(id uuid.UUID) error
+}
+
+// UploadsRepo implements the UploadsRepository interface using pgx for PostgreSQL interaction.
+type UploadsRepo struct {
+	db DBTX
+}
+
+// NewUploadsRepo initializes a new instance of UploadsRepo.
+func NewUploadsRepo(db *pgxpool.Pool) *UploadsRepo {
+	return &UploadsRepo{db: db}
+}
+
+const uploadColumns = "id, owner_id, folder_id, filename, mime_type, upload_length, upload_offset, temp_path, expires_at, created_at, version_of"
+
+// Create inserts a new upload record in the "uploads" table.
+func (r *UploadsRepo) Create(u *Upload) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	query := `INSERT INTO uploads (` + uploadColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := r.db.Exec(context.Background(), query, u.ID, u.OwnerID, u.FolderID, u.Filename, u.MimeType,
+		u.UploadLength, u.UploadOffset, u.TempPath, u.ExpiresAt, u.CreatedAt, u.VersionOf)
+	return err
+}
+
+// GetByID retrieves an upload by its unique ID.
+func (r *UploadsRepo) GetByID(id uuid.UUID) (*Upload, error) {
+	u := &Upload{}
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE id = $1`
+	err := r.db.QueryRow(context.Background(), query, id).
+		Scan(&u.ID, &u.OwnerID, &u.FolderID, &u.Filename, &u.MimeType, &u.UploadLength, &u.UploadOffset,
+			&u.TempPath, &u.ExpiresAt, &u.CreatedAt, &u.VersionOf)
+	return u, err
+}
+
+// GetExpired retrieves every upload whose expiration date is before now.
+func (r *UploadsRepo) GetExpired(now time.Time) ([]Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE expires_at < $1`
+	rows, err := r.db.Query(context.Background(), query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := []Upload{}
+	for rows.Next() {
+		u := Upload{}
+		err := rows.Scan(&u.ID, &u.OwnerID, &u.FolderID, &u.Filename, &u.MimeType, &u.UploadLength, &u.UploadOffset,
+			&u.TempPath, &u.ExpiresAt, &u.CreatedAt, &u.VersionOf)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, u)
+	}
+	return uploads, rows.Err()
+}
+
+// UpdateProgress stores the new offset of an upload and pushes back its expiration date.
+func (r *UploadsRepo) UpdateProgress(id uuid.UUID, offset int64, expiresAt time.Time) error {
+	query := "UPDATE uploads SET upload_offset = $1, expires_at = $2 WHERE id = $3"
+	_, err := r.db.Exec(context.Background(), query, offset, expiresAt, id)
+	return err
+}
+
+// Delete removes an upload record by its ID.
+func (r *UploadsRepo) Delete(id uuid.UUID) error {
+	query := "DELETE FROM uploads WHERE id = $1"
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UsageRepository interface exposes the queries measuring the storage used by a user.
+type UsageRepository interface {
+	GetContentByCategory(ownerID uuid.UUID) (map[string]int64, error)
+	GetThumbnails(ownerID uuid.UUID) (int64, error)
+	GetStreams(ownerID uuid.UUID) (int64, error)
+}
+
+// UsageRepo implements the UsageRepository interface using pgx for PostgreSQL interaction.
+type UsageRepo struct {
+	db DBTX
+}
+
+// NewUsageRepo initializes a new instance of UsageRepo.
+func NewUsageRepo(db *pgxpool.Pool) *UsageRepo {
+	return &UsageRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *UsageRepo) WithTx(tx pgx.Tx) *UsageRepo {
+	return &UsageRepo{db: tx}
+}
+
+// GetContentByCategory sums the size of a user's files, including the ones in the trash, and of their
+// previous versions, grouped by the top-level type of their mime type (`image`, `video`, `application`...).
+// Contents shared by several files are counted once per file.
+func (r *UsageRepo) GetContentByCategory(ownerID uuid.UUID) (map[string]int64, error) {
+	query := `
+        SELECT COALESCE(NULLIF(split_part(mime_type, '/', 1), ''), 'other') AS category, SUM(size)::BIGINT
+        FROM (
+            SELECT mime_type, size FROM files WHERE owner_id = $1
+            UNION ALL
+            SELECT v.mime_type, v.size FROM file_versions v JOIN files f ON f.id = v.file_id WHERE f.owner_id = $1
+        ) content
+        GROUP BY category`
+	rows, err := r.db.Query(context.Background(), query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := map[string]int64{}
+	for rows.Next() {
+		var category string
+		var size int64
+		if err := rows.Scan(&category, &size); err != nil {
+			return nil, err
+		}
+		categories[category] = size
+	}
+	return categories, rows.Err()
+}
+
+// GetThumbnails sums the size of a user's generated thumbnails, of their variants and of the video previews.
+func (r *UsageRepo) GetThumbnails(ownerID uuid.UUID) (int64, error) {
+	var size int64
+	query := `
+        SELECT COALESCE(SUM(size), 0)::BIGINT FROM (
+            SELECT size FROM thumbnails WHERE owner_id = $1
+            UNION ALL
+            SELECT v.size FROM thumbnail_variants v JOIN thumbnails t ON t.id = v.thumbnail_id WHERE t.owner_id = $1
+            UNION ALL
+            SELECT p.size FROM video_previews p JOIN thumbnails t ON t.id = p.thumbnail_id WHERE t.owner_id = $1
+        ) generated`
+	err := r.db.QueryRow(context.Background(), query, ownerID).Scan(&size)
+	return size, err
+}
+
+// GetStreams sums the size of the HLS renditions of a user's videos.
+func (r *UsageRepo) GetStreams(ownerID uuid.UUID) (int64, error) {
+	var size int64
+	query := `
+        SELECT COALESCE(SUM(s.size), 0)::BIGINT
+        FROM video_streams s JOIN thumbnails t ON t.id = s.thumbnail_id
+        WHERE t.owner_id = $1`
+	err := r.db.QueryRow(context.Background(), query, ownerID).Scan(&size)
+	return size, err
+}
@@ -5,12 +5,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UsersRepository interface {
 	Create(user *User) error
 	GetByID(id uuid.UUID) (*User, error)
+	GetQuota(id uuid.UUID) (*int64, error)
+	GetQuotaForUpdate(id uuid.UUID) (*int64, error)
 	Update(user *User) error
 	Delete(id uuid.UUID) error
 }
@@ -24,13 +27,18 @@ type User struct {
 }
 
 type UserRepo struct {
-	db *pgxpool.Pool
+	db DBTX
 }
 
 func NewUserRepo(d *pgxpool.Pool) *UserRepo {
 	return &UserRepo{db: d}
 }
 
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (s *UserRepo) WithTx(tx pgx.Tx) *UserRepo {
+	return &UserRepo{db: tx}
+}
+
 // Creates an user in the `db`.
 // Create inserts a new user into the `users` table.
 //
@@ -76,3 +84,20 @@ func (s *UserRepo) GetByEmail(email string) (*User, error) {
 		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
 	return user, err
 }
+
+// GetQuota retrieves the quota of a user in bytes, nil when the user uses the server-wide default.
+func (s *UserRepo) GetQuota(id uuid.UUID) (*int64, error) {
+	var quota *int64
+	query := `SELECT quota_bytes FROM users WHERE id = $1`
+	err := s.db.QueryRow(context.Background(), query, id).Scan(&quota)
+	return quota, err
+}
+
+// GetQuotaForUpdate does the same as GetQuota and locks the user's row until the transaction ends, so the
+// uploads of a user check their quota one after the other.
+func (s *UserRepo) GetQuotaForUpdate(id uuid.UUID) (*int64, error) {
+	var quota *int64
+	query := `SELECT quota_bytes FROM users WHERE id = $1 FOR UPDATE`
+	err := s.db.QueryRow(context.Background(), query, id).Scan(&quota)
+	return quota, err
+}
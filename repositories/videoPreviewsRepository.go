@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statuses of a video preview.
+const (
+	PreviewPending = "pending" // Its generation job hasn't run yet, or is being retried.
+	PreviewReady   = "ready"
+	PreviewFailed  = "failed" // Its generation job died.
+)
+
+// VideoPreview model represents the structure of the "video_previews" table.
+// A preview holds the scrubbing sprite sheet of a video content, which is identified by its thumbnail.
+type VideoPreview struct {
+	ThumbnailID     uuid.UUID `db:"thumbnail_id"`
+	Status          string    `db:"status"`
+	StoragePrefix   string    `db:"storage_prefix"`
+	IntervalSeconds int       `db:"interval_seconds"`
+	Frames          int       `db:"frames"`
+	Size            int64     `db:"size"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// videoPreviewColumns lists the columns scanned by scanVideoPreview, in order.
+const videoPreviewColumns = "thumbnail_id, status, storage_prefix, interval_seconds, frames, size, created_at, updated_at"
+
+// scanVideoPreview reads a row selected with videoPreviewColumns into p.
+func scanVideoPreview(row pgx.Row, p *VideoPreview) error {
+	return row.Scan(&p.ThumbnailID, &p.Status, &p.StoragePrefix, &p.IntervalSeconds, &p.Frames, &p.Size,
+		&p.CreatedAt, &p.UpdatedAt)
+}
+
+// VideoPreviewsRepository interface exposes CRUD operations for video previews.
+type VideoPreviewsRepository interface {
+	Create(p *VideoPreview) error
+	GetByThumbnailID(thumbnailID uuid.UUID) (*VideoPreview, error)
+	Update(p *VideoPreview) error
+}
+
+// VideoPreviewsRepo implements the VideoPreviewsRepository interface using pgx for PostgreSQL interaction.
+type VideoPreviewsRepo struct {
+	db DBTX
+}
+
+// NewVideoPreviewsRepo initializes a new instance of VideoPreviewsRepo.
+func NewVideoPreviewsRepo(db *pgxpool.Pool) *VideoPreviewsRepo {
+	return &VideoPreviewsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *VideoPreviewsRepo) WithTx(tx pgx.Tx) *VideoPreviewsRepo {
+	return &VideoPreviewsRepo{db: tx}
+}
+
+// Create inserts a pending preview.
+func (r *VideoPreviewsRepo) Create(p *VideoPreview) error {
+	now := time.Now()
+	if p.Status == "" {
+		p.Status = PreviewPending
+	}
+	p.CreatedAt, p.UpdatedAt = now, now
+	query := `INSERT INTO video_previews (` + videoPreviewColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.Exec(context.Background(), query, p.ThumbnailID, p.Status, p.StoragePrefix, p.IntervalSeconds,
+		p.Frames, p.Size, p.CreatedAt, p.UpdatedAt)
+	return err
+}
+
+// GetByThumbnailID retrieves the preview of the content using a thumbnail.
+func (r *VideoPreviewsRepo) GetByThumbnailID(thumbnailID uuid.UUID) (*VideoPreview, error) {
+	p := &VideoPreview{}
+	query := `SELECT ` + videoPreviewColumns + ` FROM video_previews WHERE thumbnail_id = $1`
+	err := scanVideoPreview(r.db.QueryRow(context.Background(), query, thumbnailID), p)
+	return p, err
+}
+
+// Update saves the status, interval, frame count and size of a preview.
+func (r *VideoPreviewsRepo) Update(p *VideoPreview) error {
+	p.UpdatedAt = time.Now()
+	query := `
+        UPDATE video_previews SET status = $2, interval_seconds = $3, frames = $4, size = $5, updated_at = $6
+        WHERE thumbnail_id = $1`
+	_, err := r.db.Exec(context.Background(), query, p.ThumbnailID, p.Status, p.IntervalSeconds, p.Frames, p.Size, p.UpdatedAt)
+	return err
+}
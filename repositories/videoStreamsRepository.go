@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Statuses of a video stream.
+const (
+	StreamPending = "pending" // Its transcoding job hasn't run yet, or is being retried.
+	StreamReady   = "ready"
+	StreamFailed  = "failed" // Its transcoding job died.
+)
+
+// VideoStream model represents the structure of the "video_streams" table.
+// A stream holds the HLS renditions of a video content, which is identified by its thumbnail.
+type VideoStream struct {
+	ThumbnailID   uuid.UUID `db:"thumbnail_id"`
+	Status        string    `db:"status"`
+	Renditions    []string  `db:"renditions"` // Such as 360p, from the lowest quality.
+	StoragePrefix string    `db:"storage_prefix"`
+	Size          int64     `db:"size"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// videoStreamColumns lists the columns scanned by scanVideoStream, in order.
+const videoStreamColumns = "thumbnail_id, status, renditions, storage_prefix, size, created_at, updated_at"
+
+// scanVideoStream reads a row selected with videoStreamColumns into s.
+func scanVideoStream(row pgx.Row, s *VideoStream) error {
+	return row.Scan(&s.ThumbnailID, &s.Status, &s.Renditions, &s.StoragePrefix, &s.Size, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// VideoStreamsRepository interface exposes CRUD operations for video streams.
+type VideoStreamsRepository interface {
+	Create(s *VideoStream) error
+	GetByThumbnailID(thumbnailID uuid.UUID) (*VideoStream, error)
+	Update(s *VideoStream) error
+}
+
+// VideoStreamsRepo implements the VideoStreamsRepository interface using pgx for PostgreSQL interaction.
+type VideoStreamsRepo struct {
+	db DBTX
+}
+
+// NewVideoStreamsRepo initializes a new instance of VideoStreamsRepo.
+func NewVideoStreamsRepo(db *pgxpool.Pool) *VideoStreamsRepo {
+	return &VideoStreamsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *VideoStreamsRepo) WithTx(tx pgx.Tx) *VideoStreamsRepo {
+	return &VideoStreamsRepo{db: tx}
+}
+
+// Create inserts a pending stream.
+func (r *VideoStreamsRepo) Create(s *VideoStream) error {
+	now := time.Now()
+	if s.Status == "" {
+		s.Status = StreamPending
+	}
+	if s.Renditions == nil {
+		s.Renditions = []string{}
+	}
+	s.CreatedAt, s.UpdatedAt = now, now
+	query := `INSERT INTO video_streams (` + videoStreamColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(context.Background(), query, s.ThumbnailID, s.Status, s.Renditions, s.StoragePrefix, s.Size,
+		s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+// GetByThumbnailID retrieves the stream of the content using a thumbnail.
+func (r *VideoStreamsRepo) GetByThumbnailID(thumbnailID uuid.UUID) (*VideoStream, error) {
+	s := &VideoStream{}
+	query := `SELECT ` + videoStreamColumns + ` FROM video_streams WHERE thumbnail_id = $1`
+	err := scanVideoStream(r.db.QueryRow(context.Background(), query, thumbnailID), s)
+	return s, err
+}
+
+// Update saves the status, renditions and size of a stream.
+func (r *VideoStreamsRepo) Update(s *VideoStream) error {
+	s.UpdatedAt = time.Now()
+	if s.Renditions == nil {
+		s.Renditions = []string{}
+	}
+	query := `UPDATE video_streams SET status = $2, renditions = $3, size = $4, updated_at = $5 WHERE thumbnail_id = $1`
+	_, err := r.db.Exec(context.Background(), query, s.ThumbnailID, s.Status, s.Renditions, s.Size, s.UpdatedAt)
+	return err
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Waveform model represents the structure of the "waveforms" table.
+// A waveform holds the peaks of an audio content, which is identified by its thumbnail.
+type Waveform struct {
+	ThumbnailID uuid.UUID `db:"thumbnail_id" json:"-"`
+	Duration    float64   `db:"duration"` // In seconds.
+	Peaks       []int     `db:"peaks"`    // From 0 to 100, evenly spread over Duration.
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// waveformColumns lists the columns scanned by scanWaveform, in order.
+const waveformColumns = "thumbnail_id, duration, peaks, created_at"
+
+// scanWaveform reads a row selected with waveformColumns into w.
+func scanWaveform(row pgx.Row, w *Waveform) error {
+	return row.Scan(&w.ThumbnailID, &w.Duration, &w.Peaks, &w.CreatedAt)
+}
+
+// WaveformsRepository interface exposes CRUD operations for waveforms.
+type WaveformsRepository interface {
+	Save(w *Waveform) error
+	GetByThumbnailID(thumbnailID uuid.UUID) (*Waveform, error)
+}
+
+// WaveformsRepo implements the WaveformsRepository interface using pgx for PostgreSQL interaction.
+type WaveformsRepo struct {
+	db DBTX
+}
+
+// NewWaveformsRepo initializes a new instance of WaveformsRepo.
+func NewWaveformsRepo(db *pgxpool.Pool) *WaveformsRepo {
+	return &WaveformsRepo{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *WaveformsRepo) WithTx(tx pgx.Tx) *WaveformsRepo {
+	return &WaveformsRepo{db: tx}
+}
+
+// Save inserts the waveform of a content, or replaces the one it already has.
+func (r *WaveformsRepo) Save(w *Waveform) error {
+	w.CreatedAt = time.Now()
+	query := `
+        INSERT INTO waveforms (` + waveformColumns + `) VALUES ($1, $2, $3, $4)
+        ON CONFLICT (thumbnail_id) DO UPDATE
+        SET duration = EXCLUDED.duration, peaks = EXCLUDED.peaks, created_at = EXCLUDED.created_at`
+	_, err := r.db.Exec(context.Background(), query, w.ThumbnailID, w.Duration, w.Peaks, w.CreatedAt)
+	return err
+}
+
+// GetByThumbnailID retrieves the waveform of the content using a thumbnail.
+func (r *WaveformsRepo) GetByThumbnailID(thumbnailID uuid.UUID) (*Waveform, error) {
+	w := &Waveform{}
+	query := `SELECT ` + waveformColumns + ` FROM waveforms WHERE thumbnail_id = $1`
+	err := scanWaveform(r.db.QueryRow(context.Background(), query, thumbnailID), w)
+	return w, err
+}
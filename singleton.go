@@ -2,11 +2,15 @@ package boxed
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/David/Boxed/internal/storage"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
@@ -16,6 +20,16 @@ type singleton struct {
 	BackendPort int
 	FolderPath  string
 	JwtSecret   string
+	// Storage holds the files and thumbnails. FolderPath is still used as local scratch space.
+	Storage storage.Backend
+	// UploadExpiration is how long an unfinished resumable upload is kept after its last chunk.
+	UploadExpiration time.Duration
+	// TrashRetention is how long a deleted file stays in the trash before being purged.
+	TrashRetention time.Duration
+	// DefaultQuota is the storage quota in bytes of the users without their own, 0 means unlimited.
+	DefaultQuota int64
+	// StreamVideos enables the transcoding of uploaded videos to HLS.
+	StreamVideos bool
 }
 
 var (
@@ -51,6 +65,39 @@ func GetInstance() *singleton {
 		if err != nil {
 			log.Fatal("Error while converting the backendPort to an integer")
 		}
+		// Optional variables
+		uploadExpiration := 24 * time.Hour
+		if raw := os.Getenv("UPLOAD_EXPIRATION"); raw != "" {
+			uploadExpiration, err = time.ParseDuration(raw)
+			if err != nil {
+				log.Fatal("UPLOAD_EXPIRATION must be a duration such as `24h`. Info:", err)
+			}
+		}
+		trashRetention := 30 * 24 * time.Hour
+		if raw := os.Getenv("TRASH_RETENTION"); raw != "" {
+			trashRetention, err = time.ParseDuration(raw)
+			if err != nil {
+				log.Fatal("TRASH_RETENTION must be a duration such as `720h`. Info:", err)
+			}
+		}
+		var defaultQuota int64
+		if raw := os.Getenv("DEFAULT_QUOTA"); raw != "" {
+			defaultQuota, err = parseByteSize(raw)
+			if err != nil {
+				log.Fatal("DEFAULT_QUOTA must be a size such as `10GB`. Info:", err)
+			}
+		}
+		var streamVideos bool
+		if raw := os.Getenv("HLS_ENABLED"); raw != "" {
+			streamVideos, err = strconv.ParseBool(raw)
+			if err != nil {
+				log.Fatal("HLS_ENABLED must be `true` or `false`. Info:", err)
+			}
+		}
+		backend, err := newStorageBackend(folderPath)
+		if err != nil {
+			log.Fatal("Error while setting up the storage backend. Info:", err)
+		}
 		// Make the connection
 		config, err := pgxpool.ParseConfig(dbUrl)
 		if err != nil {
@@ -67,7 +114,64 @@ func GetInstance() *singleton {
 			BackendPort: backendPort,
 			FolderPath:  folderPath,
 			JwtSecret:   jwtSecret,
+			Storage:     backend,
+
+			UploadExpiration: uploadExpiration,
+			TrashRetention:   trashRetention,
+			DefaultQuota:     defaultQuota,
+			StreamVideos:     streamVideos,
 		}
 	})
 	return instance
 }
+
+// newStorageBackend builds the backend selected by STORAGE_BACKEND: `local` (the default) stores
+// objects below FOLDER_PATH, `s3` stores them in an S3-compatible bucket configured by the S3_* variables.
+func newStorageBackend(folderPath string) (storage.Backend, error) {
+	switch os.Getenv("STORAGE_BACKEND") {
+	case "", "local":
+		return storage.NewLocal(folderPath)
+	case "s3":
+		pathStyle := true
+		if raw := os.Getenv("S3_PATH_STYLE"); raw != "" {
+			var err error
+			if pathStyle, err = strconv.ParseBool(raw); err != nil {
+				return nil, err
+			}
+		}
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: pathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q, expected `local` or `s3`", os.Getenv("STORAGE_BACKEND"))
+	}
+}
+
+// parseByteSize parses a size in bytes, optionally followed by a `KB`, `MB`, `GB` or `TB` unit (powers of 1024).
+func parseByteSize(raw string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	raw = strings.ToUpper(strings.TrimSpace(raw))
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(raw, unit.suffix) {
+			raw, factor = strings.TrimSpace(strings.TrimSuffix(raw, unit.suffix)), unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("size can't be negative")
+	}
+	return n * factor, nil
+}